@@ -0,0 +1,126 @@
+package krcrypt
+
+// Helpers for the package's AEAD modes
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"sync"
+)
+
+// ErrTagSize is returned by OpenDetached for a tag of the wrong length
+var ErrTagSize = errors.New("krcrypt: wrong authentication tag size")
+
+// SealDetached is aead.Seal for wire formats that keep the authentication tag
+// apart from the ciphertext.  It works with any AEAD that appends its tag, as
+// GCM does, so with NewGCM and NewCamelliaGCM.  Concatenating the results
+// gives exactly what Seal returns.
+func SealDetached(aead cipher.AEAD, nonce, plaintext, aad []byte) (ciphertext, tag []byte) {
+	sealed := aead.Seal(nil, nonce, plaintext, aad)
+	n := len(sealed) - aead.Overhead()
+	return sealed[:n:n], sealed[n:]
+}
+
+// OpenDetached authenticates and decrypts a ciphertext and tag from
+// SealDetached.  The tag is checked by aead.Open, in constant time, before
+// any plaintext is returned.
+func OpenDetached(aead cipher.AEAD, nonce, ciphertext, tag, aad []byte) ([]byte, error) {
+
+	if len(tag) != aead.Overhead() {
+		return nil, ErrTagSize
+	}
+
+	sealed := make([]byte, 0, len(ciphertext)+len(tag))
+	sealed = append(sealed, ciphertext...)
+	sealed = append(sealed, tag...)
+
+	return aead.Open(sealed[:0], nonce, sealed, aad)
+}
+
+// A ReuseDetectingAEAD wraps an AEAD and panics if Seal is ever called twice
+// with the same nonce, which with GCM gives away the authentication key.  It
+// is for tests and staging, to catch a broken nonce scheme early: every nonce
+// is remembered for the life of the wrapper, so its memory grows without bound
+// and it must not be used in production.  It is safe for concurrent use.
+type ReuseDetectingAEAD struct {
+	cipher.AEAD
+	mu   sync.Mutex
+	seen map[string]struct{}
+}
+
+// NewReuseDetectingAEAD returns a, wrapped to detect nonce reuse.
+func NewReuseDetectingAEAD(a cipher.AEAD) *ReuseDetectingAEAD {
+	return &ReuseDetectingAEAD{AEAD: a, seen: make(map[string]struct{})}
+}
+
+// Seal is the wrapped AEAD's Seal, but panics if nonce has been used before.
+func (r *ReuseDetectingAEAD) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+
+	r.mu.Lock()
+	if _, ok := r.seen[string(nonce)]; ok {
+		r.mu.Unlock()
+		panic("krcrypt: AEAD nonce reused")
+	}
+	r.seen[string(nonce)] = struct{}{}
+	r.mu.Unlock()
+
+	return r.AEAD.Seal(dst, nonce, plaintext, additionalData)
+}
+
+// SealWithHeader encrypts plaintext with SEED-GCM under the 16-byte key,
+// leaving header readable but authenticated, for records with plaintext
+// fields such as IDs or timestamps that need to be searched.  The result is
+// the 4-byte big-endian length of header, header, a random 12-byte nonce,
+// and the ciphertext; the length and header are the associated data.
+func SealWithHeader(key []byte, header, plaintext []byte, opts ...SealOption) ([]byte, error) {
+
+	aead, err := NewGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if uint64(len(header)) > 0xffffffff {
+		return nil, errors.New("krcrypt: header too long")
+	}
+
+	out := make([]byte, 4, 4+len(header)+aead.NonceSize()+len(plaintext)+aead.Overhead())
+	binary.BigEndian.PutUint32(out, uint32(len(header)))
+	out = append(out, header...)
+
+	sealed, err := sealNonceFrom(newSealConfig(opts).rand, aead, plaintext, out)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(out, sealed...), nil
+}
+
+// OpenWithHeader opens a record from SealWithHeader, returning the header and
+// the plaintext.  It fails if either was tampered with.  The header is a
+// subslice of sealed.
+func OpenWithHeader(key []byte, sealed []byte) (header, plaintext []byte, err error) {
+
+	aead, err := NewGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(sealed) < 4 {
+		return nil, nil, ErrCiphertextTooShort
+	}
+	hlen := binary.BigEndian.Uint32(sealed)
+	if uint64(len(sealed)-4) < uint64(hlen) {
+		return nil, nil, ErrCiphertextTooShort
+	}
+
+	ad := sealed[:4+hlen]
+	plaintext, err = openPrefixedNonce(aead, sealed[4+hlen:], ad)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ad[4:], plaintext, nil
+}
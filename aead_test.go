@@ -0,0 +1,170 @@
+package krcrypt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestSealDetached(t *testing.T) {
+
+	nonce := make([]byte, 12)
+	plain := []byte("attack at dawn")
+	aad := []byte("header")
+
+	for _, klen := range []int{16, 32} {
+		key := make([]byte, klen)
+
+		aead, err := NewGCM(key)
+		if klen == 32 {
+			aead, err = NewCamelliaGCM(key)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ct, tag := SealDetached(aead, nonce, plain, aad)
+		if len(tag) != aead.Overhead() {
+			t.Errorf("len(tag)=%d, wanted %d", len(tag), aead.Overhead())
+		}
+
+		combined := append(append([]byte(nil), ct...), tag...)
+		if want := aead.Seal(nil, nonce, plain, aad); !bytes.Equal(combined, want) {
+			t.Errorf("ciphertext||tag=%x, wanted %x", combined, want)
+		}
+
+		if got, err := OpenDetached(aead, nonce, ct, tag, aad); err != nil || !bytes.Equal(got, plain) {
+			t.Errorf("OpenDetached=(%q,%v), wanted %q", got, err, plain)
+		}
+
+		tag[0] ^= 1
+		if _, err := OpenDetached(aead, nonce, ct, tag, aad); err == nil {
+			t.Errorf("OpenDetached accepted a tampered tag")
+		}
+		tag[0] ^= 1
+
+		if _, err := OpenDetached(aead, nonce, ct, tag[:8], aad); err != ErrTagSize {
+			t.Errorf("OpenDetached(short tag)=%v, wanted %v", err, ErrTagSize)
+		}
+
+		// the caller's slices are left alone
+		if !bytes.Equal(combined[:len(ct)], ct) {
+			t.Errorf("OpenDetached modified the ciphertext")
+		}
+	}
+}
+
+func TestReuseDetectingAEAD(t *testing.T) {
+
+	g, _ := NewGCM(make([]byte, 16))
+	r := NewReuseDetectingAEAD(g)
+
+	nonce := make([]byte, 12)
+	plain := []byte("attack at dawn")
+
+	sealed := r.Seal(nil, nonce, plain, nil)
+	if want := g.Seal(nil, nonce, plain, nil); !bytes.Equal(sealed, want) {
+		t.Errorf("Seal=%x, wanted %x", sealed, want)
+	}
+	if got, err := r.Open(nil, nonce, sealed, nil); err != nil || !bytes.Equal(got, plain) {
+		t.Errorf("Open=(%q,%v), wanted %q", got, err, plain)
+	}
+
+	// a fresh nonce is fine
+	nonce[11] = 1
+	r.Seal(nil, nonce, plain, nil)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("reusing a nonce didn't panic")
+		}
+	}()
+	r.Seal(nil, nonce, []byte("retreat at dusk"), nil)
+}
+
+func TestSealWithHeader(t *testing.T) {
+
+	key := []byte("0123456789abcdef")
+	header := []byte(`{"id":1234,"ts":"2012-06-01T12:00:00Z"}`)
+	plain := []byte("the record body")
+
+	sealed, err := SealWithHeader(key, header, plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(sealed, header) || bytes.Contains(sealed, plain) {
+		t.Errorf("sealed record should show the header but not the body: %q", sealed)
+	}
+
+	h, p, err := OpenWithHeader(key, sealed)
+	if err != nil || !bytes.Equal(h, header) || !bytes.Equal(p, plain) {
+		t.Errorf("OpenWithHeader=%q, %q, %v, wanted %q, %q", h, p, err, header, plain)
+	}
+
+	// an empty header works too
+	sealed2, _ := SealWithHeader(key, nil, plain)
+	if h, p, err := OpenWithHeader(key, sealed2); err != nil || len(h) != 0 || !bytes.Equal(p, plain) {
+		t.Errorf("OpenWithHeader(empty header)=%q, %q, %v", h, p, err)
+	}
+
+	for _, i := range []int{0, 3, 4, 10, 4 + len(header), len(sealed) - 1} {
+		bad := append([]byte(nil), sealed...)
+		bad[i] ^= 1
+		if _, _, err := OpenWithHeader(key, bad); err == nil {
+			t.Errorf("OpenWithHeader accepted a record with byte %d changed", i)
+		}
+	}
+
+	if _, _, err := OpenWithHeader(key, sealed[:3]); err != ErrCiphertextTooShort {
+		t.Errorf("OpenWithHeader(3 bytes)=%v, wanted %v", err, ErrCiphertextTooShort)
+	}
+}
+
+func TestWithRand(t *testing.T) {
+
+	key := []byte("0123456789abcdef")
+	nonce := []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
+
+	sealHeader := func(o ...SealOption) ([]byte, error) {
+		return SealWithHeader(key, []byte("hdr"), []byte("hello"), o...)
+	}
+	sealSmall := func(o ...SealOption) ([]byte, error) {
+		return SealSmall(key, []byte("hello"), o...)
+	}
+
+	// with the nonce 000102..0b the outputs are fixed
+	var tests = []struct {
+		name string
+		seal func(...SealOption) ([]byte, error)
+		want string
+	}{
+		{"SealWithHeader", sealHeader, "00000003686472" + "000102030405060708090a0b" + "3d0e4e61cf" + "70f666285ccaeade1054b423b887b316"},
+		{"SealSmall", sealSmall, "000102030405060708090a0b" + "187c5e48e9" + "eab7c4833e8e9975ca25d7706872aab6"},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.seal(WithRand(bytes.NewReader(nonce)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(got) != tt.want {
+			t.Errorf("%s with a fixed nonce=%x, wanted %s", tt.name, got, tt.want)
+		}
+
+		// without the option, the nonce is random
+		if got, _ := tt.seal(); hex.EncodeToString(got) == tt.want {
+			t.Errorf("%s without WithRand used the fixed nonce", tt.name)
+		}
+
+		// a reader that runs dry is an error, not a short nonce
+		if _, err := tt.seal(WithRand(bytes.NewReader(nonce[:5]))); err == nil {
+			t.Errorf("%s with a 5-byte reader succeeded", tt.name)
+		}
+	}
+
+	// the fixed GCM output matches crypto/cipher directly
+	g, _ := NewGCM(key)
+	if ct := g.Seal(nil, nonce, []byte("hello"), []byte("\x00\x00\x00\x03hdr")); hex.EncodeToString(ct) != tests[0].want[38:] {
+		t.Errorf("SealWithHeader vector doesn't match GCM: %x", ct)
+	}
+}
@@ -0,0 +1,211 @@
+package krcrypt
+
+// The Anubis block cipher
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+http://www.larc.usp.br/~pbarreto/AnubisPage.html
+https://www.cosic.esat.kuleuven.be/nessie/tweaks.html (the tweaked S-box)
+
+*/
+
+import "encoding/binary"
+
+// An AnubisCipher is an instance of Anubis encryption using a particular key.
+//
+// Anubis is a NESSIE submission by Barreto and Rijmen, a relative of Rijndael
+// in which every component is an involution, so decryption is encryption with
+// a modified key schedule.  It wasn't selected, and has seen little use or
+// analysis since; it's here primarily for research interest and for
+// interoperability with the few systems that adopted it.  This is the tweaked
+// version, with the S-box built from 4-bit mini-boxes.
+type AnubisCipher struct {
+	ek [][4]uint32 // encryption round keys, one word per row
+	dk [][4]uint32 // decryption round keys
+}
+
+// the S-box, an involution, shared with Khazad
+var anubisSbox = [256]byte{
+	0xba, 0x54, 0x2f, 0x74, 0x53, 0xd3, 0xd2, 0x4d, 0x50, 0xac, 0x8d, 0xbf, 0x70, 0x52, 0x9a, 0x4c,
+	0xea, 0xd5, 0x97, 0xd1, 0x33, 0x51, 0x5b, 0xa6, 0xde, 0x48, 0xa8, 0x99, 0xdb, 0x32, 0xb7, 0xfc,
+	0xe3, 0x9e, 0x91, 0x9b, 0xe2, 0xbb, 0x41, 0x6e, 0xa5, 0xcb, 0x6b, 0x95, 0xa1, 0xf3, 0xb1, 0x02,
+	0xcc, 0xc4, 0x1d, 0x14, 0xc3, 0x63, 0xda, 0x5d, 0x5f, 0xdc, 0x7d, 0xcd, 0x7f, 0x5a, 0x6c, 0x5c,
+	0xf7, 0x26, 0xff, 0xed, 0xe8, 0x9d, 0x6f, 0x8e, 0x19, 0xa0, 0xf0, 0x89, 0x0f, 0x07, 0xaf, 0xfb,
+	0x08, 0x15, 0x0d, 0x04, 0x01, 0x64, 0xdf, 0x76, 0x79, 0xdd, 0x3d, 0x16, 0x3f, 0x37, 0x6d, 0x38,
+	0xb9, 0x73, 0xe9, 0x35, 0x55, 0x71, 0x7b, 0x8c, 0x72, 0x88, 0xf6, 0x2a, 0x3e, 0x5e, 0x27, 0x46,
+	0x0c, 0x65, 0x68, 0x61, 0x03, 0xc1, 0x57, 0xd6, 0xd9, 0x58, 0xd8, 0x66, 0xd7, 0x3a, 0xc8, 0x3c,
+	0xfa, 0x96, 0xa7, 0x98, 0xec, 0xb8, 0xc7, 0xae, 0x69, 0x4b, 0xab, 0xa9, 0x67, 0x0a, 0x47, 0xf2,
+	0xb5, 0x22, 0xe5, 0xee, 0xbe, 0x2b, 0x81, 0x12, 0x83, 0x1b, 0x0e, 0x23, 0xf5, 0x45, 0x21, 0xce,
+	0x49, 0x2c, 0xf9, 0xe6, 0xb6, 0x28, 0x17, 0x82, 0x1a, 0x8b, 0xfe, 0x8a, 0x09, 0xc9, 0x87, 0x4e,
+	0xe1, 0x2e, 0xe4, 0xe0, 0xeb, 0x90, 0xa4, 0x1e, 0x85, 0x60, 0x00, 0x25, 0xf4, 0xf1, 0x94, 0x0b,
+	0xe7, 0x75, 0xef, 0x34, 0x31, 0xd4, 0xd0, 0x86, 0x7e, 0xad, 0xfd, 0x29, 0x30, 0x3b, 0x9f, 0xf8,
+	0xc6, 0x13, 0x06, 0x05, 0xc5, 0x11, 0x77, 0x7c, 0x7a, 0x78, 0x36, 0x1c, 0x39, 0x59, 0x18, 0x56,
+	0xb3, 0xb0, 0x24, 0x20, 0xb2, 0x92, 0xa3, 0xc0, 0x44, 0x62, 0x10, 0xb4, 0x84, 0x43, 0x93, 0xc2,
+	0x4a, 0xbd, 0x8f, 0x2d, 0xbc, 0x9c, 0x6a, 0x40, 0xcf, 0xa2, 0x80, 0x4f, 0x1f, 0xca, 0xaa, 0x42,
+}
+
+// anubisT[i][x] is S[x] times row i of had(01, 02, 04, 06), as a big-endian
+// word; anubisV[x] is x times (01, 02, 06, 08), for the key extraction
+var anubisT [4][256]uint32
+var anubisV [256]uint32
+
+func init() {
+	h := [4]byte{0x01, 0x02, 0x04, 0x06}
+	for x := 0; x < 256; x++ {
+		s := anubisSbox[x]
+		for i := 0; i < 4; i++ {
+			// the Hadamard matrix has h[i^j] in row i, column j, over
+			// the same field as CLEFIA
+			anubisT[i][x] = uint32(clefiaMul(s, h[i]))<<24 | uint32(clefiaMul(s, h[i^1]))<<16 |
+				uint32(clefiaMul(s, h[i^2]))<<8 | uint32(clefiaMul(s, h[i^3]))
+		}
+		b := byte(x)
+		anubisV[x] = uint32(b)<<24 | uint32(clefiaMul(b, 2))<<16 | uint32(clefiaMul(b, 6))<<8 | uint32(clefiaMul(b, 8))
+	}
+}
+
+// NewAnubis creates and returns a new AnubisCipher.  The key must be 16 to 40
+// bytes long, in steps of 4 bytes; longer keys get more rounds, from 12 for a
+// 16-byte key to 18 for a 40-byte one.
+func NewAnubis(key []byte) (*AnubisCipher, error) {
+
+	klen := len(key)
+	if klen < 16 || klen > 40 || klen%4 != 0 {
+		return nil, KeySizeError(klen)
+	}
+
+	n := klen / 4
+	rounds := 8 + n
+
+	// kappa is the N x 4 key state, one word per row
+	kappa := make([]uint32, n)
+	for i := range kappa {
+		kappa[i] = binary.BigEndian.Uint32(key[4*i:])
+	}
+	inter := make([]uint32, n)
+
+	c := &AnubisCipher{
+		ek: make([][4]uint32, rounds+1),
+		dk: make([][4]uint32, rounds+1),
+	}
+
+	for r := 0; r <= rounds; r++ {
+
+		// the round key is V^T times S[kappa], evaluated by Horner's rule
+		// from the last row up; each column of kappa gives a row of K
+		var k [4]uint32
+		for j := uint(0); j < 4; j++ {
+			sh := 24 - 8*j
+			var w uint32
+			for i := n - 1; i >= 0; i-- {
+				s := uint32(anubisSbox[kappa[i]>>sh&0xff])
+				w = s*0x01010101 ^
+					anubisV[w>>24]&0xff000000 ^ anubisV[w>>16&0xff]&0x00ff0000 ^
+					anubisV[w>>8&0xff]&0x0000ff00 ^ anubisV[w&0xff]&0x000000ff
+			}
+			k[j] = w
+		}
+		c.ek[r] = k
+
+		if r == rounds {
+			break
+		}
+
+		// evolve kappa: the S-box, then shift column j down j rows, then
+		// multiply by the Hadamard matrix, then add the round constant
+		for i := 0; i < n; i++ {
+			inter[i] = anubisT[0][kappa[i]>>24] ^
+				anubisT[1][kappa[(i-1+n)%n]>>16&0xff] ^
+				anubisT[2][kappa[(i-2+n)%n]>>8&0xff] ^
+				anubisT[3][kappa[(i-3+n)%n]&0xff]
+		}
+		copy(kappa, inter)
+		kappa[0] ^= binary.BigEndian.Uint32(anubisSbox[4*r:])
+	}
+
+	// decryption runs the same rounds with the keys reversed and, except
+	// for the first and last, passed through theta
+	c.dk[0] = c.ek[rounds]
+	c.dk[rounds] = c.ek[0]
+	for r := 1; r < rounds; r++ {
+		c.dk[r] = anubisTheta(c.ek[rounds-r])
+	}
+
+	return c, nil
+}
+
+// anubisTheta multiplies each row of k by the Hadamard matrix
+func anubisTheta(k [4]uint32) [4]uint32 {
+	var t [4]uint32
+	for i, w := range k {
+		// anubisT includes the S-box, so undo it first; it's an involution
+		t[i] = anubisT[0][anubisSbox[w>>24]] ^ anubisT[1][anubisSbox[w>>16&0xff]] ^
+			anubisT[2][anubisSbox[w>>8&0xff]] ^ anubisT[3][anubisSbox[w&0xff]]
+	}
+	return t
+}
+
+// Reset zeroes the encryption and decryption round keys.
+func (c *AnubisCipher) Reset() {
+	for i := range c.ek {
+		c.ek[i] = [4]uint32{}
+		c.dk[i] = [4]uint32{}
+	}
+}
+
+// IsWiped reports whether the encryption and decryption round keys are all zero.
+func (c *AnubisCipher) IsWiped() bool {
+	for i := range c.ek {
+		if c.ek[i] != [4]uint32{} || c.dk[i] != [4]uint32{} {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *AnubisCipher) BlockSize() int { return 16 }
+
+// Encrypt encrypts the 16-byte block in src and stores the resulting ciphertext in dst.
+func (c *AnubisCipher) Encrypt(dst, src []byte) { anubisCrypt(dst, src, c.ek) }
+
+// Decrypt decrypts the 16-byte block in src and stores the resulting plaintext in dst.
+func (c *AnubisCipher) Decrypt(dst, src []byte) { anubisCrypt(dst, src, c.dk) }
+
+func anubisCrypt(dst, src []byte, rk [][4]uint32) {
+
+	var s [4]uint32
+	for i := range s {
+		s[i] = binary.BigEndian.Uint32(src[4*i:]) ^ rk[0][i]
+	}
+
+	last := len(rk) - 1
+
+	// each round is the S-box, a transposition, theta, and the round key;
+	// column i of the state becomes row i before theta mixes it
+	for r := 1; r < last; r++ {
+		var t [4]uint32
+		for i := uint(0); i < 4; i++ {
+			sh := 24 - 8*i
+			t[i] = anubisT[0][s[0]>>sh&0xff] ^ anubisT[1][s[1]>>sh&0xff] ^
+				anubisT[2][s[2]>>sh&0xff] ^ anubisT[3][s[3]>>sh&0xff] ^ rk[r][i]
+		}
+		s = t
+	}
+
+	// the last round has no theta
+	var t [4]uint32
+	for i := uint(0); i < 4; i++ {
+		sh := 24 - 8*i
+		t[i] = uint32(anubisSbox[s[0]>>sh&0xff])<<24 | uint32(anubisSbox[s[1]>>sh&0xff])<<16 |
+			uint32(anubisSbox[s[2]>>sh&0xff])<<8 | uint32(anubisSbox[s[3]>>sh&0xff])
+		t[i] ^= rk[last][i]
+	}
+
+	for i := range t {
+		binary.BigEndian.PutUint32(dst[4*i:], t[i])
+	}
+}
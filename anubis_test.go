@@ -0,0 +1,60 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+var anubisTestVectors = []struct {
+	key    []byte
+	plain  []byte
+	cipher []byte
+}{
+	// NESSIE set 1, vector 0
+	{
+		[]byte{0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0xb8, 0x35, 0xbd, 0xc3, 0x34, 0x82, 0x9d, 0x83, 0x71, 0xbf, 0xa3, 0x71, 0xe4, 0xb3, 0xc4, 0xfd},
+	},
+	// from the Linux kernel's tcrypt
+	{
+		bytes.Repeat([]byte{0xfe}, 16),
+		bytes.Repeat([]byte{0xfe}, 16),
+		[]byte{0x6d, 0xc5, 0xda, 0xa2, 0x26, 0x7d, 0x62, 0x6f, 0x08, 0xb7, 0x52, 0x8e, 0x6e, 0x6e, 0x86, 0x90},
+	},
+}
+
+func TestAnubis(t *testing.T) {
+
+	for _, v := range anubisTestVectors {
+		c, err := NewAnubis(v.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var b [16]byte
+		c.Encrypt(b[:], v.plain)
+		if !bytes.Equal(b[:], v.cipher) {
+			t.Errorf("anubis encrypt failed: got %#v wanted %#v\n", b, v.cipher)
+		}
+
+		c.Decrypt(b[:], b[:])
+		if !bytes.Equal(b[:], v.plain) {
+			t.Errorf("anubis decrypt failed: got %#v wanted %#v\n", b, v.plain)
+		}
+	}
+
+	for _, klen := range []int{12, 18, 44} {
+		if _, err := NewAnubis(make([]byte, klen)); err != KeySizeError(klen) {
+			t.Errorf("NewAnubis(%d-byte key)=%v, wanted %v", klen, err, KeySizeError(klen))
+		}
+	}
+}
+
+func TestAnubisSbox(t *testing.T) {
+	for x := 0; x < 256; x++ {
+		if anubisSbox[anubisSbox[x]] != byte(x) {
+			t.Fatalf("anubis S-box isn't an involution at %#x", x)
+		}
+	}
+}
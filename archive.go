@@ -0,0 +1,303 @@
+package krcrypt
+
+// An encrypted multi-file container using SEED-GCM
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// ErrArchive is returned when an archive is malformed, truncated, tampered
+// with, or encrypted under a different key.
+var ErrArchive = errors.New("krcrypt: corrupt or truncated archive")
+
+// the archive header: magic and version
+var archiveHeader = []byte{'K', 'R', 'A', 'R', 1}
+
+// record types
+const (
+	archiveEnd   = 0
+	archiveEntry = 1
+)
+
+// the largest sealed entry OpenArchive will accept
+const archiveMaxEntry = 1 << 30
+
+// An ArchiveEntry is one named file from an archive.
+type ArchiveEntry struct {
+	Name string
+	Data []byte
+}
+
+// An ArchiveWriter writes an encrypted archive of named entries.  The format
+// is the 5-byte header "KRAR\x01", then for each entry a type byte of 1, a
+// 4-byte big-endian length, and the SEED-GCM sealed entry (a random 12-byte
+// nonce, then the encrypted 2-byte name length, name, and data, then the
+// tag), and finally a type byte of 0, the 8-byte entry count, and a SEED-CMAC
+// of everything before it.
+//
+// Each entry's position is authenticated along with it, and the final MAC
+// covers the whole archive, so entries can't be reordered, dropped, or
+// truncated away without OpenArchive noticing.  The key must be 16 bytes;
+// separate keys for GCM and CMAC are derived from it.
+type ArchiveWriter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	mac    *CMAC
+	n      uint64 // entries written
+	closed bool
+	err    error
+}
+
+// archiveKeys derives the entry encryption and the archive MAC from the key
+func archiveKeys(key []byte) (cipher.AEAD, *CMAC, error) {
+
+	kdf, err := NewCMAC(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kdf.Write([]byte("\x01krcrypt archive encryption"))
+	aead, err := NewGCM(kdf.Sum(nil))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kdf.Reset()
+	kdf.Write([]byte("\x02krcrypt archive mac"))
+	mac, err := NewCMAC(kdf.Sum(nil))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return aead, mac, nil
+}
+
+// NewArchiveWriter returns an ArchiveWriter writing to w, after writing the header.
+func NewArchiveWriter(key []byte, w io.Writer) (*ArchiveWriter, error) {
+
+	aead, mac, err := archiveKeys(key)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &ArchiveWriter{w: w, aead: aead, mac: mac}
+	if err := a.write(archiveHeader); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// write writes b to the underlying writer and through the MAC
+func (a *ArchiveWriter) write(b []byte) error {
+	a.mac.Write(b)
+	_, err := a.w.Write(b)
+	return err
+}
+
+// archiveAD is the associated data for entry n: the header and the position
+func archiveAD(n uint64) []byte {
+	ad := make([]byte, len(archiveHeader)+8)
+	copy(ad, archiveHeader)
+	binary.BigEndian.PutUint64(ad[len(archiveHeader):], n)
+	return ad
+}
+
+// Add encrypts and writes an entry.  Names may be up to 65535 bytes, and the
+// sealed entry, the name and data plus 30 bytes of length, nonce and tag, may
+// be up to 1GB, the most OpenArchive will read.  An entry that is too large is
+// rejected without writing anything, so the archive can still be added to.
+func (a *ArchiveWriter) Add(name string, data []byte) error {
+
+	if a.err != nil {
+		return a.err
+	}
+	if a.closed {
+		return ErrClosed
+	}
+	if len(name) > 0xffff {
+		return errors.New("krcrypt: archive entry name too long")
+	}
+	if uint64(2+len(name)+len(data)+a.aead.NonceSize()+a.aead.Overhead()) > archiveMaxEntry {
+		return errors.New("krcrypt: archive entry too large")
+	}
+
+	plain := make([]byte, 2, 2+len(name)+len(data))
+	binary.BigEndian.PutUint16(plain, uint16(len(name)))
+	plain = append(plain, name...)
+	plain = append(plain, data...)
+
+	sealed, err := sealRandomNonce(a.aead, plain, archiveAD(a.n))
+	if err != nil {
+		a.err = err
+		return err
+	}
+
+	var hdr [5]byte
+	hdr[0] = archiveEntry
+	binary.BigEndian.PutUint32(hdr[1:], uint32(len(sealed)))
+
+	if err := a.write(hdr[:]); err != nil {
+		a.err = err
+		return err
+	}
+	if err := a.write(sealed); err != nil {
+		a.err = err
+		return err
+	}
+
+	a.n++
+	return nil
+}
+
+// Close writes the trailer with the MAC over the whole archive.  It does not
+// close the underlying writer.
+func (a *ArchiveWriter) Close() error {
+
+	if a.err != nil {
+		return a.err
+	}
+	if a.closed {
+		return ErrClosed
+	}
+	a.closed = true
+
+	var end [9]byte
+	end[0] = archiveEnd
+	binary.BigEndian.PutUint64(end[1:], a.n)
+	if err := a.write(end[:]); err != nil {
+		a.err = err
+		return err
+	}
+
+	_, err := a.w.Write(a.mac.Sum(nil))
+	a.err = err
+	return err
+}
+
+// EncryptArchive writes a complete archive holding the single entry name to w.
+// Use an ArchiveWriter for archives of several entries.
+func EncryptArchive(key []byte, w io.Writer, name string, data []byte) error {
+
+	a, err := NewArchiveWriter(key, w)
+	if err != nil {
+		return err
+	}
+
+	if err := a.Add(name, data); err != nil {
+		return err
+	}
+
+	return a.Close()
+}
+
+// OpenArchive reads, authenticates, and decrypts an archive written by
+// EncryptArchive or an ArchiveWriter, returning its entries in order.  Nothing
+// is returned unless the whole archive, up to the end of r, checks out.
+func OpenArchive(key []byte, r io.Reader) ([]ArchiveEntry, error) {
+
+	aead, mac, err := archiveKeys(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// read everything through the MAC
+	tr := io.TeeReader(r, mac)
+	readFull := func(b []byte) error {
+		if _, err := io.ReadFull(tr, b); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return ErrArchive
+			}
+			return err
+		}
+		return nil
+	}
+
+	hdr := make([]byte, len(archiveHeader))
+	if err := readFull(hdr); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(hdr, archiveHeader) {
+		return nil, ErrArchive
+	}
+
+	var entries []ArchiveEntry
+
+	for {
+		var typ [1]byte
+		if err := readFull(typ[:]); err != nil {
+			return nil, err
+		}
+
+		if typ[0] == archiveEnd {
+			break
+		}
+		if typ[0] != archiveEntry {
+			return nil, ErrArchive
+		}
+
+		var l [4]byte
+		if err := readFull(l[:]); err != nil {
+			return nil, err
+		}
+		n := binary.BigEndian.Uint32(l[:])
+		if n > archiveMaxEntry {
+			return nil, ErrArchive
+		}
+
+		// n is untrusted, so grow the buffer as the data arrives rather
+		// than allocating it all up front
+		sealed, err := io.ReadAll(io.LimitReader(tr, int64(n)))
+		if err != nil {
+			return nil, err
+		}
+		if len(sealed) != int(n) {
+			return nil, ErrArchive
+		}
+
+		plain, err := openPrefixedNonce(aead, sealed, archiveAD(uint64(len(entries))))
+		if err != nil || len(plain) < 2 {
+			return nil, ErrArchive
+		}
+
+		nl := int(binary.BigEndian.Uint16(plain))
+		if 2+nl > len(plain) {
+			return nil, ErrArchive
+		}
+
+		entries = append(entries, ArchiveEntry{
+			Name: string(plain[2 : 2+nl]),
+			Data: plain[2+nl:],
+		})
+	}
+
+	var count [8]byte
+	if err := readFull(count[:]); err != nil {
+		return nil, err
+	}
+
+	want := mac.Sum(nil)
+
+	// the MAC itself and anything after it are read directly
+	tag := make([]byte, len(want)+1)
+	n, err := io.ReadFull(r, tag)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	if n != len(want) || subtle.ConstantTimeCompare(tag[:n], want) != 1 {
+		return nil, ErrArchive
+	}
+
+	if binary.BigEndian.Uint64(count[:]) != uint64(len(entries)) {
+		return nil, ErrArchive
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,146 @@
+package krcrypt
+
+import (
+	"bytes"
+	"math/bits"
+	"runtime"
+	"testing"
+)
+
+func TestArchive(t *testing.T) {
+
+	key := []byte("YELLOW SUBMARINE")
+
+	files := []ArchiveEntry{
+		{"README", []byte("hello, world\n")},
+		{"empty", []byte{}},
+		{"data/zeros", make([]byte, 1000)},
+	}
+
+	var buf bytes.Buffer
+	a, err := NewArchiveWriter(key, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if err := a.Add(f.Name, f.Data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Add("late", nil); err != ErrClosed {
+		t.Errorf("Add after Close=%v, wanted %v", err, ErrClosed)
+	}
+
+	archive := buf.Bytes()
+
+	entries, err := OpenArchive(key, bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(files) {
+		t.Fatalf("got %d entries, wanted %d", len(entries), len(files))
+	}
+	for i, e := range entries {
+		if e.Name != files[i].Name || !bytes.Equal(e.Data, files[i].Data) {
+			t.Errorf("entry %d=%q (%d bytes), wanted %q (%d bytes)", i, e.Name, len(e.Data), files[i].Name, len(files[i].Data))
+		}
+	}
+
+	// every truncation is caught
+	for n := 0; n < len(archive); n++ {
+		if _, err := OpenArchive(key, bytes.NewReader(archive[:n])); err != ErrArchive {
+			t.Fatalf("OpenArchive(truncated to %d bytes)=%v, wanted %v", n, err, ErrArchive)
+		}
+	}
+
+	// as is every single-bit change
+	bad := append([]byte(nil), archive...)
+	for i := 0; i < len(bad); i++ {
+		bad[i] ^= 0x10
+		if _, err := OpenArchive(key, bytes.NewReader(bad)); err != ErrArchive {
+			t.Fatalf("OpenArchive(byte %d changed)=%v, wanted %v", i, err, ErrArchive)
+		}
+		bad[i] ^= 0x10
+	}
+
+	if _, err := OpenArchive(key, bytes.NewReader(append(bad, 0))); err != ErrArchive {
+		t.Errorf("OpenArchive(trailing data)=%v, wanted %v", err, ErrArchive)
+	}
+
+	if _, err := OpenArchive([]byte("YELLOW SUBMARINF"), bytes.NewReader(archive)); err != ErrArchive {
+		t.Errorf("OpenArchive(wrong key)=%v, wanted %v", err, ErrArchive)
+	}
+}
+
+func TestEncryptArchive(t *testing.T) {
+
+	key := []byte("YELLOW SUBMARINE")
+
+	var buf bytes.Buffer
+	if err := EncryptArchive(key, &buf, "secret.txt", []byte("attack at dawn")); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := OpenArchive(key, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name != "secret.txt" || string(entries[0].Data) != "attack at dawn" {
+		t.Errorf("OpenArchive=%+v", entries)
+	}
+}
+
+func TestOpenArchiveHugeLength(t *testing.T) {
+
+	// a header claiming a 1GB entry, with nothing behind it
+	data := append(append([]byte(nil), archiveHeader...), archiveEntry, 0x40, 0, 0, 0)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err := OpenArchive([]byte("0123456789abcdef"), bytes.NewReader(data))
+	runtime.ReadMemStats(&after)
+
+	if err != ErrArchive {
+		t.Errorf("OpenArchive(huge entry)=%v, wanted %v", err, ErrArchive)
+	}
+	if grew := after.TotalAlloc - before.TotalAlloc; grew > 1<<20 {
+		t.Errorf("OpenArchive(huge entry) allocated %d bytes", grew)
+	}
+}
+
+func TestArchiveAddTooLarge(t *testing.T) {
+
+	// the slice is never written, so on a 64-bit host it costs address
+	// space but not memory
+	if bits.UintSize < 64 || runtime.GOOS == "js" {
+		t.Skip("needs a 1GB slice")
+	}
+
+	var buf bytes.Buffer
+	a, err := NewArchiveWriter([]byte("0123456789abcdef"), &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// one byte more than OpenArchive will read, once sealed
+	huge := make([]byte, archiveMaxEntry-2-12-16+1)
+	if err := a.Add("", huge); err == nil {
+		t.Fatal("Add(entry over 1GB) succeeded")
+	}
+
+	// nothing was written, and the archive is still usable
+	if err := a.Add("small", []byte("ok")); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := OpenArchive([]byte("0123456789abcdef"), &buf)
+	if err != nil || len(entries) != 1 || entries[0].Name != "small" {
+		t.Errorf("OpenArchive=%v, %v", entries, err)
+	}
+}
@@ -149,6 +149,17 @@ func NewARIA(key []byte) (cipher.Block, error) {
 	return c, nil
 }
 
+// Reset zeroes the encryption and decryption round keys.
+func (c *ariaCipher) Reset() {
+	c.ek = [17][16]byte{}
+	c.dk = [17][16]byte{}
+}
+
+// IsWiped reports whether the encryption and decryption round keys are all zero.
+func (c *ariaCipher) IsWiped() bool {
+	return c.ek == [17][16]byte{} && c.dk == [17][16]byte{}
+}
+
 func (c *ariaCipher) BlockSize() int          { return 16 }
 func (c *ariaCipher) Encrypt(dst, src []byte) { process(dst, src, c.ek[:], c.rounds) }
 func (c *ariaCipher) Decrypt(dst, src []byte) { process(dst, src, c.dk[:], c.rounds) }
@@ -0,0 +1,143 @@
+package krcrypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+)
+
+// from Eric Young's test vectors, as published by Schneier
+var blowfishTestVectors = []struct {
+	key    string
+	plain  string
+	cipher string
+}{
+	{"0000000000000000", "0000000000000000", "4ef997456198dd78"},
+	{"ffffffffffffffff", "ffffffffffffffff", "51866fd5b85ecb8a"},
+	{"3000000000000000", "1000000000000001", "7d856f9a613063f2"},
+	{"1111111111111111", "1111111111111111", "2466dd878b963c9d"},
+	{"0123456789abcdef", "1111111111111111", "61f9c3802281b096"},
+	{"fedcba9876543210", "0123456789abcdef", "0aceab0fc6a0a28d"},
+}
+
+func TestBlowfish(t *testing.T) {
+
+	for _, v := range blowfishTestVectors {
+		key, _ := hex.DecodeString(v.key)
+		plain, _ := hex.DecodeString(v.plain)
+		want, _ := hex.DecodeString(v.cipher)
+
+		c, err := NewBlowfish(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var b [8]byte
+		c.Encrypt(b[:], plain)
+		if !bytes.Equal(b[:], want) {
+			t.Errorf("blowfish encrypt failed: got %#v wanted %#v\n", b, want)
+		}
+
+		c.Decrypt(b[:], b[:])
+		if !bytes.Equal(b[:], plain) {
+			t.Errorf("blowfish decrypt failed: got %#v wanted %#v\n", b, plain)
+		}
+	}
+
+	for _, klen := range []int{3, 57} {
+		if _, err := NewBlowfish(make([]byte, klen)); err != KeySizeError(klen) {
+			t.Errorf("NewBlowfish(%d-byte key)=%v, wanted %v", klen, err, KeySizeError(klen))
+		}
+	}
+}
+
+// the encryption of a zero block after the salted key schedule, with the key
+// 00 01 .. 1f and the salt the first i bytes of 20 21 .., from OpenSSH
+var blowfishSaltedVectors = []string{
+	"0c823b7b8d014b7e",
+	"d1e193f070a6db12",
+	"fc5ebadecbf859ad",
+	"8a0c76e7dd2cd3a8",
+	"2ccb7beeac7b7ff8",
+	"bbf6306fe15d62bf",
+}
+
+func TestBlowfishSalted(t *testing.T) {
+
+	var key, salt [32]byte
+	for i := range key {
+		key[i] = byte(i)
+		salt[i] = byte(i + 32)
+	}
+
+	for i, v := range blowfishSaltedVectors {
+		c, err := NewBlowfishSalted(key[:], salt[:i])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var b [8]byte
+		c.Encrypt(b[:], b[:])
+		if got := hex.EncodeToString(b[:]); got != v {
+			t.Errorf("salted blowfish %d failed: got %s wanted %s\n", i, got, v)
+		}
+	}
+
+	// an empty salt is the plain key schedule
+	c1, _ := NewBlowfishSalted(key[:], nil)
+	c2, _ := NewBlowfish(key[:])
+	if c1.P() != c2.P() || c1.S() != c2.S() {
+		t.Errorf("salted blowfish with no salt differs from plain blowfish")
+	}
+
+	if _, err := NewBlowfishSalted(make([]byte, 72), salt[:]); err != nil {
+		t.Errorf("NewBlowfishSalted(72-byte key)=%v", err)
+	}
+	if _, err := NewBlowfishSalted(nil, salt[:]); err != KeySizeError(0) {
+		t.Errorf("NewBlowfishSalted(empty key)=%v, wanted %v", err, KeySizeError(0))
+	}
+}
+
+// bcrypt's variant of base64, without padding
+var bcryptEncoding = base64.NewEncoding("./ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789").WithPadding(base64.NoPadding)
+
+func TestExpandKeyWithSalt(t *testing.T) {
+
+	// $2a$10$XajjQvNhvvRt5GSeFk1xFeyqRrsxkhBkUiQeg0dt.wU1qD4aFDcga is the
+	// bcrypt hash of "allmine"
+	salt, err := bcryptEncoding.DecodeString("XajjQvNhvvRt5GSeFk1xFe")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := ExpandKeyWithSalt([]byte("allmine\x00"), salt, 10)
+
+	data := []byte("OrpheanBeholderScryDoubt")
+	for i := 0; i < len(data); i += 8 {
+		for j := 0; j < 64; j++ {
+			c.Encrypt(data[i:i+8], data[i:i+8])
+		}
+	}
+
+	// bcrypt only encodes the first 23 bytes
+	if got, want := bcryptEncoding.EncodeToString(data[:23]), "yqRrsxkhBkUiQeg0dt.wU1qD4aFDcga"; got != want {
+		t.Errorf("bcrypt failed: got %s wanted %s\n", got, want)
+	}
+
+	// cost 0 is one round of expanding the key and then the salt
+	c1 := ExpandKeyWithSalt([]byte("key"), []byte("salt"), 0)
+	c2, _ := NewBlowfishSalted([]byte("key"), []byte("salt"))
+	c2.expandKey([]byte("key"), nil)
+	c2.expandKey([]byte("salt"), nil)
+	if c1.P() != c2.P() || c1.S() != c2.S() {
+		t.Errorf("ExpandKeyWithSalt with cost 0 is wrong")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("ExpandKeyWithSalt with cost 32 didn't panic")
+		}
+	}()
+	ExpandKeyWithSalt([]byte("key"), []byte("salt"), 32)
+}
@@ -0,0 +1,275 @@
+package krcrypt
+
+// The Camellia block cipher
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+https://tools.ietf.org/html/rfc3713
+https://info.isl.ntt.co.jp/crypt/eng/camellia/specifications/
+
+*/
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"math/bits"
+)
+
+// A CamelliaCipher is an instance of Camellia encryption using a particular
+// key.  Camellia, from Mitsubishi and NTT, is a 16-byte block cipher
+// standardized alongside AES and SEED in ISO/IEC 18033-3, and chosen by
+// NESSIE and CRYPTREC.
+type CamelliaCipher struct {
+	kw     [4]uint64  // the whitening keys
+	k      [24]uint64 // the round keys
+	ke     [6]uint64  // the FL and FL^-1 keys
+	rounds int        // 18 for 128-bit keys, 24 otherwise
+}
+
+var camelliaSbox1 = [256]byte{
+	112, 130, 44, 236, 179, 39, 192, 229, 228, 133, 87, 53, 234, 12, 174, 65,
+	35, 239, 107, 147, 69, 25, 165, 33, 237, 14, 79, 78, 29, 101, 146, 189,
+	134, 184, 175, 143, 124, 235, 31, 206, 62, 48, 220, 95, 94, 197, 11, 26,
+	166, 225, 57, 202, 213, 71, 93, 61, 217, 1, 90, 214, 81, 86, 108, 77,
+	139, 13, 154, 102, 251, 204, 176, 45, 116, 18, 43, 32, 240, 177, 132, 153,
+	223, 76, 203, 194, 52, 126, 118, 5, 109, 183, 169, 49, 209, 23, 4, 215,
+	20, 88, 58, 97, 222, 27, 17, 28, 50, 15, 156, 22, 83, 24, 242, 34,
+	254, 68, 207, 178, 195, 181, 122, 145, 36, 8, 232, 168, 96, 252, 105, 80,
+	170, 208, 160, 125, 161, 137, 98, 151, 84, 91, 30, 149, 224, 255, 100, 210,
+	16, 196, 0, 72, 163, 247, 117, 219, 138, 3, 230, 218, 9, 63, 221, 148,
+	135, 92, 131, 2, 205, 74, 144, 51, 115, 103, 246, 243, 157, 127, 191, 226,
+	82, 155, 216, 38, 200, 55, 198, 59, 129, 150, 111, 75, 19, 190, 99, 46,
+	233, 121, 167, 140, 159, 110, 188, 142, 41, 245, 249, 182, 47, 253, 180, 89,
+	120, 152, 6, 106, 231, 70, 113, 186, 212, 37, 171, 66, 136, 162, 141, 250,
+	114, 7, 185, 85, 248, 238, 172, 10, 54, 73, 42, 104, 60, 56, 241, 164,
+	64, 40, 211, 123, 187, 201, 67, 193, 21, 227, 173, 244, 119, 199, 128, 158,
+}
+
+// SBOX2, SBOX3, and SBOX4 are rotations of SBOX1's output or input
+var camelliaSbox2, camelliaSbox3, camelliaSbox4 [256]byte
+
+func init() {
+	for i := 0; i < 256; i++ {
+		camelliaSbox2[i] = bits.RotateLeft8(camelliaSbox1[i], 1)
+		camelliaSbox3[i] = bits.RotateLeft8(camelliaSbox1[i], 7)
+		camelliaSbox4[i] = camelliaSbox1[bits.RotateLeft8(byte(i), 1)]
+	}
+}
+
+// the key schedule constants, the hex digits of the square roots of the first six primes
+var camelliaSigma = [6]uint64{
+	0xa09e667f3bcc908b,
+	0xb67ae8584caa73b2,
+	0xc6ef372fe94f82be,
+	0x54ff53a5f1d36f1c,
+	0x10e527fade682d1d,
+	0xb05688c2b3e6c1fd,
+}
+
+// NewCamellia creates and returns a new CamelliaCipher.  The key must be 16,
+// 24, or 32 bytes.
+func NewCamellia(key []byte) (*CamelliaCipher, error) {
+
+	var kl, kr [2]uint64
+
+	switch klen := len(key); klen {
+	case 16:
+	case 24:
+		kr[0] = binary.BigEndian.Uint64(key[16:])
+		kr[1] = ^kr[0]
+	case 32:
+		kr[0] = binary.BigEndian.Uint64(key[16:])
+		kr[1] = binary.BigEndian.Uint64(key[24:])
+	default:
+		return nil, KeySizeError(klen)
+	}
+
+	kl[0] = binary.BigEndian.Uint64(key)
+	kl[1] = binary.BigEndian.Uint64(key[8:])
+
+	// KA and KB come from KL and KR through a few rounds of the cipher
+	d1, d2 := kl[0]^kr[0], kl[1]^kr[1]
+	d2 ^= camelliaF(d1, camelliaSigma[0])
+	d1 ^= camelliaF(d2, camelliaSigma[1])
+	d1 ^= kl[0]
+	d2 ^= kl[1]
+	d2 ^= camelliaF(d1, camelliaSigma[2])
+	d1 ^= camelliaF(d2, camelliaSigma[3])
+	ka := [2]uint64{d1, d2}
+
+	c := new(CamelliaCipher)
+
+	if len(key) == 16 {
+		c.rounds = 18
+
+		c.kw[0], c.kw[1] = camelliaRot(kl, 0)
+		c.k[0], c.k[1] = camelliaRot(ka, 0)
+		c.k[2], c.k[3] = camelliaRot(kl, 15)
+		c.k[4], c.k[5] = camelliaRot(ka, 15)
+		c.ke[0], c.ke[1] = camelliaRot(ka, 30)
+		c.k[6], c.k[7] = camelliaRot(kl, 45)
+		c.k[8], _ = camelliaRot(ka, 45)
+		_, c.k[9] = camelliaRot(kl, 60)
+		c.k[10], c.k[11] = camelliaRot(ka, 60)
+		c.ke[2], c.ke[3] = camelliaRot(kl, 77)
+		c.k[12], c.k[13] = camelliaRot(kl, 94)
+		c.k[14], c.k[15] = camelliaRot(ka, 94)
+		c.k[16], c.k[17] = camelliaRot(kl, 111)
+		c.kw[2], c.kw[3] = camelliaRot(ka, 111)
+
+		return c, nil
+	}
+
+	d1, d2 = ka[0]^kr[0], ka[1]^kr[1]
+	d2 ^= camelliaF(d1, camelliaSigma[4])
+	d1 ^= camelliaF(d2, camelliaSigma[5])
+	kb := [2]uint64{d1, d2}
+
+	c.rounds = 24
+
+	c.kw[0], c.kw[1] = camelliaRot(kl, 0)
+	c.k[0], c.k[1] = camelliaRot(kb, 0)
+	c.k[2], c.k[3] = camelliaRot(kr, 15)
+	c.k[4], c.k[5] = camelliaRot(ka, 15)
+	c.ke[0], c.ke[1] = camelliaRot(kr, 30)
+	c.k[6], c.k[7] = camelliaRot(kb, 30)
+	c.k[8], c.k[9] = camelliaRot(kl, 45)
+	c.k[10], c.k[11] = camelliaRot(ka, 45)
+	c.ke[2], c.ke[3] = camelliaRot(kl, 60)
+	c.k[12], c.k[13] = camelliaRot(kr, 60)
+	c.k[14], c.k[15] = camelliaRot(kb, 60)
+	c.k[16], c.k[17] = camelliaRot(kl, 77)
+	c.ke[4], c.ke[5] = camelliaRot(ka, 77)
+	c.k[18], c.k[19] = camelliaRot(kr, 94)
+	c.k[20], c.k[21] = camelliaRot(ka, 94)
+	c.k[22], c.k[23] = camelliaRot(kl, 111)
+	c.kw[2], c.kw[3] = camelliaRot(kb, 111)
+
+	return c, nil
+}
+
+// NewCamelliaGCM returns Camellia in Galois/Counter Mode with the standard
+// 12-byte nonce and 16-byte tag, as in RFC 6367.  The key must be 16, 24, or
+// 32 bytes.
+func NewCamelliaGCM(key []byte) (cipher.AEAD, error) {
+	block, err := NewCamellia(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// camelliaRot returns the halves of the 128-bit value x rotated left by n bits
+func camelliaRot(x [2]uint64, n uint) (uint64, uint64) {
+	hi, lo := x[0], x[1]
+	if n >= 64 {
+		hi, lo = lo, hi
+		n -= 64
+	}
+	if n == 0 {
+		return hi, lo
+	}
+	return hi<<n | lo>>(64-n), lo<<n | hi>>(64-n)
+}
+
+// Reset zeroes the whitening, round and FL subkeys.
+func (c *CamelliaCipher) Reset() {
+	c.kw = [4]uint64{}
+	c.k = [24]uint64{}
+	c.ke = [6]uint64{}
+}
+
+// IsWiped reports whether the whitening, round and FL subkeys are all zero.
+func (c *CamelliaCipher) IsWiped() bool {
+	return c.kw == [4]uint64{} && c.k == [24]uint64{} && c.ke == [6]uint64{}
+}
+
+func (c *CamelliaCipher) BlockSize() int { return 16 }
+
+// camelliaF is the round function: the S-boxes, then the byte-wise linear P layer
+func camelliaF(x, k uint64) uint64 {
+
+	x ^= k
+
+	t1 := camelliaSbox1[byte(x>>56)]
+	t2 := camelliaSbox2[byte(x>>48)]
+	t3 := camelliaSbox3[byte(x>>40)]
+	t4 := camelliaSbox4[byte(x>>32)]
+	t5 := camelliaSbox2[byte(x>>24)]
+	t6 := camelliaSbox3[byte(x>>16)]
+	t7 := camelliaSbox4[byte(x>>8)]
+	t8 := camelliaSbox1[byte(x)]
+
+	y1 := t1 ^ t3 ^ t4 ^ t6 ^ t7 ^ t8
+	y2 := t1 ^ t2 ^ t4 ^ t5 ^ t7 ^ t8
+	y3 := t1 ^ t2 ^ t3 ^ t5 ^ t6 ^ t8
+	y4 := t2 ^ t3 ^ t4 ^ t5 ^ t6 ^ t7
+	y5 := t1 ^ t2 ^ t6 ^ t7 ^ t8
+	y6 := t2 ^ t3 ^ t5 ^ t7 ^ t8
+	y7 := t3 ^ t4 ^ t5 ^ t6 ^ t8
+	y8 := t1 ^ t4 ^ t5 ^ t6 ^ t7
+
+	return uint64(y1)<<56 | uint64(y2)<<48 | uint64(y3)<<40 | uint64(y4)<<32 |
+		uint64(y5)<<24 | uint64(y6)<<16 | uint64(y7)<<8 | uint64(y8)
+}
+
+// camelliaFL is the FL function inserted every six rounds
+func camelliaFL(x, k uint64) uint64 {
+	x1, x2 := uint32(x>>32), uint32(x)
+	k1, k2 := uint32(k>>32), uint32(k)
+	x2 ^= bits.RotateLeft32(x1&k1, 1)
+	x1 ^= x2 | k2
+	return uint64(x1)<<32 | uint64(x2)
+}
+
+// camelliaFLInv is the inverse of camelliaFL
+func camelliaFLInv(y, k uint64) uint64 {
+	y1, y2 := uint32(y>>32), uint32(y)
+	k1, k2 := uint32(k>>32), uint32(k)
+	y1 ^= y2 | k2
+	y2 ^= bits.RotateLeft32(y1&k1, 1)
+	return uint64(y1)<<32 | uint64(y2)
+}
+
+// Encrypt encrypts the 16-byte block in src and stores the resulting ciphertext in dst.
+func (c *CamelliaCipher) Encrypt(dst, src []byte) {
+
+	d1 := binary.BigEndian.Uint64(src) ^ c.kw[0]
+	d2 := binary.BigEndian.Uint64(src[8:]) ^ c.kw[1]
+
+	for r := 0; r < c.rounds; r += 2 {
+		if r > 0 && r%6 == 0 {
+			d1 = camelliaFL(d1, c.ke[r/3-2])
+			d2 = camelliaFLInv(d2, c.ke[r/3-1])
+		}
+		d2 ^= camelliaF(d1, c.k[r])
+		d1 ^= camelliaF(d2, c.k[r+1])
+	}
+
+	binary.BigEndian.PutUint64(dst, d2^c.kw[2])
+	binary.BigEndian.PutUint64(dst[8:], d1^c.kw[3])
+}
+
+// Decrypt decrypts the 16-byte block in src and stores the resulting plaintext in dst.
+func (c *CamelliaCipher) Decrypt(dst, src []byte) {
+
+	d1 := binary.BigEndian.Uint64(src) ^ c.kw[2]
+	d2 := binary.BigEndian.Uint64(src[8:]) ^ c.kw[3]
+
+	// the same network, with the subkeys in reverse order
+	for r := c.rounds; r > 0; r -= 2 {
+		if r < c.rounds && r%6 == 0 {
+			d1 = camelliaFL(d1, c.ke[r/3-1])
+			d2 = camelliaFLInv(d2, c.ke[r/3-2])
+		}
+		d2 ^= camelliaF(d1, c.k[r-1])
+		d1 ^= camelliaF(d2, c.k[r-2])
+	}
+
+	binary.BigEndian.PutUint64(dst, d2^c.kw[0])
+	binary.BigEndian.PutUint64(dst[8:], d1^c.kw[1])
+}
@@ -0,0 +1,91 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+// from RFC 3713, Appendix A
+var camelliaTestVectors = []struct {
+	key    []byte
+	plain  []byte
+	cipher []byte
+}{
+	{
+		[]byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 0xfe, 0xdc, 0xba, 0x98, 0x76, 0x54, 0x32, 0x10},
+		[]byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 0xfe, 0xdc, 0xba, 0x98, 0x76, 0x54, 0x32, 0x10},
+		[]byte{0x67, 0x67, 0x31, 0x38, 0x54, 0x96, 0x69, 0x73, 0x08, 0x57, 0x06, 0x56, 0x48, 0xea, 0xbe, 0x43},
+	},
+	{
+		[]byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 0xfe, 0xdc, 0xba, 0x98, 0x76, 0x54, 0x32, 0x10, 0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77},
+		[]byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 0xfe, 0xdc, 0xba, 0x98, 0x76, 0x54, 0x32, 0x10},
+		[]byte{0xb4, 0x99, 0x34, 0x01, 0xb3, 0xe9, 0x96, 0xf8, 0x4e, 0xe5, 0xce, 0xe7, 0xd7, 0x9b, 0x09, 0xb9},
+	},
+	{
+		[]byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 0xfe, 0xdc, 0xba, 0x98, 0x76, 0x54, 0x32, 0x10, 0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+		[]byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 0xfe, 0xdc, 0xba, 0x98, 0x76, 0x54, 0x32, 0x10},
+		[]byte{0x9a, 0xcc, 0x23, 0x7d, 0xff, 0x16, 0xd7, 0x6c, 0x20, 0xef, 0x7c, 0x91, 0x9e, 0x3a, 0x75, 0x09},
+	},
+}
+
+func TestCamelliaEncrypt(t *testing.T) {
+
+	for _, v := range camelliaTestVectors {
+		c, err := NewCamellia(v.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var ct, p [16]byte
+
+		c.Encrypt(ct[:], v.plain)
+
+		if !bytes.Equal(v.cipher, ct[:]) {
+			t.Errorf("camellia encrypt failed: got %#v wanted %#v\n", ct, v.cipher)
+		}
+
+		c.Decrypt(p[:], ct[:])
+
+		if !bytes.Equal(v.plain, p[:]) {
+			t.Errorf("camellia decrypt failed: got %#v wanted %#v\n", p, v.plain)
+		}
+	}
+
+	if _, err := NewCamellia(make([]byte, 20)); err != KeySizeError(20) {
+		t.Errorf("NewCamellia(20 byte key)=%v, wanted %v", err, KeySizeError(20))
+	}
+}
+
+func TestCamelliaGCM(t *testing.T) {
+
+	key := []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f}
+	nonce := make([]byte, 12)
+	plain := []byte("attack at dawn")
+	aad := []byte("header")
+
+	g, err := NewCamelliaGCM(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the ciphertext part is Camellia-CTR from counter 2, as computed by openssl
+	want := []byte{0x46, 0xf3, 0x1a, 0x56, 0x90, 0xc8, 0xd2, 0x06, 0x3a, 0xfb, 0xfb, 0x62, 0xb0, 0xcf}
+
+	sealed := g.Seal(nil, nonce, plain, aad)
+	if !bytes.Equal(sealed[:len(plain)], want) {
+		t.Errorf("camellia-gcm encrypt failed: got %#v wanted %#v\n", sealed[:len(plain)], want)
+	}
+
+	if got, err := g.Open(nil, nonce, sealed, aad); err != nil || !bytes.Equal(got, plain) {
+		t.Errorf("Open=(%q,%v), wanted %q", got, err, plain)
+	}
+
+	sealed[len(sealed)-1] ^= 1
+	if _, err := g.Open(nil, nonce, sealed, aad); err == nil {
+		t.Errorf("Open accepted a tampered tag")
+	}
+
+	if _, err := NewCamelliaGCM(key[:8]); err != KeySizeError(8) {
+		t.Errorf("NewCamelliaGCM(short key)=%v, wanted %v", err, KeySizeError(8))
+	}
+}
@@ -0,0 +1,35 @@
+package krcrypt
+
+import (
+	"crypto/cipher"
+	"encoding/hex"
+	"testing"
+)
+
+func TestCAST6(t *testing.T) {
+
+	// from RFC 2612, appendix A
+	var tests = []struct {
+		key, plain, cipher string
+	}{
+		{"2342bb9efa38542c0af75647f29f615d", "00000000000000000000000000000000", "c842a08972b43d20836c91d1b7530f6b"},
+		{"2342bb9efa38542cbed0ac83940ac298bac77a7717942863", "00000000000000000000000000000000", "1b386c0210dcadcbdd0e41aa08a7a7e8"},
+		{"2342bb9efa38542cbed0ac83940ac2988d7c47ce264908461cc1b5137ae6b604", "00000000000000000000000000000000", "4f6a2038286897b9c9870136553317fa"},
+	}
+
+	var vectors []BlockVector
+	for _, tt := range tests {
+		key, _ := hex.DecodeString(tt.key)
+		plain, _ := hex.DecodeString(tt.plain)
+		ct, _ := hex.DecodeString(tt.cipher)
+		vectors = append(vectors, BlockVector{key, plain, ct})
+	}
+
+	RunBlockKAT(t, func(k []byte) (cipher.Block, error) { return NewCAST6(k) }, vectors)
+
+	for _, klen := range []int{0, 15, 17, 18, 33} {
+		if _, err := NewCAST6(make([]byte, klen)); err != KeySizeError(klen) {
+			t.Errorf("NewCAST6(%d-byte key)=%v, wanted %v", klen, err, KeySizeError(klen))
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package krcrypt
+
+// Streaming SEED-CBC with PKCS#7 padding
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrCiphertextLength is returned when a CBC ciphertext is not a whole number
+// of blocks, or is too short to hold even one.
+var ErrCiphertextLength = errors.New("krcrypt: ciphertext is not a multiple of the block size")
+
+// how many blocks we pull from the underlying reader at a time
+const cbcChunkBlocks = 256
+
+// ErrClosed is returned when writing to an EncryptWriter that has been closed.
+var ErrClosed = errors.New("krcrypt: write to closed writer")
+
+// An EncryptWriter encrypts everything written to it with SEED-CBC, in the
+// format read by CBCDecryptReader: a random 16-byte IV followed by the
+// PKCS#7-padded ciphertext.  The padding is added by Close, which must be
+// called to complete the stream.
+type EncryptWriter struct {
+	w        io.Writer
+	mode     cipher.BlockMode
+	buf      []byte // plaintext waiting to be encrypted, at most one chunk
+	written  int64  // plaintext bytes encrypted and written so far
+	progress func(int64)
+	err      error
+}
+
+// An EncryptWriterOption configures an EncryptWriter.
+type EncryptWriterOption func(*EncryptWriter)
+
+// WithProgress registers a callback that is passed the total number of
+// plaintext bytes processed each time a chunk is flushed to the underlying
+// writer.  It is called synchronously from Write and Close, so never
+// concurrently, and its final value is the total length of the input.
+func WithProgress(f func(bytesProcessed int64)) EncryptWriterOption {
+	return func(e *EncryptWriter) { e.progress = f }
+}
+
+// NewEncryptWriter returns a writer that encrypts to w with SEED-CBC, using
+// the given 16-byte key and a fresh random IV, which is written immediately.
+func NewEncryptWriter(key []byte, w io.Writer, opts ...EncryptWriterOption) (*EncryptWriter, error) {
+
+	block, err := NewSEED(key)
+	if err != nil {
+		return nil, fmt.Errorf("krcrypt: NewEncryptWriter: %w", err)
+	}
+
+	iv := make([]byte, block.BlockSize())
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(iv); err != nil {
+		return nil, err
+	}
+
+	e := &EncryptWriter{
+		w:    w,
+		mode: cipher.NewCBCEncrypter(block, iv),
+		buf:  make([]byte, 0, cbcChunkBlocks*block.BlockSize()),
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e, nil
+}
+
+// Write encrypts p to the underlying writer.  Data is buffered and written a
+// chunk at a time.
+func (e *EncryptWriter) Write(p []byte) (int, error) {
+
+	n := 0
+	for len(p) > 0 && e.err == nil {
+		c := copy(e.buf[len(e.buf):cap(e.buf)], p)
+		e.buf = e.buf[:len(e.buf)+c]
+		p = p[c:]
+		n += c
+
+		if len(e.buf) == cap(e.buf) {
+			e.flush(false)
+		}
+	}
+
+	return n, e.err
+}
+
+// Close pads and writes out the final block.  It does not close the underlying writer.
+func (e *EncryptWriter) Close() error {
+	if e.err != nil {
+		return e.err
+	}
+	e.flush(true)
+	if e.err == nil {
+		e.err = ErrClosed
+		return nil
+	}
+	return e.err
+}
+
+// flush encrypts and writes out the buffered plaintext
+func (e *EncryptWriter) flush(final bool) {
+
+	n := len(e.buf)
+	if final {
+		e.buf = pkcs7Pad(e.buf, e.mode.BlockSize())
+	}
+
+	e.mode.CryptBlocks(e.buf, e.buf)
+	if _, err := e.w.Write(e.buf); err != nil {
+		e.err = err
+		return
+	}
+
+	e.buf = e.buf[:0]
+	e.written += int64(n)
+
+	if e.progress != nil {
+		e.progress(e.written)
+	}
+}
+
+// A CBCDecryptReader decrypts a SEED-CBC stream consisting of a 16-byte IV
+// followed by the PKCS#7-padded ciphertext.
+type CBCDecryptReader struct {
+	r     io.Reader
+	mode  cipher.BlockMode
+	chunk []byte   // ciphertext read from r, decrypted in place
+	plain []byte   // backing store for out
+	held  [16]byte // the most recent plaintext block, which might be the padding
+	nheld int      // how much of held is valid (0 or 16)
+	out   []byte   // plaintext ready to be returned by Read
+	err   error    // sticky error, returned once out is drained
+}
+
+// NewCBCDecryptReader returns a reader that decrypts the SEED-CBC stream
+// read from r.  The IV is taken from the first 16 bytes of the stream.
+func NewCBCDecryptReader(key []byte, r io.Reader) (*CBCDecryptReader, error) {
+
+	block, err := NewSEED(key)
+	if err != nil {
+		return nil, fmt.Errorf("krcrypt: NewCBCDecryptReader: %w", err)
+	}
+
+	iv := make([]byte, block.BlockSize())
+	if _, err := io.ReadFull(r, iv); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = ErrCiphertextLength
+		}
+		return nil, err
+	}
+
+	return &CBCDecryptReader{
+		r:     r,
+		mode:  cipher.NewCBCDecrypter(block, iv),
+		chunk: make([]byte, cbcChunkBlocks*block.BlockSize()),
+	}, nil
+}
+
+// Read reads decrypted plaintext into p.  The final block is held back until
+// the end of the stream is seen, so that its padding can be removed.
+func (d *CBCDecryptReader) Read(p []byte) (int, error) {
+
+	for len(d.out) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		d.fill()
+	}
+
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+// fill decrypts the next chunk of ciphertext into d.out
+func (d *CBCDecryptReader) fill() {
+
+	bs := d.mode.BlockSize()
+
+	n, err := io.ReadFull(d.r, d.chunk)
+	final := false
+	switch err {
+	case nil:
+	case io.EOF, io.ErrUnexpectedEOF:
+		final = true
+	default:
+		d.err = err
+		return
+	}
+
+	// reject a partial block, or a stream with no ciphertext at all, before
+	// decrypting anything
+	if n%bs != 0 || (final && n == 0 && d.nheld == 0) {
+		d.err = ErrCiphertextLength
+		return
+	}
+
+	d.mode.CryptBlocks(d.chunk[:n], d.chunk[:n])
+
+	d.plain = append(d.plain[:0], d.held[:d.nheld]...)
+	d.plain = append(d.plain, d.chunk[:n]...)
+
+	if final {
+		d.out, d.err = pkcs7Unpad(d.plain, bs)
+		if d.err == nil {
+			d.err = io.EOF
+		}
+		return
+	}
+
+	// keep back the last block: we don't know yet if it's the final one
+	split := len(d.plain) - bs
+	d.nheld = copy(d.held[:], d.plain[split:])
+	d.out = d.plain[:split]
+}
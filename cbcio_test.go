@@ -0,0 +1,176 @@
+package krcrypt
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// openssl enc -seed-cbc -K 000102030405060708090a0b0c0d0e0f -iv 0f0e0d0c0b0a09080706050403020100
+var cbcTestVector = struct {
+	key    []byte
+	iv     []byte
+	plain  []byte
+	cipher []byte
+}{
+	[]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f},
+	[]byte{0x0f, 0x0e, 0x0d, 0x0c, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x06, 0x05, 0x04, 0x03, 0x02, 0x01, 0x00},
+	[]byte("The quick brown fox jumps over the lazy dog"),
+	[]byte{
+		0x7d, 0xb9, 0xda, 0x88, 0xd1, 0x8e, 0xe4, 0x5a, 0x20, 0x91, 0x86, 0x4f, 0x07, 0x96, 0x76, 0xe5,
+		0xf6, 0xc3, 0xdb, 0xc8, 0x0a, 0x65, 0x70, 0xb9, 0x9e, 0x8e, 0x32, 0x36, 0x2a, 0x91, 0x91, 0xbc,
+		0xdc, 0xff, 0x77, 0x40, 0x47, 0x1b, 0x85, 0x66, 0xe3, 0x4c, 0xe8, 0x70, 0x88, 0x33, 0x95, 0xd0,
+	},
+}
+
+// encrypt plain the way the stream format expects: IV, then padded ciphertext
+func cbcEncryptStream(key, iv, plain []byte) []byte {
+	block, _ := NewSEED(key)
+	padded := pkcs7Pad(append([]byte(nil), plain...), block.BlockSize())
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(padded, padded)
+	return append(append([]byte(nil), iv...), padded...)
+}
+
+func TestCBCDecryptReader(t *testing.T) {
+
+	v := cbcTestVector
+	stream := append(append([]byte(nil), v.iv...), v.cipher...)
+
+	r, err := NewCBCDecryptReader(v.key, bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := io.ReadAll(r)
+	if err != nil || !bytes.Equal(got, v.plain) {
+		t.Errorf("CBCDecryptReader=(%q,%v), wanted %q", got, err, v.plain)
+	}
+}
+
+func TestCBCDecryptReaderLengths(t *testing.T) {
+
+	v := cbcTestVector
+
+	// cover empty input, partial blocks, exact blocks, and more than one chunk
+	for _, n := range []int{0, 1, 15, 16, 17, 4095, 4096, 4097, 10000} {
+		plain := make([]byte, n)
+		for i := range plain {
+			plain[i] = byte(i)
+		}
+
+		stream := cbcEncryptStream(v.key, v.iv, plain)
+
+		r, err := NewCBCDecryptReader(v.key, iotest.OneByteReader(bytes.NewReader(stream)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := io.ReadAll(r)
+		if err != nil || !bytes.Equal(got, plain) {
+			t.Errorf("CBCDecryptReader(len=%d) failed: err=%v", n, err)
+		}
+	}
+}
+
+func TestCBCDecryptReaderErrors(t *testing.T) {
+
+	v := cbcTestVector
+	stream := append(append([]byte(nil), v.iv...), v.cipher...)
+
+	if _, err := NewCBCDecryptReader(v.key, bytes.NewReader(v.iv[:10])); err != ErrCiphertextLength {
+		t.Errorf("short IV: got %v, wanted %v", err, ErrCiphertextLength)
+	}
+
+	r, _ := NewCBCDecryptReader(v.key, bytes.NewReader(stream[:len(stream)-1]))
+	if _, err := io.ReadAll(r); err != ErrCiphertextLength {
+		t.Errorf("partial final block: got %v, wanted %v", err, ErrCiphertextLength)
+	}
+
+	r, _ = NewCBCDecryptReader(v.key, bytes.NewReader(stream[:16+10]))
+	if _, err := io.ReadAll(r); err != ErrCiphertextLength {
+		t.Errorf("misaligned first block: got %v, wanted %v", err, ErrCiphertextLength)
+	}
+
+	// an IV and nothing else is caught as a length error, not a padding error
+	r, _ = NewCBCDecryptReader(v.key, bytes.NewReader(v.iv))
+	if _, err := io.ReadAll(r); err != ErrCiphertextLength {
+		t.Errorf("no ciphertext: got %v, wanted %v", err, ErrCiphertextLength)
+	}
+
+	r, _ = NewCBCDecryptReader(v.key, bytes.NewReader(stream[:len(stream)-16]))
+	if _, err := io.ReadAll(r); err != ErrPadding {
+		t.Errorf("truncated stream: got %v, wanted %v", err, ErrPadding)
+	}
+
+	_, err := NewCBCDecryptReader(v.key[:15], bytes.NewReader(stream))
+	if !errors.Is(err, KeySizeError(15)) || !strings.HasPrefix(err.Error(), "krcrypt: NewCBCDecryptReader: ") {
+		t.Errorf("bad key: got %v, wanted %v with the operation prefixed", err, KeySizeError(15))
+	}
+
+	_, err = NewEncryptWriter(v.key[:15], io.Discard)
+	if !errors.Is(err, KeySizeError(15)) || !strings.HasPrefix(err.Error(), "krcrypt: NewEncryptWriter: ") {
+		t.Errorf("NewEncryptWriter(bad key): got %v, wanted %v with the operation prefixed", err, KeySizeError(15))
+	}
+}
+
+func TestEncryptWriterProgress(t *testing.T) {
+
+	key := cbcTestVector.key
+	chunk := cbcChunkBlocks * 16
+
+	plain := make([]byte, 2*chunk+100)
+	for i := range plain {
+		plain[i] = byte(i)
+	}
+
+	var reports []int64
+	var out bytes.Buffer
+
+	w, err := NewEncryptWriter(key, &out, WithProgress(func(n int64) { reports = append(reports, n) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// write in awkward pieces so chunks fill up mid-Write
+	for b := plain; len(b) > 0; {
+		n := 1000
+		if n > len(b) {
+			n = len(b)
+		}
+		if _, err := w.Write(b[:n]); err != nil {
+			t.Fatal(err)
+		}
+		b = b[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []int64{int64(chunk), int64(2 * chunk), int64(len(plain))}
+	if len(reports) != len(want) {
+		t.Fatalf("progress reports=%v, wanted %v", reports, want)
+	}
+	for i := range want {
+		if reports[i] != want[i] {
+			t.Errorf("progress reports=%v, wanted %v", reports, want)
+			break
+		}
+	}
+
+	r, err := NewCBCDecryptReader(key, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil || !bytes.Equal(got, plain) {
+		t.Errorf("decrypting EncryptWriter output failed: err=%v", err)
+	}
+
+	if _, err := w.Write([]byte("x")); err != ErrClosed {
+		t.Errorf("Write after Close=%v, wanted %v", err, ErrClosed)
+	}
+}
@@ -0,0 +1,60 @@
+package krcrypt
+
+// Sector encryption with SEED-CFB
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+)
+
+// A CFBSector encrypts fixed-size disk sectors with SEED-CFB, each under its
+// own IV: the SEED-CMAC of the sector number under a key kept apart from the
+// encryption key.  The ciphertext is the same length as the plaintext.
+//
+// It is simpler than XTS, but weaker: rewriting a sector reuses its IV, so
+// an attacker who sees two versions learns where they first differ, and can
+// flip chosen bits of the last block undetected.  There is no authentication.
+type CFBSector struct {
+	block cipher.Block
+	ivKey []byte
+}
+
+// NewCFBSector returns a CFBSector for the 16-byte key.  The encryption and
+// IV keys are derived from it with SplitKey.
+func NewCFBSector(key []byte) (*CFBSector, error) {
+
+	encKey, ivKey, err := SplitKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := NewSEED(encKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CFBSector{block: block, ivKey: ivKey}, nil
+}
+
+// iv returns the IV for a sector
+func (s *CFBSector) iv(sector uint64) []byte {
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], sector)
+	mac, _ := NewCMAC(s.ivKey)
+	mac.Write(n[:])
+	return mac.Sum(nil)
+}
+
+// Encrypt encrypts the sector numbered sector from src into dst, which may
+// overlap exactly.
+func (s *CFBSector) Encrypt(dst, src []byte, sector uint64) {
+	cipher.NewCFBEncrypter(s.block, s.iv(sector)).XORKeyStream(dst, src)
+}
+
+// Decrypt decrypts the sector numbered sector from src into dst, which may
+// overlap exactly.
+func (s *CFBSector) Decrypt(dst, src []byte, sector uint64) {
+	cipher.NewCFBDecrypter(s.block, s.iv(sector)).XORKeyStream(dst, src)
+}
@@ -0,0 +1,45 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCFBSector(t *testing.T) {
+
+	s, err := NewCFBSector([]byte("0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plain := bytes.Repeat([]byte("same data "), 52)[:512]
+
+	seen := make(map[string]bool)
+	for _, sector := range []uint64{0, 1, 2, 1 << 32, 1<<64 - 1} {
+		ct := make([]byte, len(plain))
+		s.Encrypt(ct, plain, sector)
+
+		if bytes.Equal(ct[:16], plain[:16]) || seen[string(ct)] {
+			t.Errorf("sector %d: ciphertext repeats the plaintext or another sector", sector)
+		}
+		seen[string(ct)] = true
+
+		// decrypting in place
+		s.Decrypt(ct, ct, sector)
+		if !bytes.Equal(ct, plain) {
+			t.Errorf("sector %d: round trip failed", sector)
+		}
+	}
+
+	// the same sector always encrypts the same way
+	a, b := make([]byte, 512), make([]byte, 512)
+	s.Encrypt(a, plain, 7)
+	s.Encrypt(b, plain, 7)
+	if !bytes.Equal(a, b) {
+		t.Errorf("sector 7 encrypted two different ways")
+	}
+
+	if _, err := NewCFBSector(make([]byte, 10)); err != KeySizeError(10) {
+		t.Errorf("NewCFBSector(10-byte key)=%v, wanted %v", err, KeySizeError(10))
+	}
+}
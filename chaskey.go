@@ -0,0 +1,137 @@
+package krcrypt
+
+// The Chaskey message authentication code
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+https://eprint.iacr.org/2014/386.pdf
+http://mouha.be/chaskey/
+
+*/
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+// Chaskey is an instance of the Chaskey MAC with a particular key.  It is
+// built on a 128-bit ARX permutation rather than a block cipher, so on small
+// messages it is many times faster than CMAC over SEED.
+type Chaskey struct {
+	k, k1, k2 [4]uint32 // the key and the two derived subkeys
+	v         [4]uint32
+	buf       [16]byte // the last block written, which may be the final one
+	nbuf      int
+}
+
+var _ hash.Hash = (*Chaskey)(nil)
+
+// NewChaskey returns a Chaskey computing a 16-byte tag with the given 16-byte key.
+func NewChaskey(key []byte) (*Chaskey, error) {
+
+	if klen := len(key); klen != 16 {
+		return nil, KeySizeError(klen)
+	}
+
+	c := new(Chaskey)
+	for i := range c.k {
+		c.k[i] = binary.LittleEndian.Uint32(key[4*i:])
+	}
+	c.k1 = chaskeyTimesTwo(c.k)
+	c.k2 = chaskeyTimesTwo(c.k1)
+	c.v = c.k
+
+	return c, nil
+}
+
+// chaskeyTimesTwo doubles k in GF(2^128), with k held as little-endian words
+func chaskeyTimesTwo(k [4]uint32) [4]uint32 {
+	return [4]uint32{
+		k[0]<<1 ^ 0x87&-(k[3]>>31),
+		k[1]<<1 | k[0]>>31,
+		k[2]<<1 | k[1]>>31,
+		k[3]<<1 | k[2]>>31,
+	}
+}
+
+// the eight-round permutation
+func chaskeyPermute(v *[4]uint32) {
+	v0, v1, v2, v3 := v[0], v[1], v[2], v[3]
+	for i := 0; i < 8; i++ {
+		v0 += v1
+		v1 = bits.RotateLeft32(v1, 5) ^ v0
+		v0 = bits.RotateLeft32(v0, 16)
+		v2 += v3
+		v3 = bits.RotateLeft32(v3, 8) ^ v2
+		v0 += v3
+		v3 = bits.RotateLeft32(v3, 13) ^ v0
+		v2 += v1
+		v1 = bits.RotateLeft32(v1, 7) ^ v2
+		v2 = bits.RotateLeft32(v2, 16)
+	}
+	v[0], v[1], v[2], v[3] = v0, v1, v2, v3
+}
+
+func (c *Chaskey) Size() int      { return 16 }
+func (c *Chaskey) BlockSize() int { return 16 }
+
+// Reset clears the state so a new message can be authenticated with the same key.
+func (c *Chaskey) Reset() {
+	c.v = c.k
+	c.nbuf = 0
+}
+
+// Write adds more data to the running MAC.  It never returns an error.
+func (c *Chaskey) Write(m []byte) (int, error) {
+
+	n := len(m)
+
+	for len(m) > 0 {
+		// only process the buffered block once we know it isn't the last one
+		if c.nbuf == 16 {
+			for i := range c.v {
+				c.v[i] ^= binary.LittleEndian.Uint32(c.buf[4*i:])
+			}
+			chaskeyPermute(&c.v)
+			c.nbuf = 0
+		}
+		w := copy(c.buf[c.nbuf:], m)
+		c.nbuf += w
+		m = m[w:]
+	}
+
+	return n, nil
+}
+
+// Sum appends the tag for the data written so far to b.  It does not change
+// the underlying state, so more data can be written afterwards.
+func (c *Chaskey) Sum(b []byte) []byte {
+
+	v := c.v
+
+	// a full final block is masked with k1; anything else is padded with a
+	// single 1 bit and masked with k2
+	var last [16]byte
+	copy(last[:], c.buf[:c.nbuf])
+	l := &c.k1
+	if c.nbuf < 16 {
+		last[c.nbuf] = 0x01
+		l = &c.k2
+	}
+
+	for i := range v {
+		v[i] ^= binary.LittleEndian.Uint32(last[4*i:]) ^ l[i]
+	}
+	chaskeyPermute(&v)
+
+	var tag [16]byte
+	for i := range v {
+		binary.LittleEndian.PutUint32(tag[4*i:], v[i]^l[i])
+	}
+	return append(b, tag[:]...)
+}
@@ -0,0 +1,102 @@
+package krcrypt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestChaskeyIncremental(t *testing.T) {
+
+	key := make([]byte, 16)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	m := make([]byte, 100)
+	for i := range m {
+		m[i] = byte(i)
+	}
+
+	c, err := NewChaskey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Write(m)
+	want := c.Sum(nil)
+
+	// Sum must not disturb the state
+	if again := c.Sum(nil); !bytes.Equal(again, want) {
+		t.Errorf("second Sum=%x, wanted %x", again, want)
+	}
+
+	for _, split := range []int{1, 15, 16, 17, 32, 99} {
+		c.Reset()
+		for b := m; len(b) > 0; {
+			n := split
+			if n > len(b) {
+				n = len(b)
+			}
+			c.Write(b[:n])
+			b = b[n:]
+		}
+		if got := c.Sum([]byte("prefix")); !bytes.Equal(got[6:], want) || string(got[:6]) != "prefix" {
+			t.Errorf("chaskey(writes of %d)=%x, wanted %x", split, got, want)
+		}
+	}
+}
+
+func TestChaskeyPadding(t *testing.T) {
+
+	// not the zero key, for which the two subkeys are both zero
+	key := []byte("YELLOW SUBMARINE")
+	c, _ := NewChaskey(key)
+
+	// a short message padded by hand must not collide with the padded original
+	short := []byte("fifteen bytes!!")
+	padded := append(append([]byte(nil), short...), 0x01)
+
+	c.Write(short)
+	t1 := c.Sum(nil)
+	c.Reset()
+	c.Write(padded)
+	t2 := c.Sum(nil)
+
+	if bytes.Equal(t1, t2) {
+		t.Errorf("chaskey(%q) == chaskey(%q)", short, padded)
+	}
+
+	if _, err := NewChaskey(make([]byte, 15)); err != KeySizeError(15) {
+		t.Errorf("NewChaskey(15 byte key)=%v, wanted %v", err, KeySizeError(15))
+	}
+}
+
+func TestChaskeyTimesTwo(t *testing.T) {
+
+	// the word-oriented doubling must agree with CMAC doubling on the byte-reversed value
+	var k [4]uint32
+	for i := range k {
+		k[i] = 0x80706050 + uint32(i)*0x01020304
+	}
+
+	for i := 0; i < 100; i++ {
+		var b [16]byte
+		for j := range k {
+			binary.LittleEndian.PutUint32(b[4*j:], k[j])
+		}
+		for l, r := 0, 15; l < r; l, r = l+1, r-1 {
+			b[l], b[r] = b[r], b[l]
+		}
+		want := cmacField.Double(b)
+
+		k = chaskeyTimesTwo(k)
+		for j := range k {
+			binary.LittleEndian.PutUint32(b[4*j:], k[j])
+		}
+		for l, r := 0, 15; l < r; l, r = l+1, r-1 {
+			b[l], b[r] = b[r], b[l]
+		}
+		if b != want {
+			t.Fatalf("chaskeyTimesTwo step %d=%x, wanted %x", i, b, want)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package krcrypt
+
+// The CLEFIA block cipher from Sony
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+https://tools.ietf.org/html/rfc6114
+http://www.sony.net/Products/cryptography/clefia/
+
+*/
+
+import "encoding/binary"
+
+// A ClefiaCipher is an instance of CLEFIA encryption using a particular key.
+type ClefiaCipher struct {
+	rk     []uint32  // round keys, two per round
+	wk     [4]uint32 // whitening keys
+	rounds int
+}
+
+// S0, built at init
+var clefiaS0 [256]byte
+
+// S1 is inversion in GF(2^8) between two affine maps; this is its table
+var clefiaS1 = [256]byte{
+	0x6c, 0xda, 0xc3, 0xe9, 0x4e, 0x9d, 0x0a, 0x3d, 0xb8, 0x36, 0xb4, 0x38, 0x13, 0x34, 0x0c, 0xd9,
+	0xbf, 0x74, 0x94, 0x8f, 0xb7, 0x9c, 0xe5, 0xdc, 0x9e, 0x07, 0x49, 0x4f, 0x98, 0x2c, 0xb0, 0x93,
+	0x12, 0xeb, 0xcd, 0xb3, 0x92, 0xe7, 0x41, 0x60, 0xe3, 0x21, 0x27, 0x3b, 0xe6, 0x19, 0xd2, 0x0e,
+	0x91, 0x11, 0xc7, 0x3f, 0x2a, 0x8e, 0xa1, 0xbc, 0x2b, 0xc8, 0xc5, 0x0f, 0x5b, 0xf3, 0x87, 0x8b,
+	0xfb, 0xf5, 0xde, 0x20, 0xc6, 0xa7, 0x84, 0xce, 0xd8, 0x65, 0x51, 0xc9, 0xa4, 0xef, 0x43, 0x53,
+	0x25, 0x5d, 0x9b, 0x31, 0xe8, 0x3e, 0x0d, 0xd7, 0x80, 0xff, 0x69, 0x8a, 0xba, 0x0b, 0x73, 0x5c,
+	0x6e, 0x54, 0x15, 0x62, 0xf6, 0x35, 0x30, 0x52, 0xa3, 0x16, 0xd3, 0x28, 0x32, 0xfa, 0xaa, 0x5e,
+	0xcf, 0xea, 0xed, 0x78, 0x33, 0x58, 0x09, 0x7b, 0x63, 0xc0, 0xc1, 0x46, 0x1e, 0xdf, 0xa9, 0x99,
+	0x55, 0x04, 0xc4, 0x86, 0x39, 0x77, 0x82, 0xec, 0x40, 0x18, 0x90, 0x97, 0x59, 0xdd, 0x83, 0x1f,
+	0x9a, 0x37, 0x06, 0x24, 0x64, 0x7c, 0xa5, 0x56, 0x48, 0x08, 0x85, 0xd0, 0x61, 0x26, 0xca, 0x6f,
+	0x7e, 0x6a, 0xb6, 0x71, 0xa0, 0x70, 0x05, 0xd1, 0x45, 0x8c, 0x23, 0x1c, 0xf0, 0xee, 0x89, 0xad,
+	0x7a, 0x4b, 0xc2, 0x2f, 0xdb, 0x5a, 0x4d, 0x76, 0x67, 0x17, 0x2d, 0xf4, 0xcb, 0xb1, 0x4a, 0xa8,
+	0xb5, 0x22, 0x47, 0x3a, 0xd5, 0x10, 0x4c, 0x72, 0xcc, 0x00, 0xf9, 0xe0, 0xfd, 0xe2, 0xfe, 0xae,
+	0xf8, 0x5f, 0xab, 0xf1, 0x1b, 0x42, 0x81, 0xd6, 0xbe, 0x44, 0x29, 0xa6, 0x57, 0xb9, 0xaf, 0xf2,
+	0xd4, 0x75, 0x66, 0xbb, 0x68, 0x9f, 0x50, 0x02, 0x01, 0x3c, 0x7f, 0x8d, 0x1a, 0x88, 0xbd, 0xac,
+	0xf7, 0xe4, 0x79, 0x96, 0xa2, 0xfc, 0x6d, 0xb2, 0x6b, 0x03, 0xe1, 0x2e, 0x7d, 0x14, 0x95, 0x1d,
+}
+
+// the key schedule constants for each key size, built at init
+var clefiaCon128, clefiaCon192, clefiaCon256 []uint32
+
+func init() {
+
+	// S0 is built from four 4-bit S-boxes
+	ss := [4][16]byte{
+		{0xe, 0x6, 0xc, 0xa, 0x8, 0x7, 0x2, 0xf, 0xb, 0x1, 0x4, 0x0, 0x5, 0x9, 0xd, 0x3},
+		{0x6, 0x4, 0x0, 0xd, 0x2, 0xb, 0xa, 0x3, 0x9, 0xc, 0xe, 0xf, 0x8, 0x7, 0x5, 0x1},
+		{0xb, 0x8, 0x5, 0xe, 0xa, 0x6, 0x4, 0xc, 0xf, 0x7, 0x2, 0x3, 0x1, 0x0, 0xd, 0x9},
+		{0xa, 0x2, 0x6, 0xd, 0x3, 0x4, 0x5, 0xe, 0x0, 0x7, 0x8, 0x9, 0xb, 0xf, 0xc, 0x1},
+	}
+
+	// doubling in GF(2^4), mod z^4+z+1
+	mul2 := func(x byte) byte {
+		x <<= 1
+		if x&0x10 != 0 {
+			x ^= 0x13
+		}
+		return x
+	}
+
+	for x := range clefiaS0 {
+		t0, t1 := ss[0][x>>4], ss[1][x&15]
+		u0, u1 := t0^mul2(t1), mul2(t0)^t1
+		clefiaS0[x] = ss[2][u0]<<4 | ss[3][u1]
+	}
+
+	clefiaCon128 = clefiaConstants(0x428a, 60)
+	clefiaCon192 = clefiaConstants(0x7137, 84)
+	clefiaCon256 = clefiaConstants(0xb5c0, 92)
+}
+
+// clefiaConstants generates n constants from the initial value iv
+func clefiaConstants(iv uint16, n int) []uint32 {
+
+	const p, q = 0xb7e1, 0x243f
+
+	con := make([]uint32, n)
+	t := iv
+	for i := 0; i < n; i += 2 {
+		con[i] = uint32(t^p)<<16 | uint32(^t<<1|^t>>15)
+		con[i+1] = uint32(^t^q)<<16 | uint32(t<<8|t>>8)
+
+		// t = t * 0x0002^-1 in GF(2^16), mod z^16+z^15+z^13+z^11+z^5+z^4+1
+		if t&1 != 0 {
+			t = (t^0xa831)>>1 | 0x8000
+		} else {
+			t >>= 1
+		}
+	}
+
+	return con
+}
+
+// clefiaMul multiplies in GF(2^8), mod z^8+z^4+z^3+z^2+1
+func clefiaMul(a, b byte) byte {
+	var r byte
+	for b != 0 {
+		if b&1 != 0 {
+			r ^= a
+		}
+		a = a<<1 ^ 0x1d&-(a>>7)
+		b >>= 1
+	}
+	return r
+}
+
+// NewClefia creates and returns a new ClefiaCipher.  The key argument should
+// be 16, 24, or 32 bytes.
+func NewClefia(key []byte) (*ClefiaCipher, error) {
+
+	c := new(ClefiaCipher)
+
+	switch len(key) {
+	case 16:
+		c.rounds = 18
+	case 24:
+		c.rounds = 22
+	case 32:
+		c.rounds = 26
+	default:
+		return nil, KeySizeError(len(key))
+	}
+
+	c.rk = make([]uint32, 2*c.rounds)
+
+	if len(key) == 16 {
+		var k [4]uint32
+		for i := range k {
+			k[i] = binary.BigEndian.Uint32(key[4*i:])
+		}
+
+		con := clefiaCon128
+		l := k
+		clefiaGFN4(&l, con[:24], 12)
+		c.wk = k
+
+		for i := 0; i < 9; i++ {
+			t := clefiaXor(l, con[24+4*i:])
+			l = clefiaSigma(l)
+			if i&1 == 1 {
+				t = clefiaXor(t, k[:])
+			}
+			copy(c.rk[4*i:], t[:])
+		}
+
+		return c, nil
+	}
+
+	var kl, kr [4]uint32
+	for i := range kl {
+		kl[i] = binary.BigEndian.Uint32(key[4*i:])
+	}
+	if len(key) == 24 {
+		kr[0] = binary.BigEndian.Uint32(key[16:])
+		kr[1] = binary.BigEndian.Uint32(key[20:])
+		kr[2], kr[3] = ^kl[0], ^kl[1]
+	} else {
+		for i := range kr {
+			kr[i] = binary.BigEndian.Uint32(key[16+4*i:])
+		}
+	}
+
+	con := clefiaCon192
+	if len(key) == 32 {
+		con = clefiaCon256
+	}
+
+	var l [8]uint32
+	copy(l[:4], kl[:])
+	copy(l[4:], kr[:])
+	clefiaGFN8(&l, con[:40], 10)
+
+	var ll, lr [4]uint32
+	copy(ll[:], l[:4])
+	copy(lr[:], l[4:])
+
+	c.wk = clefiaXor(kl, kr[:])
+
+	for i := 0; i < c.rounds/2; i++ {
+		var t [4]uint32
+		if i%4 < 2 {
+			t = clefiaXor(ll, con[40+4*i:])
+			ll = clefiaSigma(ll)
+			if i&1 == 1 {
+				t = clefiaXor(t, kr[:])
+			}
+		} else {
+			t = clefiaXor(lr, con[40+4*i:])
+			lr = clefiaSigma(lr)
+			if i&1 == 1 {
+				t = clefiaXor(t, kl[:])
+			}
+		}
+		copy(c.rk[4*i:], t[:])
+	}
+
+	return c, nil
+}
+
+// Reset zeroes the round and whitening keys.
+func (c *ClefiaCipher) Reset() {
+	for i := range c.rk {
+		c.rk[i] = 0
+	}
+	c.wk = [4]uint32{}
+}
+
+// IsWiped reports whether the round and whitening keys are all zero.
+func (c *ClefiaCipher) IsWiped() bool {
+	for _, k := range c.rk {
+		if k != 0 {
+			return false
+		}
+	}
+	return c.wk == [4]uint32{}
+}
+
+func (c *ClefiaCipher) BlockSize() int { return 16 }
+
+// Encrypt encrypts the 16-byte block in src and stores the resulting ciphertext in dst.
+func (c *ClefiaCipher) Encrypt(dst, src []byte) {
+
+	var x [4]uint32
+	for i := range x {
+		x[i] = binary.BigEndian.Uint32(src[4*i:])
+	}
+
+	x[1] ^= c.wk[0]
+	x[3] ^= c.wk[1]
+	clefiaGFN4(&x, c.rk, c.rounds)
+	x[1] ^= c.wk[2]
+	x[3] ^= c.wk[3]
+
+	for i := range x {
+		binary.BigEndian.PutUint32(dst[4*i:], x[i])
+	}
+}
+
+// Decrypt decrypts the 16-byte block in src and stores the resulting plaintext in dst.
+func (c *ClefiaCipher) Decrypt(dst, src []byte) {
+
+	var x [4]uint32
+	for i := range x {
+		x[i] = binary.BigEndian.Uint32(src[4*i:])
+	}
+
+	x[1] ^= c.wk[2]
+	x[3] ^= c.wk[3]
+
+	for i := c.rounds - 1; i >= 0; i-- {
+		x[1] ^= clefiaF0(c.rk[2*i], x[0])
+		x[3] ^= clefiaF1(c.rk[2*i+1], x[2])
+		if i > 0 {
+			x[0], x[1], x[2], x[3] = x[3], x[0], x[1], x[2]
+		}
+	}
+
+	x[1] ^= c.wk[0]
+	x[3] ^= c.wk[1]
+
+	for i := range x {
+		binary.BigEndian.PutUint32(dst[4*i:], x[i])
+	}
+}
+
+// clefiaGFN4 is the 4-branch generalized Feistel network, with no shuffle after the last round
+func clefiaGFN4(x *[4]uint32, rk []uint32, rounds int) {
+	for i := 0; i < rounds; i++ {
+		x[1] ^= clefiaF0(rk[2*i], x[0])
+		x[3] ^= clefiaF1(rk[2*i+1], x[2])
+		if i < rounds-1 {
+			x[0], x[1], x[2], x[3] = x[1], x[2], x[3], x[0]
+		}
+	}
+}
+
+// clefiaGFN8 is the 8-branch network, used by the key schedule for longer keys
+func clefiaGFN8(x *[8]uint32, rk []uint32, rounds int) {
+	for i := 0; i < rounds; i++ {
+		x[1] ^= clefiaF0(rk[4*i], x[0])
+		x[3] ^= clefiaF1(rk[4*i+1], x[2])
+		x[5] ^= clefiaF0(rk[4*i+2], x[4])
+		x[7] ^= clefiaF1(rk[4*i+3], x[6])
+		if i < rounds-1 {
+			x[0], x[1], x[2], x[3], x[4], x[5], x[6], x[7] = x[1], x[2], x[3], x[4], x[5], x[6], x[7], x[0]
+		}
+	}
+}
+
+func clefiaF0(rk, x uint32) uint32 {
+	t := rk ^ x
+	s0 := clefiaS0[t>>24]
+	s1 := clefiaS1[t>>16&0xff]
+	s2 := clefiaS0[t>>8&0xff]
+	s3 := clefiaS1[t&0xff]
+
+	// multiply by M0 = circ-had(1, 2, 4, 6)
+	y0 := s0 ^ clefiaMul(2, s1) ^ clefiaMul(4, s2) ^ clefiaMul(6, s3)
+	y1 := clefiaMul(2, s0) ^ s1 ^ clefiaMul(6, s2) ^ clefiaMul(4, s3)
+	y2 := clefiaMul(4, s0) ^ clefiaMul(6, s1) ^ s2 ^ clefiaMul(2, s3)
+	y3 := clefiaMul(6, s0) ^ clefiaMul(4, s1) ^ clefiaMul(2, s2) ^ s3
+
+	return uint32(y0)<<24 | uint32(y1)<<16 | uint32(y2)<<8 | uint32(y3)
+}
+
+func clefiaF1(rk, x uint32) uint32 {
+	t := rk ^ x
+	s0 := clefiaS1[t>>24]
+	s1 := clefiaS0[t>>16&0xff]
+	s2 := clefiaS1[t>>8&0xff]
+	s3 := clefiaS0[t&0xff]
+
+	// multiply by M1 = circ-had(1, 8, 2, a)
+	y0 := s0 ^ clefiaMul(8, s1) ^ clefiaMul(2, s2) ^ clefiaMul(10, s3)
+	y1 := clefiaMul(8, s0) ^ s1 ^ clefiaMul(10, s2) ^ clefiaMul(2, s3)
+	y2 := clefiaMul(2, s0) ^ clefiaMul(10, s1) ^ s2 ^ clefiaMul(8, s3)
+	y3 := clefiaMul(10, s0) ^ clefiaMul(2, s1) ^ clefiaMul(8, s2) ^ s3
+
+	return uint32(y0)<<24 | uint32(y1)<<16 | uint32(y2)<<8 | uint32(y3)
+}
+
+// clefiaSigma is the DoubleSwap function on a 128-bit value
+func clefiaSigma(x [4]uint32) [4]uint32 {
+	hi := uint64(x[0])<<32 | uint64(x[1])
+	lo := uint64(x[2])<<32 | uint64(x[3])
+
+	yhi := hi<<7 | lo&0x7f
+	ylo := hi>>57<<57 | lo>>7
+
+	return [4]uint32{uint32(yhi >> 32), uint32(yhi), uint32(ylo >> 32), uint32(ylo)}
+}
+
+func clefiaXor(x [4]uint32, y []uint32) [4]uint32 {
+	return [4]uint32{x[0] ^ y[0], x[1] ^ y[1], x[2] ^ y[2], x[3] ^ y[3]}
+}
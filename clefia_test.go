@@ -0,0 +1,57 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+// the test vectors from RFC 6114, one for each key size
+var clefiaTestVectors = []struct {
+	key    []byte
+	plain  []byte
+	cipher []byte
+}{
+	{
+		[]byte{0xff, 0xee, 0xdd, 0xcc, 0xbb, 0xaa, 0x99, 0x88, 0x77, 0x66, 0x55, 0x44, 0x33, 0x22, 0x11, 0x00},
+		[]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f},
+		[]byte{0xde, 0x2b, 0xf2, 0xfd, 0x9b, 0x74, 0xaa, 0xcd, 0xf1, 0x29, 0x85, 0x55, 0x45, 0x94, 0x94, 0xfd},
+	},
+	{
+		[]byte{0xff, 0xee, 0xdd, 0xcc, 0xbb, 0xaa, 0x99, 0x88, 0x77, 0x66, 0x55, 0x44, 0x33, 0x22, 0x11, 0x00, 0xf0, 0xe0, 0xd0, 0xc0, 0xb0, 0xa0, 0x90, 0x80},
+		[]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f},
+		[]byte{0xe2, 0x48, 0x2f, 0x64, 0x9f, 0x02, 0x8d, 0xc4, 0x80, 0xdd, 0xa1, 0x84, 0xfd, 0xe1, 0x81, 0xad},
+	},
+	{
+		[]byte{0xff, 0xee, 0xdd, 0xcc, 0xbb, 0xaa, 0x99, 0x88, 0x77, 0x66, 0x55, 0x44, 0x33, 0x22, 0x11, 0x00, 0xf0, 0xe0, 0xd0, 0xc0, 0xb0, 0xa0, 0x90, 0x80, 0x70, 0x60, 0x50, 0x40, 0x30, 0x20, 0x10, 0x00},
+		[]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f},
+		[]byte{0xa1, 0x39, 0x78, 0x14, 0x28, 0x9d, 0xe8, 0x0c, 0x10, 0xda, 0x46, 0xd1, 0xfa, 0x48, 0xb3, 0x8a},
+	},
+}
+
+func TestClefiaEncrypt(t *testing.T) {
+
+	for _, v := range clefiaTestVectors {
+		cl, err := NewClefia(v.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var c, p [16]byte
+
+		cl.Encrypt(c[:], v.plain)
+
+		if !bytes.Equal(v.cipher, c[:]) {
+			t.Errorf("clefia encrypt failed: got %#v wanted %#v\n", c, v.cipher)
+		}
+
+		cl.Decrypt(p[:], c[:])
+
+		if !bytes.Equal(v.plain, p[:]) {
+			t.Errorf("clefia decrypt failed: got %#v wanted %#v\n", p, v.plain)
+		}
+	}
+
+	if _, err := NewClefia(make([]byte, 20)); err != KeySizeError(20) {
+		t.Errorf("NewClefia(20 byte key)=%v, wanted %v", err, KeySizeError(20))
+	}
+}
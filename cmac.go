@@ -0,0 +1,225 @@
+package krcrypt
+
+// CMAC over SEED, or any block cipher
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+https://tools.ietf.org/html/rfc4493
+https://tools.ietf.org/html/rfc4615 (the PRF for keys of any length)
+
+*/
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"hash"
+	"io"
+)
+
+// CMAC is an instance of the CMAC (OMAC1) message authentication code: CBC-MAC
+// with the last block masked by a subkey, so it is secure for messages of any
+// length.  It works over a block cipher with an 8-, 16- or 32-byte block.
+type CMAC struct {
+	block  cipher.Block
+	bs     int      // the block size
+	size   int      // the tag size, which may be truncated
+	k1, k2 [32]byte // subkeys for a full and a padded last block
+	x      [32]byte // the chaining value
+	buf    [32]byte // the last block written, which may be the final one
+	nbuf   int
+}
+
+var _ hash.Hash = (*CMAC)(nil)
+
+// NewCMAC returns a CMAC computing a 16-byte tag using SEED with the given key.
+func NewCMAC(key []byte) (*CMAC, error) {
+	block, err := NewSEED(key)
+	if err != nil {
+		return nil, err
+	}
+	return newCMAC(block), nil
+}
+
+// newCMAC returns a CMAC over block, with a full-length tag.  It panics if
+// the block size isn't 8, 16 or 32 bytes.
+func newCMAC(block cipher.Block) *CMAC {
+
+	bs := block.BlockSize()
+	if bs != 8 && bs != 16 && bs != 32 {
+		panic(BlockSizeError(bs))
+	}
+
+	c := &CMAC{block: block, bs: bs, size: bs}
+
+	block.Encrypt(c.k1[:bs], c.k1[:bs])
+	cmacDouble(c.k1[:bs], c.k1[:bs])
+	cmacDouble(c.k2[:bs], c.k1[:bs])
+
+	return c
+}
+
+// cmacDouble sets dst to src times x in GF(2^64), GF(2^128) or GF(2^256),
+// depending on the length, with the big-endian bit order of CMAC.  The
+// 128-bit case is cmacField's; GFField only has the one width.
+func cmacDouble(dst, src []byte) {
+
+	if len(src) == 16 {
+		var x [16]byte
+		copy(x[:], src)
+		x = cmacField.Double(x)
+		copy(dst, x[:])
+		return
+	}
+
+	// the low terms of the reduction polynomials, from the CMAC spec and
+	// for 256 bits from the table of Seroussi's low-weight polynomials
+	r := uint16(0x1b)
+	if len(src) == 32 {
+		r = 0x425
+	}
+
+	n := len(src)
+	carry := src[0] >> 7
+	for i := 0; i < n-1; i++ {
+		dst[i] = src[i]<<1 | src[i+1]>>7
+	}
+	dst[n-1] = src[n-1]<<1 ^ (byte(r) & -carry)
+	dst[n-2] ^= byte(r>>8) & -carry
+}
+
+func (c *CMAC) Size() int      { return c.size }
+func (c *CMAC) BlockSize() int { return c.bs }
+
+// Reset clears the state so a new message can be authenticated with the same key.
+func (c *CMAC) Reset() {
+	c.x = [32]byte{}
+	c.nbuf = 0
+}
+
+// Write adds more data to the running MAC.  It never returns an error.
+func (c *CMAC) Write(m []byte) (int, error) {
+
+	n := len(m)
+	x, buf := c.x[:c.bs], c.buf[:c.bs]
+
+	for len(m) > 0 {
+		// only chain the buffered block once we know it isn't the last one
+		if c.nbuf == c.bs {
+			xorslice(x, x, buf)
+			c.block.Encrypt(x, x)
+			c.nbuf = 0
+		}
+		k := copy(buf[c.nbuf:], m)
+		c.nbuf += k
+		m = m[k:]
+	}
+
+	return n, nil
+}
+
+// Sum appends the tag for the data written so far to b.  It does not change
+// the underlying state, so more data can be written afterwards.
+func (c *CMAC) Sum(b []byte) []byte {
+
+	var yb [32]byte
+	y := yb[:c.bs]
+	copy(y, c.buf[:c.nbuf])
+
+	// a full last block is masked with k1, a padded one with k2
+	if c.nbuf < c.bs {
+		y[c.nbuf] = 0x80
+	}
+	var k [32]byte
+	selectBytes(subtle.ConstantTimeEq(int32(c.nbuf), int32(c.bs)), k[:c.bs], c.k1[:c.bs], c.k2[:c.bs])
+	xorslice(y, y, k[:c.bs])
+
+	xorslice(y, y, c.x[:c.bs])
+	c.block.Encrypt(y, y)
+	return append(b, y[:c.size]...)
+}
+
+// NewCMACWriter returns a writer that feeds SEED-CMAC with the given key,
+// and a function returning the tag for everything written so far.  It is for
+// authenticating a stream with io.Copy without holding it in memory.
+func NewCMACWriter(key []byte) (io.Writer, func() []byte, error) {
+	c, err := NewCMAC(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, func() []byte { return c.Sum(nil) }, nil
+}
+
+// NewSecureCBCMAC returns CMAC over any of the package's block ciphers: an
+// 8-, 16- or 32-byte block, giving a tag of the same size.
+//
+// Raw CBC-MAC, the last block of CBC encryption with a zero IV, is only
+// secure when every message has the same length.  Given the tag t of a
+// one-block message m, anyone can forge the tag of m || (m xor t), which is
+// again t.  CMAC closes that hole by masking the last block with one of two
+// subkeys derived from the cipher, one for a full block and one for a padded
+// one, so it is safe for messages of any length and needs no length prefix.
+// With a 16-byte block the result is the same as NewCMAC's.  It panics for
+// any other block size.
+func NewSecureCBCMAC(block cipher.Block) hash.Hash {
+	return newCMAC(block)
+}
+
+// newCMACPRF returns CMAC as a PRF keyed with key of any length, as RFC 4615
+// does for AES: a key that isn't 16 bytes is first replaced by its CMAC under
+// the all-zero key.  newBlock creates the cipher from a 16-byte key.
+func newCMACPRF(newBlock func([]byte) (cipher.Block, error), key []byte) *CMAC {
+
+	if len(key) != 16 {
+		block, _ := newBlock(make([]byte, 16))
+		c := newCMAC(block)
+		c.Write(key)
+		key = c.Sum(nil)
+	}
+
+	block, _ := newBlock(key)
+	return newCMAC(block)
+}
+
+// SplitKey derives two independent 16-byte keys from a 16-byte master key,
+// for modes like EME or SIV that need two, since using the same key twice
+// can be fatal.  Each subkey is SEED-CMAC under the master key of a fixed
+// label, one per subkey, so the same master always gives the same pair.  The
+// master key should not also be used directly.
+func SplitKey(master []byte) (k1, k2 []byte, err error) {
+
+	mac, err := NewCMAC(master)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mac.Write([]byte("\x01krcrypt split key"))
+	k1 = mac.Sum(nil)
+
+	mac.Reset()
+	mac.Write([]byte("\x02krcrypt split key"))
+	k2 = mac.Sum(nil)
+
+	return k1, k2, nil
+}
+
+// DeriveCBCIV returns a 16-byte CBC IV for the message identified by
+// messageID, encrypted under key: the SEED-CMAC under key of a fixed label
+// and the ID.  The IV is unpredictable to anyone without the key, as CBC
+// requires, and needs no random number generator, but messageID must never
+// repeat under the same key; a sequence number or database row ID will do.
+// It panics if key is not 16 bytes.
+func DeriveCBCIV(key, messageID []byte) []byte {
+
+	mac, err := NewCMAC(key)
+	if err != nil {
+		panic(err)
+	}
+
+	mac.Write([]byte("krcrypt cbc iv\x00"))
+	mac.Write(messageID)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,280 @@
+package krcrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestCMACVectors(t *testing.T) {
+
+	// AES-CMAC from RFC 4493
+	key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	block, _ := aes.NewCipher(key)
+
+	msg, _ := hex.DecodeString("6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710")
+
+	var tests = []struct {
+		len int
+		tag string
+	}{
+		{0, "bb1d6929e95937287fa37d129b756746"},
+		{16, "070a16b46b4d4144f79bdd9dd04a287c"},
+		{40, "dfa66747de9ae63030ca32611497c827"},
+		{64, "51f0bebf7e3b9d92fc49741779363cfe"},
+	}
+
+	c := newCMAC(block)
+	for _, tt := range tests {
+		c.Reset()
+		c.Write(msg[:tt.len])
+		if got := hex.EncodeToString(c.Sum(nil)); got != tt.tag {
+			t.Errorf("CMAC(%d bytes) failed: got %s wanted %s\n", tt.len, got, tt.tag)
+		}
+	}
+}
+
+func TestCMACHash(t *testing.T) {
+
+	// the RFC 4493 message and tags, with AES, checked through the hash.Hash methods
+	key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	block, _ := aes.NewCipher(key)
+	msg, _ := hex.DecodeString("6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710")
+	tag16, _ := hex.DecodeString("070a16b46b4d4144f79bdd9dd04a287c")
+	tag64, _ := hex.DecodeString("51f0bebf7e3b9d92fc49741779363cfe")
+
+	c := newCMAC(block)
+	k1, k2 := c.k1, c.k2
+
+	// several Writes, with a Sum part way through that must not consume anything
+	c.Write(msg[:5])
+	c.Write(msg[5:16])
+	if got := c.Sum([]byte("tag:")); string(got[:4]) != "tag:" || !bytes.Equal(got[4:], tag16) {
+		t.Errorf("Sum after 16 bytes=%x, wanted \"tag:\" followed by %x", got, tag16)
+	}
+	c.Write(msg[16:33])
+	c.Write(msg[33:])
+	if got := c.Sum(nil); !bytes.Equal(got, tag64) {
+		t.Errorf("Sum after 64 bytes=%x, wanted %x", got, tag64)
+	}
+	if got := c.Sum(nil); !bytes.Equal(got, tag64) {
+		t.Errorf("second Sum=%x, wanted %x", got, tag64)
+	}
+
+	// Reset starts a new message with the same subkeys
+	c.Reset()
+	c.Write(msg[:16])
+	if got := c.Sum(nil); !bytes.Equal(got, tag16) {
+		t.Errorf("Sum after Reset=%x, wanted %x", got, tag16)
+	}
+	if c.k1 != k1 || c.k2 != k2 {
+		t.Errorf("Reset changed the subkeys")
+	}
+}
+
+func TestCMACPRF(t *testing.T) {
+
+	// AES-CMAC-PRF-128 from RFC 4615
+	msg, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f10111213")
+
+	var tests = []struct {
+		key, prf string
+	}{
+		{"000102030405060708090a0b0c0d0e0fedcb", "84a348a4a45d235babfffc0d2b4da09a"},
+		{"000102030405060708090a0b0c0d0e0f", "980ae87b5f4c9c5214f5b6a8455e4c2d"},
+		{"00010203040506070809", "290d9e112edb09ee141fcf64c0b72f3d"},
+	}
+
+	newAES := func(k []byte) (cipher.Block, error) { return aes.NewCipher(k) }
+
+	for _, tt := range tests {
+		key, _ := hex.DecodeString(tt.key)
+		c := newCMACPRF(newAES, key)
+		c.Write(msg)
+		if got := hex.EncodeToString(c.Sum(nil)); got != tt.prf {
+			t.Errorf("CMAC-PRF(%d-byte key) failed: got %s wanted %s\n", len(key), got, tt.prf)
+		}
+	}
+}
+
+func TestSplitKey(t *testing.T) {
+
+	master := []byte("YELLOW SUBMARINE")
+
+	k1, k2, err := SplitKey(master)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(k1) != 16 || len(k2) != 16 {
+		t.Fatalf("len(k1)=%d len(k2)=%d, wanted 16", len(k1), len(k2))
+	}
+	if bytes.Equal(k1, k2) || bytes.Equal(k1, master) || bytes.Equal(k2, master) {
+		t.Errorf("SplitKey returned repeated keys: %x %x", k1, k2)
+	}
+
+	// and the same ones every time
+	j1, j2, _ := SplitKey(master)
+	if !bytes.Equal(j1, k1) || !bytes.Equal(j2, k2) {
+		t.Errorf("SplitKey isn't deterministic")
+	}
+
+	if _, _, err := SplitKey(master[:8]); err != KeySizeError(8) {
+		t.Errorf("SplitKey(8 byte key)=%v, wanted %v", err, KeySizeError(8))
+	}
+}
+
+func TestCMACWriter(t *testing.T) {
+
+	key := []byte("0123456789abcdef")
+	data := make([]byte, 100000)
+	for i := range data {
+		data[i] = byte(i * 13)
+	}
+
+	f, err := os.CreateTemp(t.TempDir(), "cmac")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write(data)
+	f.Seek(0, io.SeekStart)
+	defer f.Close()
+
+	w, tag, err := NewCMACWriter(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(w, f); err != nil {
+		t.Fatal(err)
+	}
+
+	c, _ := NewCMAC(key)
+	c.Write(data)
+	if got, want := tag(), c.Sum(nil); !bytes.Equal(got, want) {
+		t.Errorf("CMAC of copied file=%x, wanted %x", got, want)
+	}
+
+	if _, _, err := NewCMACWriter(key[:15]); err != KeySizeError(15) {
+		t.Errorf("NewCMACWriter(15-byte key) err=%v, wanted KeySizeError(15)", err)
+	}
+}
+
+func TestDeriveCBCIV(t *testing.T) {
+
+	key := []byte("0123456789abcdef")
+
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		id := []byte(fmt.Sprint("message-", i))
+		iv := DeriveCBCIV(key, id)
+		if len(iv) != 16 {
+			t.Fatalf("DeriveCBCIV returned %d bytes, wanted 16", len(iv))
+		}
+		if seen[string(iv)] {
+			t.Errorf("DeriveCBCIV(%q) repeated an earlier IV", id)
+		}
+		seen[string(iv)] = true
+
+		if again := DeriveCBCIV(key, id); !bytes.Equal(again, iv) {
+			t.Errorf("DeriveCBCIV(%q) not deterministic: %x then %x", id, iv, again)
+		}
+	}
+
+	// the same ID under another key gives another IV
+	if a, b := DeriveCBCIV(key, []byte("x")), DeriveCBCIV([]byte("fedcba9876543210"), []byte("x")); bytes.Equal(a, b) {
+		t.Errorf("DeriveCBCIV gave the same IV under two keys")
+	}
+}
+
+func TestSecureCBCMAC(t *testing.T) {
+
+	msg, _ := hex.DecodeString("6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710")
+
+	aesKey, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	aesBlock, _ := aes.NewCipher(aesKey)
+	tdesKey, _ := hex.DecodeString("8aa83bf8cbda10620bc1bf19fbb6cd58bc313d4a371ca8b5")
+	tdesBlock, _ := des.NewTripleDESCipher(tdesKey)
+
+	// AES-CMAC from RFC 4493, and TDES-CMAC from NIST SP 800-38B
+	var tests = []struct {
+		block cipher.Block
+		len   int
+		tag   string
+	}{
+		{aesBlock, 0, "bb1d6929e95937287fa37d129b756746"},
+		{aesBlock, 40, "dfa66747de9ae63030ca32611497c827"},
+		{aesBlock, 64, "51f0bebf7e3b9d92fc49741779363cfe"},
+		{tdesBlock, 0, "b7a688e122ffaf95"},
+	}
+
+	for _, tt := range tests {
+		m := NewSecureCBCMAC(tt.block)
+		m.Write(msg[:tt.len])
+		if got := hex.EncodeToString(m.Sum(nil)); got != tt.tag {
+			t.Errorf("CBC-MAC(%d-byte block, %d bytes)=%s, wanted %s", tt.block.BlockSize(), tt.len, got, tt.tag)
+		}
+	}
+
+	tf, _ := NewThreefish256(make([]byte, 32), make([]byte, 16))
+	seed, _ := NewSEED(make([]byte, 16))
+	hight, _ := NewHIGHT(make([]byte, 16))
+
+	for _, b := range []cipher.Block{hight, seed, tf} {
+		bs := b.BlockSize()
+		m := NewSecureCBCMAC(b)
+		if m.Size() != bs || m.BlockSize() != bs {
+			t.Errorf("%d-byte block: Size=%d BlockSize=%d", bs, m.Size(), m.BlockSize())
+		}
+
+		// a message and its extensions all get different tags, including
+		// the extension that forges raw CBC-MAC
+		m.Write(msg[:bs])
+		t1 := m.Sum(nil)
+
+		ext := make([]byte, bs)
+		xorslice(ext, msg[:bs], t1)
+		m.Write(ext)
+		t2 := m.Sum(nil)
+
+		m.Reset()
+		m.Write(msg[:2*bs])
+		t3 := m.Sum(nil)
+
+		m.Reset()
+		m.Write(msg[:bs-1])
+		t4 := m.Sum(nil)
+
+		if bytes.Equal(t1, t2) || bytes.Equal(t1, t3) || bytes.Equal(t2, t3) || bytes.Equal(t1, t4) {
+			t.Errorf("%d-byte block: tags of prefixes collide: %x %x %x %x", bs, t1, t2, t3, t4)
+		}
+	}
+
+	// the same as NewCMAC for SEED
+	c, _ := NewCMAC(make([]byte, 16))
+	m := NewSecureCBCMAC(seed)
+	c.Write(msg[:20])
+	m.Write(msg[:20])
+	if !bytes.Equal(c.Sum(nil), m.Sum(nil)) {
+		t.Errorf("NewSecureCBCMAC(SEED) differs from NewCMAC")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("NewSecureCBCMAC(5-byte block) didn't panic")
+		}
+	}()
+	NewSecureCBCMAC(oddBlock{})
+}
+
+// a block cipher with an unsupported block size
+type oddBlock struct{}
+
+func (oddBlock) BlockSize() int          { return 5 }
+func (oddBlock) Encrypt(dst, src []byte) { copy(dst, src) }
+func (oddBlock) Decrypt(dst, src []byte) { copy(dst, src) }
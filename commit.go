@@ -0,0 +1,75 @@
+package krcrypt
+
+// Hash-based commitments with SEED
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+https://en.wikipedia.org/wiki/One-way_compression_function#Davies%E2%80%93Meyer
+Handbook of Applied Cryptography, section 9.4.1 (algorithm 9.42)
+
+*/
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+)
+
+// the length of the random opening from Commit
+const commitOpeningSize = 32
+
+// seedDaviesMeyer hashes data to 16 bytes with SEED in the Davies-Meyer
+// construction, H = E_m(H) xor H for each 16-byte block m, after padding
+// with 0x80, zeros, and the 64-bit big-endian bit length.  The key schedule
+// runs once per block, so it is slow.
+func seedDaviesMeyer(data []byte) [16]byte {
+
+	n := len(data)
+	padded := make([]byte, (n+1+8+15)/16*16)
+	copy(padded, data)
+	padded[n] = 0x80
+	binary.BigEndian.PutUint64(padded[len(padded)-8:], uint64(n)*8)
+
+	var h, e [16]byte
+	for i := 0; i < len(padded); i += 16 {
+		c := new(SEEDCipher)
+		c.subkeys(padded[i:i+16], &kc)
+		c.Encrypt(e[:], h[:])
+		xorslice(h[:], h[:], e[:])
+	}
+
+	return h
+}
+
+// Commit commits to value, returning a 16-byte commitment to publish now and
+// a random 32-byte opening to reveal, with the value, later.  The commitment
+// is the SEED Davies-Meyer hash of opening||value.  It hides the value, since
+// the opening is unknown, and binds the committer to it, since finding
+// another value with the same commitment means finding a collision.  With a
+// 128-bit hash that takes about 2^64 work, so this is not for commitments
+// that must hold against a well-funded committer.
+func Commit(value []byte) (commitment, opening []byte, err error) {
+
+	opening = make([]byte, commitOpeningSize)
+	if _, err := rand.Read(opening); err != nil {
+		return nil, nil, err
+	}
+
+	h := seedDaviesMeyer(append(opening[:commitOpeningSize:commitOpeningSize], value...))
+	return h[:], opening, nil
+}
+
+// VerifyCommit reports whether opening and value open commitment.
+func VerifyCommit(commitment, opening, value []byte) bool {
+
+	if len(opening) != commitOpeningSize {
+		return false
+	}
+
+	h := seedDaviesMeyer(append(opening[:commitOpeningSize:commitOpeningSize], value...))
+	return subtle.ConstantTimeCompare(h[:], commitment) == 1
+}
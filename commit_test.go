@@ -0,0 +1,58 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCommit(t *testing.T) {
+
+	value := []byte("my sealed bid: 1000")
+
+	commitment, opening, err := Commit(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !VerifyCommit(commitment, opening, value) {
+		t.Errorf("VerifyCommit rejected the correct opening")
+	}
+
+	if VerifyCommit(commitment, opening, []byte("my sealed bid: 1001")) {
+		t.Errorf("VerifyCommit accepted a modified value")
+	}
+
+	bad := append([]byte(nil), opening...)
+	bad[0] ^= 1
+	if VerifyCommit(commitment, bad, value) {
+		t.Errorf("VerifyCommit accepted a modified opening")
+	}
+
+	if VerifyCommit(commitment, opening[:16], value) {
+		t.Errorf("VerifyCommit accepted a short opening")
+	}
+
+	// committing again hides the value behind a new opening
+	c2, o2, _ := Commit(value)
+	if bytes.Equal(c2, commitment) || bytes.Equal(o2, opening) {
+		t.Errorf("two commitments to the same value are equal")
+	}
+}
+
+func TestSEEDDaviesMeyer(t *testing.T) {
+
+	// lengths around the padding boundary all hash differently
+	seen := make(map[[16]byte]int)
+	data := bytes.Repeat([]byte{0}, 40)
+	for n := 0; n <= len(data); n++ {
+		h := seedDaviesMeyer(data[:n])
+		if m, ok := seen[h]; ok {
+			t.Errorf("hash of %d zero bytes equals hash of %d", n, m)
+		}
+		seen[h] = n
+
+		if seedDaviesMeyer(data[:n]) != h {
+			t.Errorf("hash of %d bytes isn't deterministic", n)
+		}
+	}
+}
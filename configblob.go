@@ -0,0 +1,49 @@
+package krcrypt
+
+// Encrypted values in configuration files
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+import (
+	"encoding"
+	"errors"
+)
+
+// ErrNoConfigKey is returned when an EncryptedBlob is marshaled or unmarshaled before SetConfigKey has been called.
+var ErrNoConfigKey = errors.New("krcrypt: no config key set")
+
+var configKey = keyedAEAD{errNoKey: ErrNoConfigKey}
+
+// SetConfigKey sets the 16-byte SEED key used by all EncryptedBlob values.
+func SetConfigKey(key []byte) error {
+	return configKey.set(key)
+}
+
+// An EncryptedBlob is a secret kept encrypted in a configuration file.  As
+// text it is the base64 of a random nonce followed by the SEED-GCM
+// ciphertext, and it is decrypted as it is unmarshaled, so a config struct
+// with an EncryptedBlob field holds the plaintext once loaded.  Any encoding
+// that uses encoding.TextMarshaler, such as encoding/json, will do.
+type EncryptedBlob []byte
+
+var (
+	_ encoding.TextMarshaler   = EncryptedBlob(nil)
+	_ encoding.TextUnmarshaler = (*EncryptedBlob)(nil)
+)
+
+// MarshalText implements encoding.TextMarshaler.
+func (b EncryptedBlob) MarshalText() ([]byte, error) {
+	return configKey.sealText(b)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (b *EncryptedBlob) UnmarshalText(text []byte) error {
+
+	plain, err := configKey.openText(text)
+	if err != nil {
+		return err
+	}
+
+	*b = EncryptedBlob(plain)
+	return nil
+}
@@ -0,0 +1,68 @@
+package krcrypt
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEncryptedBlob(t *testing.T) {
+
+	if err := SetConfigKey([]byte("0123456789abcdef")); err != nil {
+		t.Fatal(err)
+	}
+
+	type config struct {
+		User     string
+		Password EncryptedBlob
+	}
+
+	const secret = "correct horse battery staple"
+
+	text, err := json.Marshal(config{"admin", EncryptedBlob(secret)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(text), secret) {
+		t.Errorf("plaintext in the config file: %s", text)
+	}
+
+	var got config
+	if err := json.Unmarshal(text, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.User != "admin" || string(got.Password) != secret {
+		t.Errorf("round trip=%+v, wanted password %q", got, secret)
+	}
+
+	// a tampered value is rejected
+	text[len(text)-5] ^= 1
+	if err := json.Unmarshal(text, &got); err == nil {
+		t.Errorf("tampered config unmarshaled without error")
+	}
+}
+
+func TestEncryptedBlobNoKey(t *testing.T) {
+
+	configKey.Lock()
+	saved := configKey.aead
+	configKey.aead = nil
+	configKey.Unlock()
+
+	defer func() {
+		configKey.Lock()
+		configKey.aead = saved
+		configKey.Unlock()
+	}()
+
+	if _, err := EncryptedBlob("x").MarshalText(); err != ErrNoConfigKey {
+		t.Errorf("MarshalText without key=%v, wanted %v", err, ErrNoConfigKey)
+	}
+
+	var b EncryptedBlob
+	if err := b.UnmarshalText([]byte("AAAA")); err != ErrNoConfigKey {
+		t.Errorf("UnmarshalText without key=%v, wanted %v", err, ErrNoConfigKey)
+	}
+}
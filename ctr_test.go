@@ -0,0 +1,265 @@
+package krcrypt
+
+import (
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewCTR(t *testing.T) {
+
+	key := make([]byte, 16)
+	iv := make([]byte, 16)
+	iv[15] = 0xfe // make sure the counter carries
+
+	data := make([]byte, 100)
+
+	s, err := NewCTR(key, iv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]byte, len(data))
+	s.XORKeyStream(got, data)
+
+	block, _ := NewSEED(key)
+	want := make([]byte, len(data))
+	cipher.NewCTR(block, iv).XORKeyStream(want, data)
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("NewCTR keystream=%x, wanted %x", got, want)
+	}
+
+	// odd-sized pieces must give the same keystream as one call
+	s, _ = NewCTR(key, iv)
+	got = make([]byte, len(data))
+	for i := 0; i < len(data); i += 7 {
+		j := i + 7
+		if j > len(data) {
+			j = len(data)
+		}
+		s.XORKeyStream(got[i:j], data[i:j])
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("NewCTR keystream in pieces=%x, wanted %x", got, want)
+	}
+
+	if _, err := NewCTR(key, iv[:8]); !errors.Is(err, IVSizeError(8)) || !strings.HasPrefix(err.Error(), "krcrypt: NewCTR: ") {
+		t.Errorf("NewCTR(short iv)=%v, wanted %v with the operation prefixed", err, IVSizeError(8))
+	}
+
+	var kerr KeySizeError
+	if _, err := NewCTR(key[:8], iv); !errors.As(err, &kerr) || kerr != 8 {
+		t.Errorf("NewCTR(short key)=%v, wanted a KeySizeError of 8", err)
+	}
+}
+
+func TestNewCTRBounded(t *testing.T) {
+
+	key := make([]byte, 16)
+	iv := make([]byte, 16)
+
+	s, err := NewCTRBounded(key, iv, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make([]byte, 40)
+	s.XORKeyStream(got[:25], got[:25])
+	s.XORKeyStream(got[25:], got[25:])
+
+	want := make([]byte, 40)
+	u, _ := NewCTR(key, iv)
+	u.XORKeyStream(want, want)
+	if !bytes.Equal(got, want) {
+		t.Errorf("bounded keystream=%x, wanted %x", got, want)
+	}
+
+	// the limit is exact: an empty call is fine, one more byte is not
+	s.XORKeyStream(nil, nil)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("exceeding the limit didn't panic")
+		}
+	}()
+	s.XORKeyStream(got[:1], got[:1])
+}
+
+func TestSEEDCTR4(t *testing.T) {
+
+	for _, v := range seedTestVectors {
+		b, _ := NewSEED(v.key)
+		c := b.(*SEEDCipher)
+
+		var ctr [16]byte
+		copy(ctr[:], v.plain)
+		ctr[15] = 0xfe
+
+		want := make([]byte, 4*16)
+		next := ctr
+		for i := 0; i < 4; i++ {
+			c.Encrypt(want[16*i:], next[:])
+			incCounter(next[:])
+		}
+
+		got := make([]byte, 4*16)
+		c.ctr4(&ctr, got)
+		if !bytes.Equal(got, want) {
+			t.Errorf("ctr4=%x, wanted %x", got, want)
+		}
+		if ctr != next {
+			t.Errorf("ctr4 left the counter at %x, wanted %x", ctr, next)
+		}
+	}
+}
+
+func benchmarkSEEDStream(b *testing.B, s cipher.Stream) {
+	buf := make([]byte, 4096)
+	b.SetBytes(int64(len(buf)))
+	for i := 0; i < b.N; i++ {
+		s.XORKeyStream(buf, buf)
+	}
+}
+
+func BenchmarkSEEDCTR(b *testing.B) {
+	s, _ := NewCTR(make([]byte, 16), make([]byte, 16))
+	benchmarkSEEDStream(b, s)
+}
+
+func BenchmarkSEEDCTRSequential(b *testing.B) {
+	block, _ := NewSEED(make([]byte, 16))
+	benchmarkSEEDStream(b, cipher.NewCTR(block, make([]byte, 16)))
+}
+
+func TestEncryptCTRContext(t *testing.T) {
+
+	key := make([]byte, 16)
+	iv := make([]byte, 16)
+
+	data := make([]byte, 3*ctrContextChunk+5)
+	want := make([]byte, len(data))
+	s, _ := NewCTR(key, iv)
+	s.XORKeyStream(want, data)
+
+	if err := EncryptCTRContext(context.Background(), key, iv, data); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Errorf("EncryptCTRContext output differs from NewCTR")
+	}
+}
+
+// a context that is cancelled once Err has been checked 'after' times
+type cancelAfterContext struct {
+	context.Context
+	after int
+}
+
+func (c *cancelAfterContext) Err() error {
+	if c.after == 0 {
+		return context.Canceled
+	}
+	c.after--
+	return nil
+}
+
+func TestEncryptCTRContextCancel(t *testing.T) {
+
+	key := make([]byte, 16)
+	iv := make([]byte, 16)
+	data := make([]byte, 3*ctrContextChunk)
+
+	ctx := &cancelAfterContext{context.Background(), 1}
+	if err := EncryptCTRContext(ctx, key, iv, data); err != context.Canceled {
+		t.Fatalf("EncryptCTRContext=%v, wanted %v", err, context.Canceled)
+	}
+
+	// only the first chunk should have been touched
+	if bytes.Equal(data[:ctrContextChunk], make([]byte, ctrContextChunk)) {
+		t.Errorf("first chunk was not encrypted")
+	}
+	if !bytes.Equal(data[ctrContextChunk:], make([]byte, 2*ctrContextChunk)) {
+		t.Errorf("data after the first chunk was modified")
+	}
+}
+
+func TestIncrementBE(t *testing.T) {
+
+	var tests = []struct {
+		in, want []byte
+	}{
+		{[]byte{}, []byte{}},
+		{[]byte{0x00}, []byte{0x01}},
+		{[]byte{0xff}, []byte{0x00}},
+		{[]byte{0x01, 0xfe}, []byte{0x01, 0xff}},
+		{[]byte{0x01, 0xff}, []byte{0x02, 0x00}},
+		{[]byte{0x00, 0xff, 0xff, 0xff}, []byte{0x01, 0x00, 0x00, 0x00}},
+		{[]byte{0xfe, 0xff, 0xff, 0xff}, []byte{0xff, 0x00, 0x00, 0x00}},
+		{bytes.Repeat([]byte{0xff}, 16), make([]byte, 16)},
+	}
+
+	for _, tt := range tests {
+		got := append([]byte(nil), tt.in...)
+		IncrementBE(got)
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("IncrementBE(%x)=%x, wanted %x", tt.in, got, tt.want)
+		}
+	}
+
+	// the counter must step exactly as cipher.NewCTR's does
+	block, _ := NewSEED(make([]byte, 16))
+	iv := append(make([]byte, 14), 0xff, 0xfe)
+	want := make([]byte, 64)
+	cipher.NewCTR(block, iv).XORKeyStream(want, want)
+
+	got := make([]byte, 64)
+	for i := 0; i < 4; i++ {
+		block.Encrypt(got[16*i:], iv)
+		IncrementBE(iv)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("IncrementBE doesn't match cipher.NewCTR")
+	}
+}
+
+func TestKeystreamBlock(t *testing.T) {
+
+	key := []byte("0123456789abcdef")
+	c, _ := NewSEEDRounds(key, 16)
+
+	seen := make(map[[16]byte]bool)
+	for _, nonce := range []uint64{0, 1, 0x0123456789abcdef, 1 << 63} {
+		for counter := uint64(0); counter < 200; counter++ {
+			got := c.KeystreamBlock(nonce, counter)
+
+			var in, want [16]byte
+			binary.BigEndian.PutUint64(in[:], nonce)
+			binary.BigEndian.PutUint64(in[8:], counter)
+			c.Encrypt(want[:], in[:])
+			if got != want {
+				t.Fatalf("KeystreamBlock(%x, %d)=%x, wanted %x", nonce, counter, got, want)
+			}
+
+			if seen[got] {
+				t.Errorf("KeystreamBlock(%x, %d) repeated an earlier block", nonce, counter)
+			}
+			seen[got] = true
+		}
+	}
+
+	// it is the CTR keystream for the iv nonce||0
+	iv := make([]byte, 16)
+	binary.BigEndian.PutUint64(iv, 42)
+	s, _ := NewCTR(key, iv)
+	ks := make([]byte, 48)
+	s.XORKeyStream(ks, ks)
+	for i := 0; i < 3; i++ {
+		if b := c.KeystreamBlock(42, uint64(i)); !bytes.Equal(b[:], ks[16*i:16*i+16]) {
+			t.Errorf("KeystreamBlock(42, %d)=%x, wanted CTR keystream %x", i, b, ks[16*i:16*i+16])
+		}
+	}
+}
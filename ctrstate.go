@@ -0,0 +1,95 @@
+package krcrypt
+
+// Resumable SEED-CTR
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+// the CTRState MarshalBinary format version
+const ctrStateVersion = 1
+
+// A CTRState tracks how far a stream from NewResumableCTR has got, so that
+// an interrupted decryption can carry on later exactly where it stopped.  Its
+// serialized form holds the IV, the position, and a short check value for the
+// key, but not the key itself: restoring needs a CTRState made with the same
+// key.
+type CTRState struct {
+	s     *seedCTR
+	iv    [16]byte
+	n     uint64 // bytes of keystream used so far
+	check [4]byte
+}
+
+// NewResumableCTR is NewCTR with a CTRState that follows the returned stream.
+func NewResumableCTR(key, iv []byte) (*CTRState, cipher.Stream, error) {
+
+	s, err := NewCTR(key, iv)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	st := &CTRState{s: s.(*seedCTR)}
+	copy(st.iv[:], iv)
+
+	mac := newCMAC(st.s.c)
+	mac.Write([]byte("krcrypt ctr state"))
+	copy(st.check[:], mac.Sum(nil))
+
+	return st, resumableStream{st}, nil
+}
+
+// resumableStream is the stream half of a CTRState
+type resumableStream struct{ st *CTRState }
+
+func (r resumableStream) XORKeyStream(dst, src []byte) {
+	r.st.s.XORKeyStream(dst, src)
+	r.st.n += uint64(len(src))
+}
+
+// MarshalBinary returns the stream's IV and position.
+func (st *CTRState) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 1+4+16+8)
+	b[0] = ctrStateVersion
+	copy(b[1:], st.check[:])
+	copy(b[5:], st.iv[:])
+	binary.BigEndian.PutUint64(b[21:], st.n)
+	return b, nil
+}
+
+// UnmarshalBinary moves the stream to the IV and position saved by
+// MarshalBinary.  It returns ErrState if b is malformed, or an error if it
+// was saved from a stream with a different key.
+func (st *CTRState) UnmarshalBinary(b []byte) error {
+
+	if len(b) != 1+4+16+8 || b[0] != ctrStateVersion {
+		return ErrState
+	}
+
+	if subtle.ConstantTimeCompare(b[1:5], st.check[:]) != 1 {
+		return errors.New("krcrypt: CTR state saved with a different key")
+	}
+
+	copy(st.iv[:], b[5:21])
+	st.n = binary.BigEndian.Uint64(b[21:])
+
+	// start at the block holding byte n, and discard the part already used
+	s := st.s
+	s.ctr = st.iv
+	for i, carry := 15, st.n/16; i >= 0 && carry != 0; i-- {
+		sum := uint64(s.ctr[i]) + carry&0xff
+		s.ctr[i] = byte(sum)
+		carry = carry>>8 + sum>>8
+	}
+	s.used = len(s.ks)
+
+	var skip [16]byte
+	s.XORKeyStream(skip[:st.n%16], skip[:st.n%16])
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestResumableCTR(t *testing.T) {
+
+	key := []byte("0123456789abcdef")
+	iv := bytes.Repeat([]byte{0xff}, 16) // so resuming must carry through every byte
+	iv[0] = 0x7f
+
+	msg := make([]byte, 1000)
+	for i := range msg {
+		msg[i] = byte(i)
+	}
+
+	ref, _ := NewCTR(key, iv)
+	want := make([]byte, len(msg))
+	ref.XORKeyStream(want, msg)
+
+	for _, n := range []int{0, 1, 15, 16, 17, 64, 65, 500, 999, 1000} {
+		st, s, err := NewResumableCTR(key, iv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make([]byte, len(msg))
+		s.XORKeyStream(got[:n], msg[:n])
+
+		saved, _ := st.MarshalBinary()
+
+		// resume in a fresh stream, started from another IV
+		st2, s2, _ := NewResumableCTR(key, make([]byte, 16))
+		if err := st2.UnmarshalBinary(saved); err != nil {
+			t.Fatalf("UnmarshalBinary after %d bytes: %v", n, err)
+		}
+		s2.XORKeyStream(got[n:], msg[n:])
+
+		if !bytes.Equal(got, want) {
+			t.Errorf("stream resumed after %d bytes differs from a continuous one", n)
+		}
+
+		// and the resumed state can itself be saved again
+		again, _ := st2.MarshalBinary()
+		if st3, _, _ := NewResumableCTR(key, iv); st3.UnmarshalBinary(again) != nil {
+			t.Errorf("couldn't restore a state saved after resuming")
+		}
+	}
+
+	st, _, _ := NewResumableCTR(key, iv)
+	saved, _ := st.MarshalBinary()
+
+	other, _, _ := NewResumableCTR([]byte("fedcba9876543210"), iv)
+	if err := other.UnmarshalBinary(saved); err == nil {
+		t.Errorf("state restored into a stream with a different key")
+	}
+
+	if err := st.UnmarshalBinary(saved[:10]); err != ErrState {
+		t.Errorf("UnmarshalBinary(truncated)=%v, wanted %v", err, ErrState)
+	}
+}
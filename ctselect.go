@@ -0,0 +1,45 @@
+package krcrypt
+
+// Constant-time selection
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+import "crypto/subtle"
+
+// ConstantTimeSelectBytes returns a new slice holding a if cond is 1 or b if
+// cond is 0, taking the same time either way.  cond must be 0 or 1, as from
+// the functions in crypto/subtle, and a and b must have the same length; it
+// panics if they don't.
+func ConstantTimeSelectBytes(cond int, a, b []byte) []byte {
+
+	if len(a) != len(b) {
+		panic("krcrypt: ConstantTimeSelectBytes with slices of different lengths")
+	}
+
+	out := make([]byte, len(a))
+	selectBytes(cond, out, a, b)
+	return out
+}
+
+// selectBytes is ConstantTimeSelectBytes into dst, without allocating
+func selectBytes(cond int, dst, a, b []byte) {
+	for i := range dst {
+		dst[i] = byte(subtle.ConstantTimeSelect(cond, int(a[i]), int(b[i])))
+	}
+}
+
+// verifyAndRelease is the last step of every hand-written Open: it compares
+// the computed tag with the one received in constant time, and returns
+// plaintext only if they match.  Otherwise plaintext, which may be a scratch
+// buffer already holding unauthenticated output, is zeroed so none of it can
+// leak, and it returns nil and ErrAuthentication.  plaintext may be nil when
+// the tag is checked before anything is decrypted.
+func verifyAndRelease(computedTag, gotTag, plaintext []byte) ([]byte, error) {
+	if subtle.ConstantTimeCompare(computedTag, gotTag) != 1 {
+		for i := range plaintext {
+			plaintext[i] = 0
+		}
+		return nil, ErrAuthentication
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,63 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestConstantTimeSelectBytes(t *testing.T) {
+
+	a := []byte("the first block.")
+	b := []byte("the other block!")
+
+	if got := ConstantTimeSelectBytes(1, a, b); !bytes.Equal(got, a) {
+		t.Errorf("ConstantTimeSelectBytes(1)=%q, wanted %q", got, a)
+	}
+	if got := ConstantTimeSelectBytes(0, a, b); !bytes.Equal(got, b) {
+		t.Errorf("ConstantTimeSelectBytes(0)=%q, wanted %q", got, b)
+	}
+
+	// the result is always a fresh slice of the common length
+	if got := ConstantTimeSelectBytes(1, a[:5], b[:5]); len(got) != 5 || &got[0] == &a[0] {
+		t.Errorf("ConstantTimeSelectBytes returned %q, not a new 5-byte slice", got)
+	}
+
+	got := make([]byte, len(a))
+	if selectBytes(1, got, a, b); !bytes.Equal(got, a) {
+		t.Errorf("selectBytes(1)=%q, wanted %q", got, a)
+	}
+	if selectBytes(0, got, a, b); !bytes.Equal(got, b) {
+		t.Errorf("selectBytes(0)=%q, wanted %q", got, b)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("ConstantTimeSelectBytes with different lengths didn't panic")
+		}
+	}()
+	ConstantTimeSelectBytes(1, a, b[:15])
+}
+
+func TestVerifyAndRelease(t *testing.T) {
+
+	tag := []byte("0123456789abcdef")
+
+	scratch := []byte("unverified plaintext")
+	got, err := verifyAndRelease(tag, []byte("0123456789abcdeg"), scratch)
+	if got != nil || err != ErrAuthentication {
+		t.Errorf("verifyAndRelease(bad tag)=(%q,%v), wanted (nil,%v)", got, err, ErrAuthentication)
+	}
+	if !bytes.Equal(scratch, make([]byte, len(scratch))) {
+		t.Errorf("verifyAndRelease(bad tag) left the scratch buffer %q", scratch)
+	}
+
+	// a tag of the wrong length fails too
+	if _, err := verifyAndRelease(tag, tag[:8], nil); err != ErrAuthentication {
+		t.Errorf("verifyAndRelease(short tag)=%v, wanted %v", err, ErrAuthentication)
+	}
+
+	plain := []byte("verified plaintext")
+	if got, err := verifyAndRelease(tag, tag, plain); err != nil || !bytes.Equal(got, []byte("verified plaintext")) {
+		t.Errorf("verifyAndRelease(good tag)=(%q,%v), wanted %q", got, err, "verified plaintext")
+	}
+}
@@ -6,8 +6,18 @@ Licensed under the MIT License
 
 The library includes HIGHT, SEED, and ARIA.
 
-These ciphers are used almost exclusively inside Korea.
+It also includes some block ciphers from elsewhere: Speck, Noekeon, Serpent, Threefish-256, RC5, RC6, CLEFIA, GIFT, Midori, SAFER, Anubis, Khazad, RC2, Blowfish, Twofish, Camellia, and CAST-256.
 
-For more information on these ciphers, please see: http://seed.kisa.or.kr/kor/main.jsp
+SEED, ARIA and HIGHT are Korean standards, used mostly in Korean systems; the
+other ciphers come from elsewhere and are used or studied more widely.
+
+For more information on the Korean ciphers, please see: http://seed.kisa.or.kr/kor/main.jsp
+
+All byte order conversions go through encoding/binary and the package does not
+use unsafe, so ciphertext is byte-for-byte identical regardless of the host's
+endianness.  The tests pass on amd64, 386, and js/wasm (with
+go_js_wasm_exec).  On a big-endian GOARCH such as s390x they also check that
+the host really is big-endian and that every known-answer test still passes;
+running them there takes real hardware or an emulator like qemu-s390x.
 */
 package krcrypt
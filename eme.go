@@ -0,0 +1,168 @@
+package krcrypt
+
+// EME, a wide-block encryption mode, over SEED
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+http://eprint.iacr.org/2003/147 (EME)
+http://eprint.iacr.org/2004/125 (EME*, for partial blocks; not implemented)
+http://grouper.ieee.org/groups/1619/email/pdf00020.pdf (EME-32 test vectors)
+
+*/
+
+import (
+	"crypto/cipher"
+	"errors"
+)
+
+// ErrEMESectorSize is returned for a sector that isn't a multiple of 16
+// bytes, or is shorter than 16 or longer than 2048 bytes.
+var ErrEMESectorSize = errors.New("krcrypt: EME sector must be 16 to 2048 bytes, in whole blocks")
+
+// emeMaxBytes is the longest sector EME can encrypt: 128 blocks
+const emeMaxBytes = 2048
+
+// EME is the EME wide-block mode of Halevi and Rogaway over SEED.  The whole
+// sector is enciphered as a single block, so changing any byte of the
+// plaintext or the associated data changes every byte of the ciphertext,
+// unlike XTS where a change only affects its own 16-byte block.  There is
+// no expansion: the ciphertext is exactly as long as the plaintext.
+//
+// Sectors must be a whole number of 16-byte blocks, from 16 to 2048 bytes.
+// EME* would extend this to partial blocks, but isn't implemented, so other
+// lengths are rejected with ErrEMESectorSize rather than handled some
+// unproven way.
+//
+// EME is deterministic: equal sectors with equal associated data encrypt
+// equally, so pass something like the sector number as associated data.
+type EME struct {
+	b  cipher.Block
+	l  [16]byte     // E(0), the base of the whitening offsets
+	ad cipher.Block // the PMAC cipher compressing the associated data
+}
+
+// NewEME returns EME over SEED.  The key must be 32 bytes: the first half
+// keys the cipher and the second half keys the PMAC that reduces the
+// associated data to EME's 16-byte tweak.
+func NewEME(key []byte) (*EME, error) {
+
+	if klen := len(key); klen != 32 {
+		return nil, KeySizeError(klen)
+	}
+
+	b, err := NewSEED(key[:16])
+	if err != nil {
+		return nil, err
+	}
+
+	ad, err := NewSEED(key[16:])
+	if err != nil {
+		return nil, err
+	}
+
+	e := newEME(b)
+	e.ad = ad
+	return e, nil
+}
+
+// newEME returns EME over any block cipher with a 16-byte block size
+func newEME(b cipher.Block) *EME {
+	e := &EME{b: b}
+	var zero [16]byte
+	b.Encrypt(e.l[:], zero[:])
+	return e
+}
+
+// Encrypt enciphers the sector in src into dst, which may be the same slice.
+// It returns ErrEMESectorSize if src isn't a valid sector length, and panics
+// if dst is shorter than src.
+func (e *EME) Encrypt(dst, src, associatedData []byte) error {
+	t := e.tweak(associatedData)
+	return e.crypt(dst, src, &t, false)
+}
+
+// Decrypt deciphers the sector in src into dst, which may be the same slice.
+// It returns ErrEMESectorSize if src isn't a valid sector length, and panics
+// if dst is shorter than src.
+func (e *EME) Decrypt(dst, src, associatedData []byte) error {
+	t := e.tweak(associatedData)
+	return e.crypt(dst, src, &t, true)
+}
+
+// tweak compresses the associated data into a 16-byte tweak.  Empty
+// associated data gives the all-zero tweak, as in plain EME.
+func (e *EME) tweak(ad []byte) [16]byte {
+
+	var t [16]byte
+	if len(ad) == 0 {
+		return t
+	}
+
+	p := newPMAC(e.ad)
+	p.Write(ad)
+	p.Sum(t[:0])
+	return t
+}
+
+// crypt runs EME in either direction, with the tweak t.  The two directions
+// differ only in which way the cipher is run.
+func (e *EME) crypt(dst, src []byte, t *[16]byte, decrypt bool) error {
+
+	if len(src) < 16 || len(src) > emeMaxBytes || len(src)%16 != 0 {
+		return ErrEMESectorSize
+	}
+	if len(dst) < len(src) {
+		panic("krcrypt: output smaller than input")
+	}
+
+	fn := e.b.Encrypt
+	if decrypt {
+		fn = e.b.Decrypt
+	}
+
+	m := len(src) / 16
+	dst = dst[:len(src)]
+
+	// PPPj = E(Pj ^ 2^j L)
+	l := e.l
+	var x [16]byte
+	for j := 0; j < m; j++ {
+		l = XTSField.Double(l)
+		xorslice(x[:], src[16*j:16*j+16], l[:])
+		fn(dst[16*j:], x[:])
+	}
+
+	// MP = T ^ PPP1 ^ ... ^ PPPm
+	mp := *t
+	for j := 0; j < m; j++ {
+		xorslice(mp[:], mp[:], dst[16*j:16*j+16])
+	}
+
+	var mc, mm [16]byte
+	fn(mc[:], mp[:])
+	xorslice(mm[:], mp[:], mc[:])
+
+	// CCCj = PPPj ^ 2^(j-1) M, and CCC1 = MC ^ T ^ CCC2 ^ ... ^ CCCm
+	ccc1 := mc
+	xorslice(ccc1[:], ccc1[:], t[:])
+	for j := 1; j < m; j++ {
+		mm = XTSField.Double(mm)
+		xorslice(dst[16*j:16*j+16], dst[16*j:16*j+16], mm[:])
+		xorslice(ccc1[:], ccc1[:], dst[16*j:16*j+16])
+	}
+	copy(dst, ccc1[:])
+
+	// Cj = E(CCCj) ^ 2^j L
+	l = e.l
+	for j := 0; j < m; j++ {
+		l = XTSField.Double(l)
+		fn(dst[16*j:], dst[16*j:16*j+16])
+		xorslice(dst[16*j:16*j+16], dst[16*j:16*j+16], l[:])
+	}
+
+	return nil
+}
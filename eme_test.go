@@ -0,0 +1,122 @@
+package krcrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"testing"
+)
+
+// EME-AES vectors: the first is the all-zero vector from the EME-32 test
+// vectors, the others use the key 000102..0f, the tweak f0f1..ff, and the
+// plaintext 00 01 02 ..., and were checked against github.com/rfjakob/eme.
+var emeTestVectors = []struct {
+	key, tweak []byte
+	len        int
+	cipher     string
+}{
+	{make([]byte, 32), make([]byte, 16), -16, "f1b9ce8ca15a4ba9fb476905434b9fd3"},
+	{countBytes(0, 16), countBytes(0xf0, 16), 16, "5d85be6fde90e25e3c4b0fe21c03a879"},
+	{countBytes(0, 16), countBytes(0xf0, 16), 64, "c7af7c417f4681cbdd248e511e573b242f904a772321e8651e1f4618cfe72058a0b49128a0f7290a52fd210b59bdc197389982683b5ed55786270fe8499a2a2e"},
+}
+
+func countBytes(start byte, n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = start + byte(i)
+	}
+	return b
+}
+
+func TestEMEVectors(t *testing.T) {
+
+	for _, v := range emeTestVectors {
+		block, _ := aes.NewCipher(v.key)
+		e := newEME(block)
+
+		var plain []byte
+		if v.len < 0 {
+			plain = make([]byte, -v.len)
+		} else {
+			plain = countBytes(0, v.len)
+		}
+
+		var tweak [16]byte
+		copy(tweak[:], v.tweak)
+
+		c := make([]byte, len(plain))
+		e.crypt(c, plain, &tweak, false)
+		if got := hex.EncodeToString(c); got != v.cipher {
+			t.Errorf("EME encrypt failed: got %s wanted %s\n", got, v.cipher)
+		}
+
+		e.crypt(c, c, &tweak, true)
+		if !bytes.Equal(c, plain) {
+			t.Errorf("EME decrypt failed: got %#v wanted %#v\n", c, plain)
+		}
+	}
+}
+
+func TestEME(t *testing.T) {
+
+	e, err := NewEME(countBytes(0, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ad := []byte("sector 1")
+
+	for _, n := range []int{16, 32, 48, 512, 2032, 2048} {
+		plain := countBytes(0, n)
+
+		c := make([]byte, n)
+		if err := e.Encrypt(c, plain, ad); err != nil {
+			t.Fatalf("EME(%d): %v", n, err)
+		}
+
+		p := make([]byte, n)
+		e.Decrypt(p, c, ad)
+		if !bytes.Equal(p, plain) {
+			t.Errorf("EME(%d) round trip failed", n)
+		}
+
+		// in place
+		e.Decrypt(c, c, ad)
+		if !bytes.Equal(c, plain) {
+			t.Errorf("EME(%d) in-place decrypt failed", n)
+		}
+
+		// a change to the first or last plaintext byte, or to the
+		// associated data, should change every ciphertext block
+		e.Encrypt(c, plain, ad)
+		for _, change := range []func(p, ad []byte){
+			func(p, ad []byte) { p[0] ^= 1 },
+			func(p, ad []byte) { p[len(p)-1] ^= 1 },
+			func(p, ad []byte) { ad[len(ad)-1] ^= 1 },
+		} {
+			p2, ad2 := append([]byte(nil), plain...), append([]byte(nil), ad...)
+			change(p2, ad2)
+			c2 := make([]byte, n)
+			e.Encrypt(c2, p2, ad2)
+			for i := 0; i < n; i += 16 {
+				if bytes.Equal(c[i:i+16], c2[i:i+16]) {
+					t.Errorf("EME(%d): block at %d unchanged", n, i)
+				}
+			}
+		}
+	}
+
+	if _, err := NewEME(make([]byte, 16)); err != KeySizeError(16) {
+		t.Errorf("NewEME(16 bytes)=%v, wanted %v", err, KeySizeError(16))
+	}
+
+	// partial blocks would need EME*
+	for _, n := range []int{0, 15, 17, 31, 100, 2047, 2049, 2064} {
+		if err := e.Encrypt(make([]byte, n), make([]byte, n), nil); err != ErrEMESectorSize {
+			t.Errorf("EME(%d)=%v, wanted %v", n, err, ErrEMESectorSize)
+		}
+		if err := e.Decrypt(make([]byte, n), make([]byte, n), nil); err != ErrEMESectorSize {
+			t.Errorf("EME decrypt(%d)=%v, wanted %v", n, err, ErrEMESectorSize)
+		}
+	}
+}
@@ -0,0 +1,528 @@
+package krcrypt
+
+// A seekable encrypted file format
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// ErrEncryptedFile is returned when an encrypted file is malformed, tampered
+// with, was not closed cleanly, or is encrypted under a different key.
+var ErrEncryptedFile = errors.New("krcrypt: corrupt encrypted file")
+
+// the encrypted file header: magic and version
+var encFileHeader = []byte{'K', 'R', 'E', 'F', 1}
+
+const (
+	encFileChunk     = 4096              // plaintext bytes per chunk, and bytes per block
+	encFileEntrySize = 8 + 12 + 16       // an index entry: the chunk's block, nonce and tag
+	encFileSlot      = 8 + 8 + 8 + 16    // a commit: generation, size, index block and MAC
+	encFileDataStart = 5 + 2*encFileSlot // where the data area begins, after the header and slots
+)
+
+// An EncryptedFile is a file encrypted in 4096-byte chunks, allowing random
+// access with ReadAt and WriteAt that only decrypts and re-encrypts the
+// chunks touched.  The format is the 5-byte header "KREF\x01", then two
+// 40-byte commit slots, then a data area of 4096-byte blocks.  Each chunk's
+// SEED-GCM ciphertext, without its tag, sits in a block of its own, and an
+// index, stored in a run of blocks, holds every chunk's 8-byte block number,
+// random 12-byte nonce and 16-byte tag.  A slot holds a generation number,
+// the plaintext size, the index's first block, and a SEED-CMAC over the
+// header, those fields and the index.  Each chunk's position is authenticated
+// along with it, and the MAC ties the index together, so chunks can't be
+// moved, rolled back, or truncated away unnoticed.
+//
+// Nothing that the last commit refers to is ever overwritten: changed chunks
+// and each new index go to free blocks.  Sync and Close commit by writing
+// the next generation into the older of the two slots, after flushing
+// everything it refers to, and opening uses the valid slot with the highest
+// generation.  A crash at any point therefore leaves the file as it was at
+// the last Sync or Close, never a mixture.  Blocks freed by a commit are
+// reused, and free blocks at the end of the file are truncated away.  The
+// methods may be called from several goroutines.
+type EncryptedFile struct {
+	mu     sync.Mutex
+	f      *os.File
+	aead   cipher.AEAD
+	mac    *CMAC
+	size   int64
+	index  []encFileEntry
+	blocks []uint8 // the state of each block of the data area
+	gen    uint64  // the generation of the last commit
+	dirty  bool
+	closed bool
+
+	// writes everything to the file; tests pass one that fails part way
+	writeAt func(*os.File, []byte, int64) (int, error)
+}
+
+// an index entry: where a chunk is and how to open it
+type encFileEntry struct {
+	block int64
+	nonce [12]byte
+	tag   [16]byte
+}
+
+// block states; a block with neither bit set is free
+const (
+	blockCommitted = 1 << iota // referred to by the last commit
+	blockPending               // holds a chunk of the current contents
+)
+
+// encryptedFileKeys derives the chunk encryption and the index MAC from the key
+func encryptedFileKeys(key []byte) (cipher.AEAD, *CMAC, error) {
+
+	kdf, err := NewCMAC(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kdf.Write([]byte("\x01krcrypt file encryption"))
+	aead, err := NewGCM(kdf.Sum(nil))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kdf.Reset()
+	kdf.Write([]byte("\x02krcrypt file mac"))
+	mac, err := NewCMAC(kdf.Sum(nil))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return aead, mac, nil
+}
+
+// OpenEncryptedFile opens the encrypted file at path with the 16-byte key for
+// reading and writing, creating an empty one if it doesn't exist.
+func OpenEncryptedFile(key []byte, path string) (*EncryptedFile, error) {
+	return openEncryptedFile(key, path, (*os.File).WriteAt)
+}
+
+// openEncryptedFile is OpenEncryptedFile, doing all its writes with writeAt
+func openEncryptedFile(key []byte, path string, writeAt func(*os.File, []byte, int64) (int, error)) (*EncryptedFile, error) {
+
+	aead, mac, err := encryptedFileKeys(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &EncryptedFile{f: f, aead: aead, mac: mac, writeAt: writeAt}
+
+	fi, err := f.Stat()
+	if err == nil {
+		if fi.Size() == 0 {
+			if _, err = e.writeAt(f, encFileHeader, 0); err == nil {
+				e.dirty = true
+				err = e.sync()
+			}
+		} else {
+			err = e.load(fi.Size())
+		}
+	}
+
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// blockOffset returns where block b starts in the file
+func blockOffset(b int64) int64 {
+	return encFileDataStart + b*encFileChunk
+}
+
+// indexBlocks returns how many blocks an index of n entries takes
+func indexBlocks(n int) int {
+	return (n*encFileEntrySize + encFileChunk - 1) / encFileChunk
+}
+
+// load reads the newest valid commit of an existing file of length flen
+func (e *EncryptedFile) load(flen int64) error {
+
+	if flen < encFileDataStart {
+		return ErrEncryptedFile
+	}
+
+	head := make([]byte, encFileDataStart)
+	if _, err := e.f.ReadAt(head, 0); err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(head[:len(encFileHeader)], encFileHeader) != 1 {
+		return ErrEncryptedFile
+	}
+
+	nblocks := (flen - encFileDataStart + encFileChunk - 1) / encFileChunk
+
+	var index []byte
+	var first int64
+	for s := 0; s < 2; s++ {
+		slot := head[len(encFileHeader)+s*encFileSlot:][:encFileSlot]
+		gen := binary.BigEndian.Uint64(slot)
+		size := binary.BigEndian.Uint64(slot[8:])
+		ib := binary.BigEndian.Uint64(slot[16:])
+
+		// a torn or never written slot fails its MAC; the sizes are checked
+		// first so a garbage one can't ask for a huge read
+		if gen <= e.gen || size > uint64(nblocks)*encFileChunk || ib > uint64(nblocks) {
+			continue
+		}
+		n := int((size + encFileChunk - 1) / encFileChunk)
+		if int64(ib)+int64(indexBlocks(n)) > nblocks {
+			continue
+		}
+
+		buf := make([]byte, n*encFileEntrySize)
+		if _, err := e.f.ReadAt(buf, blockOffset(int64(ib))); err != nil && err != io.EOF {
+			return err
+		} else if err == io.EOF {
+			continue
+		}
+
+		if subtle.ConstantTimeCompare(e.slotMAC(slot[:24], buf), slot[24:]) != 1 {
+			continue
+		}
+
+		e.gen, e.size, first, index = gen, int64(size), int64(ib), buf
+	}
+
+	if e.gen == 0 {
+		return ErrEncryptedFile
+	}
+
+	e.blocks = make([]uint8, nblocks)
+	e.index = make([]encFileEntry, len(index)/encFileEntrySize)
+	for i := range e.index {
+		b := index[i*encFileEntrySize:]
+		ent := &e.index[i]
+		ent.block = int64(binary.BigEndian.Uint64(b))
+		if ent.block < 0 || ent.block >= nblocks {
+			return ErrEncryptedFile
+		}
+		copy(ent.nonce[:], b[8:])
+		copy(ent.tag[:], b[20:])
+		e.blocks[ent.block] = blockCommitted | blockPending
+	}
+	for b := first; b < first+int64(indexBlocks(len(e.index))); b++ {
+		e.blocks[b] |= blockCommitted
+	}
+
+	return nil
+}
+
+// slotMAC returns the MAC over the header, a slot's generation, size and
+// index block, and the encoded index
+func (e *EncryptedFile) slotMAC(fields, index []byte) []byte {
+	e.mac.Reset()
+	e.mac.Write(encFileHeader)
+	e.mac.Write(fields)
+	e.mac.Write(index)
+	return e.mac.Sum(nil)
+}
+
+// alloc returns the first of n consecutive free blocks, extending the data
+// area if there aren't any
+func (e *EncryptedFile) alloc(n int) int64 {
+
+	if n == 0 {
+		return 0
+	}
+
+	run := 0
+	for b, st := range e.blocks {
+		if st != 0 {
+			run = 0
+			continue
+		}
+		if run++; run == n {
+			return int64(b - n + 1)
+		}
+	}
+
+	start := len(e.blocks) - run
+	for len(e.blocks) < start+n {
+		e.blocks = append(e.blocks, 0)
+	}
+	return int64(start)
+}
+
+// Size returns the length of the plaintext.
+func (e *EncryptedFile) Size() int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.size
+}
+
+// chunkAD is the associated data for chunk i: the header and the position
+func chunkAD(i int64) []byte {
+	ad := make([]byte, len(encFileHeader)+8)
+	copy(ad, encFileHeader)
+	binary.BigEndian.PutUint64(ad[len(encFileHeader):], uint64(i))
+	return ad
+}
+
+// readChunk decrypts and checks chunk i
+func (e *EncryptedFile) readChunk(i int64) ([]byte, error) {
+
+	n := e.size - i*encFileChunk
+	if n > encFileChunk {
+		n = encFileChunk
+	}
+
+	ent := &e.index[i]
+	sealed := make([]byte, n, n+16)
+	if _, err := e.f.ReadAt(sealed, blockOffset(ent.block)); err != nil {
+		if err == io.EOF {
+			err = ErrEncryptedFile
+		}
+		return nil, err
+	}
+	sealed = append(sealed, ent.tag[:]...)
+
+	plain, err := e.aead.Open(sealed[:0], ent.nonce[:], sealed, chunkAD(i))
+	if err != nil {
+		return nil, ErrEncryptedFile
+	}
+
+	return plain, nil
+}
+
+// writeChunk encrypts plain, at most a chunk long, as chunk i under a fresh
+// nonce, into a free block.  The chunk's previous block is kept if the last
+// commit refers to it, and freed otherwise.
+func (e *EncryptedFile) writeChunk(i int64, plain []byte) error {
+
+	var ent encFileEntry
+	if _, err := rand.Read(ent.nonce[:]); err != nil {
+		return err
+	}
+
+	sealed := e.aead.Seal(nil, ent.nonce[:], plain, chunkAD(i))
+	copy(ent.tag[:], sealed[len(plain):])
+
+	ent.block = e.alloc(1)
+	if _, err := e.writeAt(e.f, sealed[:len(plain)], blockOffset(ent.block)); err != nil {
+		return err
+	}
+	e.blocks[ent.block] |= blockPending
+
+	if i < int64(len(e.index)) {
+		e.blocks[e.index[i].block] &^= blockPending
+		e.index[i] = ent
+	} else {
+		e.index = append(e.index, ent)
+	}
+	e.dirty = true
+
+	if end := i*encFileChunk + int64(len(plain)); end > e.size {
+		e.size = end
+	}
+
+	return nil
+}
+
+// ReadAt implements io.ReaderAt, decrypting only the chunks that hold
+// p[0:len(p)].  It returns ErrEncryptedFile if any of them fail to
+// authenticate.
+func (e *EncryptedFile) ReadAt(p []byte, off int64) (int, error) {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return 0, ErrClosed
+	}
+	if off < 0 {
+		return 0, errors.New("krcrypt: negative offset")
+	}
+
+	n := 0
+	for n < len(p) && off < e.size {
+		plain, err := e.readChunk(off / encFileChunk)
+		if err != nil {
+			return n, err
+		}
+		k := copy(p[n:], plain[off%encFileChunk:])
+		n += k
+		off += int64(k)
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// WriteAt implements io.WriterAt, re-encrypting the chunks that p touches.
+// Writing past the end grows the file, filling any gap with zeros.
+func (e *EncryptedFile) WriteAt(p []byte, off int64) (int, error) {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return 0, ErrClosed
+	}
+	if off < 0 {
+		return 0, errors.New("krcrypt: negative offset")
+	}
+
+	end := off + int64(len(p))
+	newSize := e.size
+	if end > newSize {
+		newSize = end
+	}
+
+	// a gap after the current end is rewritten as zeros along with p
+	start := off
+	if e.size < start {
+		start = e.size
+	}
+
+	for i := start / encFileChunk; i*encFileChunk < end; i++ {
+		cstart := i * encFileChunk
+		cend := cstart + encFileChunk
+		if cend > newSize {
+			cend = newSize
+		}
+
+		plain := make([]byte, cend-cstart)
+		if cstart < e.size {
+			old, err := e.readChunk(i)
+			if err != nil {
+				return 0, err
+			}
+			copy(plain, old)
+		}
+
+		from, to := off, end
+		if from < cstart {
+			from = cstart
+		}
+		if to > cend {
+			to = cend
+		}
+		if from < to {
+			copy(plain[from-cstart:], p[from-off:to-off])
+		}
+
+		if err := e.writeChunk(i, plain); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// Sync commits the changes so far, flushing them to disk.
+func (e *EncryptedFile) Sync() error {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return ErrClosed
+	}
+
+	return e.sync()
+}
+
+// sync commits the current contents: the index goes to free blocks, and once
+// it and the chunks are on disk the next generation is written to the older
+// slot, which is the single write that switches the file over.
+func (e *EncryptedFile) sync() error {
+
+	if !e.dirty {
+		return nil
+	}
+
+	index := make([]byte, 0, len(e.index)*encFileEntrySize)
+	for _, ent := range e.index {
+		index = binary.BigEndian.AppendUint64(index, uint64(ent.block))
+		index = append(index, ent.nonce[:]...)
+		index = append(index, ent.tag[:]...)
+	}
+
+	nb := indexBlocks(len(e.index))
+	first := e.alloc(nb)
+	if nb > 0 {
+		if _, err := e.writeAt(e.f, index, blockOffset(first)); err != nil {
+			return err
+		}
+	}
+	if err := e.f.Sync(); err != nil {
+		return err
+	}
+
+	gen := e.gen + 1
+	slot := make([]byte, 24, encFileSlot)
+	binary.BigEndian.PutUint64(slot, gen)
+	binary.BigEndian.PutUint64(slot[8:], uint64(e.size))
+	binary.BigEndian.PutUint64(slot[16:], uint64(first))
+	slot = append(slot, e.slotMAC(slot, index)...)
+
+	if _, err := e.writeAt(e.f, slot, int64(len(encFileHeader))+int64(gen%2)*encFileSlot); err != nil {
+		return err
+	}
+	if err := e.f.Sync(); err != nil {
+		return err
+	}
+
+	// what the new commit refers to is now the only thing to protect
+	for b, st := range e.blocks {
+		e.blocks[b] = st &^ blockCommitted
+		if st&blockPending != 0 {
+			e.blocks[b] |= blockCommitted
+		}
+	}
+	for b := first; b < first+int64(nb); b++ {
+		e.blocks[b] |= blockCommitted
+	}
+	e.gen = gen
+	e.dirty = false
+
+	// give back free blocks at the end; failing to is harmless
+	n := len(e.blocks)
+	for n > 0 && e.blocks[n-1] == 0 {
+		n--
+	}
+	if n < len(e.blocks) && e.f.Truncate(blockOffset(int64(n))) == nil {
+		e.blocks = e.blocks[:n]
+	}
+
+	return nil
+}
+
+// Close commits the changes so far, as Sync does, and closes the file.
+func (e *EncryptedFile) Close() error {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return ErrClosed
+	}
+	e.closed = true
+
+	err := e.sync()
+	if cerr := e.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
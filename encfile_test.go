@@ -0,0 +1,189 @@
+package krcrypt
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptedFile(t *testing.T) {
+
+	key := []byte("0123456789abcdef")
+	path := filepath.Join(t.TempDir(), "data.enc")
+	rnd := rand.New(rand.NewSource(1))
+
+	e, err := OpenEncryptedFile(key, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ref []byte
+	for i := 0; i < 300; i++ {
+		off := rnd.Int63n(int64(len(ref)) + 3*encFileChunk)
+		buf := make([]byte, rnd.Intn(2*encFileChunk))
+
+		if rnd.Intn(2) == 0 {
+			rnd.Read(buf)
+			if _, err := e.WriteAt(buf, off); err != nil {
+				t.Fatalf("WriteAt(%d bytes at %d): %v", len(buf), off, err)
+			}
+			if end := off + int64(len(buf)); end > int64(len(ref)) {
+				ref = append(ref, make([]byte, end-int64(len(ref)))...)
+			}
+			copy(ref[off:], buf)
+		} else {
+			n, err := e.ReadAt(buf, off)
+			want := 0
+			if off < int64(len(ref)) {
+				want = min(len(buf), len(ref)-int(off))
+			}
+			if n != want || (n < len(buf) && err != io.EOF) || (n == len(buf) && err != nil) {
+				t.Fatalf("ReadAt(%d bytes at %d)=%d, %v, wanted %d bytes from %d", len(buf), off, n, err, want, len(ref))
+			}
+			if n > 0 && !bytes.Equal(buf[:n], ref[off:off+int64(n)]) {
+				t.Fatalf("ReadAt(%d bytes at %d) differs from the reference", len(buf), off)
+			}
+		}
+
+		// now and then close and reopen, so the index goes through the disk
+		if i%50 == 49 {
+			if err := e.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if e, err = OpenEncryptedFile(key, path); err != nil {
+				t.Fatalf("reopening: %v", err)
+			}
+		}
+
+		if e.Size() != int64(len(ref)) {
+			t.Fatalf("Size()=%d, wanted %d", e.Size(), len(ref))
+		}
+	}
+
+	got := make([]byte, len(ref))
+	if _, err := e.ReadAt(got, 0); err != nil || !bytes.Equal(got, ref) {
+		t.Errorf("whole file read back differs from the reference (err=%v)", err)
+	}
+	e.Close()
+
+	if _, err := e.ReadAt(got, 0); err != ErrClosed {
+		t.Errorf("ReadAt after Close=%v, wanted %v", err, ErrClosed)
+	}
+
+	// look for some written data, rather than the zeros of a gap
+	sample := ref[:64]
+	for i := 0; i+64 <= len(ref) && bytes.Count(sample, []byte{0}) > 8; i += 64 {
+		sample = ref[i : i+64]
+	}
+	raw, _ := os.ReadFile(path)
+	if bytes.Contains(raw, sample) {
+		t.Errorf("plaintext found in the encrypted file")
+	}
+
+	if _, err := OpenEncryptedFile([]byte("fedcba9876543210"), path); err != ErrEncryptedFile {
+		t.Errorf("OpenEncryptedFile(wrong key)=%v, wanted %v", err, ErrEncryptedFile)
+	}
+
+	// a flipped bit in the data is caught, but only by reads of its chunk
+	e, _ = OpenEncryptedFile(key, path)
+	raw[blockOffset(e.index[1].block)+7] ^= 1
+	e.f.Close()
+	os.WriteFile(path, raw, 0600)
+	e, err = OpenEncryptedFile(key, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.ReadAt(got[:100], 0); err != nil {
+		t.Errorf("ReadAt of an untouched chunk=%v, wanted success", err)
+	}
+	if _, err := e.ReadAt(got[:100], encFileChunk); err != ErrEncryptedFile {
+		t.Errorf("ReadAt of a tampered chunk=%v, wanted %v", err, ErrEncryptedFile)
+	}
+	e.Close()
+
+	// a file whose header is damaged doesn't open
+	raw[0] ^= 1
+	os.WriteFile(path, raw, 0600)
+	if _, err := OpenEncryptedFile(key, path); err != ErrEncryptedFile {
+		t.Errorf("OpenEncryptedFile(bad header)=%v, wanted %v", err, ErrEncryptedFile)
+	}
+}
+
+// errCrash is what the interrupted writes of TestEncryptedFileCrash return
+var errCrash = errors.New("simulated crash")
+
+func TestEncryptedFileCrash(t *testing.T) {
+
+	key := []byte("0123456789abcdef")
+	rnd := rand.New(rand.NewSource(2))
+
+	before := make([]byte, 3*encFileChunk+100)
+	rnd.Read(before)
+
+	// overwrite across two chunks and grow the file by several more
+	after := append(append([]byte(nil), before...), make([]byte, 3*encFileChunk)...)
+	rnd.Read(after[encFileChunk-50 : 2*encFileChunk+50])
+	rnd.Read(after[len(before)+10:])
+
+	for crashAt := 0; ; crashAt++ {
+
+		path := filepath.Join(t.TempDir(), "data.enc")
+
+		// once armed, after crashAt more writes one is torn half way and
+		// the process dies
+		armed, writes := false, 0
+		writeAt := func(f *os.File, b []byte, off int64) (int, error) {
+			if armed {
+				if writes++; writes > crashAt {
+					n, _ := f.WriteAt(b[:len(b)/2], off)
+					return n, errCrash
+				}
+			}
+			return f.WriteAt(b, off)
+		}
+
+		e, err := openEncryptedFile(key, path, writeAt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.WriteAt(before, 0)
+		if err := e.Sync(); err != nil {
+			t.Fatal(err)
+		}
+
+		armed = true
+		_, err = e.WriteAt(after[encFileChunk-50:], encFileChunk-50)
+		if err == nil {
+			err = e.Sync()
+		}
+		e.f.Close()
+
+		crashed := err != nil
+		if crashed && err != errCrash {
+			t.Fatalf("crash after %d writes: %v", crashAt, err)
+		}
+		want := before
+		if !crashed {
+			want = after
+		}
+
+		e, err = OpenEncryptedFile(key, path)
+		if err != nil {
+			t.Fatalf("crash after %d writes: reopening: %v", crashAt, err)
+		}
+		got := make([]byte, e.Size())
+		if _, err := e.ReadAt(got, 0); err != nil || !bytes.Equal(got, want) {
+			t.Errorf("crash after %d writes: read back %d bytes (err=%v), wanted the %d written before the crash", crashAt, len(got), err, len(want))
+		}
+		e.Close()
+
+		// stop once there were too few writes to crash in
+		if !crashed {
+			break
+		}
+	}
+}
@@ -0,0 +1,25 @@
+//go:build armbe || arm64be || m68k || mips || mips64 || mips64p32 || ppc || ppc64 || s390 || s390x || shbe || sparc || sparc64
+
+package krcrypt
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// This file is only built for big-endian targets, where a host-endian word
+// load would give different ciphertext from the one every known-answer test
+// was written on.  Run it under qemu, e.g. with GOARCH=s390x and
+// qemu-s390x registered with binfmt_misc.
+func TestBigEndianHost(t *testing.T) {
+
+	var b [2]byte
+	binary.NativeEndian.PutUint16(b[:], 1)
+	if b[0] != 0 {
+		t.Fatalf("built for a big-endian GOARCH, but the host is little-endian")
+	}
+
+	if err := SelfTestAll(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,80 @@
+package krcrypt
+
+// SEED-CBC for IPsec ESP
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+http://tools.ietf.org/html/rfc4196
+http://tools.ietf.org/html/rfc4303#section-2.4
+
+*/
+
+import "crypto/cipher"
+
+// ESPEncrypt encrypts an ESP payload with SEED-CBC as described in RFC 4196.
+// The payload is followed by the ESP trailer: monotonic padding bytes 1, 2, 3,
+// ..., the pad length, and nextHeader (the protocol of the payload, e.g. 4 for
+// IPv4), bringing it to a multiple of 16 bytes.  The result is the 16-byte iv
+// followed by the ciphertext, as carried in the ESP packet.
+func ESPEncrypt(key, iv, payload []byte, nextHeader byte) ([]byte, error) {
+
+	block, err := NewSEED(key)
+	if err != nil {
+		return nil, err
+	}
+
+	bs := block.BlockSize()
+	if len(iv) != bs {
+		return nil, IVSizeError(len(iv))
+	}
+
+	padLen := (bs - (len(payload)+2)%bs) % bs
+
+	out := make([]byte, bs, bs+len(payload)+padLen+2)
+	copy(out, iv)
+	out = append(out, payload...)
+	for i := 1; i <= padLen; i++ {
+		out = append(out, byte(i))
+	}
+	out = append(out, byte(padLen), nextHeader)
+
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out[bs:], out[bs:])
+	return out, nil
+}
+
+// ESPDecrypt reverses ESPEncrypt, taking the IV from the front of data.  It
+// checks the trailer and returns ErrPadding if the padding is inconsistent.
+func ESPDecrypt(key, data []byte) (payload []byte, nextHeader byte, err error) {
+
+	block, err := NewSEED(key)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	bs := block.BlockSize()
+	if len(data) < 2*bs || len(data)%bs != 0 {
+		return nil, 0, ErrCiphertextLength
+	}
+
+	plain := make([]byte, len(data)-bs)
+	cipher.NewCBCDecrypter(block, data[:bs]).CryptBlocks(plain, data[bs:])
+
+	nextHeader = plain[len(plain)-1]
+	padLen := int(plain[len(plain)-2])
+	if padLen+2 > len(plain) {
+		return nil, 0, ErrPadding
+	}
+
+	end := len(plain) - 2 - padLen
+	for i, p := range plain[end : len(plain)-2] {
+		if int(p) != i+1 {
+			return nil, 0, ErrPadding
+		}
+	}
+
+	return plain[:end], nextHeader, nil
+}
@@ -0,0 +1,64 @@
+package krcrypt
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+)
+
+func TestESP(t *testing.T) {
+
+	key := []byte("0123456789abcdef")
+	iv := []byte("fedcba9876543210")
+
+	for n := 0; n < 40; n++ {
+		payload := make([]byte, n)
+		for i := range payload {
+			payload[i] = byte(0xa0 + i)
+		}
+
+		out, err := ESPEncrypt(key, iv, payload, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(out[:16], iv) || len(out)%16 != 0 || len(out)-16 < n+2 || len(out)-16 >= n+2+16 {
+			t.Fatalf("ESPEncrypt(len=%d) produced a malformed packet of length %d", n, len(out))
+		}
+
+		got, nh, err := ESPDecrypt(key, out)
+		if err != nil || nh != 4 || !bytes.Equal(got, payload) {
+			t.Errorf("ESPDecrypt(len=%d)=(%x,%d,%v), wanted (%x,4,nil)", n, got, nh, err, payload)
+		}
+	}
+}
+
+func TestESPBadTrailer(t *testing.T) {
+
+	key := []byte("0123456789abcdef")
+	iv := []byte("fedcba9876543210")
+	block, _ := NewSEED(key)
+
+	encrypt := func(plain []byte) []byte {
+		out := append([]byte(nil), iv...)
+		ct := make([]byte, len(plain))
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(ct, plain)
+		return append(out, ct...)
+	}
+
+	// payload, padding 1..5 with the third byte wrong, pad length, next header
+	bad := []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 2, 9, 4, 5, 5, 41}
+	if _, _, err := ESPDecrypt(key, encrypt(bad)); err != ErrPadding {
+		t.Errorf("non-monotonic padding: got %v, wanted %v", err, ErrPadding)
+	}
+
+	// pad length longer than the packet
+	bad = []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 200, 41}
+	if _, _, err := ESPDecrypt(key, encrypt(bad)); err != ErrPadding {
+		t.Errorf("overlong pad length: got %v, wanted %v", err, ErrPadding)
+	}
+
+	if _, _, err := ESPDecrypt(key, make([]byte, 40)); err != ErrCiphertextLength {
+		t.Errorf("misaligned packet: got %v, wanted %v", err, ErrCiphertextLength)
+	}
+}
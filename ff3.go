@@ -0,0 +1,231 @@
+package krcrypt
+
+// FF3-1 format-preserving encryption over SEED
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+http://nvlpubs.nist.gov/nistpubs/SpecialPublications/NIST.SP.800-38Gr1-draft.pdf
+http://csrc.nist.gov/CSRC/media/Projects/Cryptographic-Standards-and-Guidelines/documents/examples/FF3samples.pdf
+
+*/
+
+import (
+	"crypto/cipher"
+	"errors"
+	"math/big"
+)
+
+// ErrRadix is returned for a radix outside 2 to 65536
+var ErrRadix = errors.New("krcrypt: radix must be 2 to 65536")
+
+// ErrFPEInput is returned when the input to format-preserving encryption has
+// the wrong length or a numeral not less than the radix.
+var ErrFPEInput = errors.New("krcrypt: invalid input for format-preserving encryption")
+
+// FF3 is an instance of FF3-1 format-preserving encryption, which encrypts a
+// string of numerals in some radix to another string of the same length in
+// the same radix: a 16-digit card number to another 16-digit number, for
+// example.  FF3-1 is an 8-round Feistel network using SEED as the round
+// function, and needs only one cipher call per round however long the input,
+// so it is faster than FF1 for short inputs.
+//
+// Numerals are uint16s, each less than the radix.  The input length must be
+// at least MinLen, so that the domain holds at least a million values, and at
+// most MaxLen, so that each half fits in the 96 bits the round function takes.
+type FF3 struct {
+	b      cipher.Block
+	tl, tr [4]byte // the tweak halves
+	radix  int
+	minLen int
+	maxLen int
+}
+
+// NewFF3 returns FF3-1 with SEED under the 16-byte key, the 7-byte (56-bit)
+// tweak, and the given radix.
+func NewFF3(key, tweak []byte, radix int) (*FF3, error) {
+
+	if klen := len(key); klen != 16 {
+		return nil, KeySizeError(klen)
+	}
+
+	if tlen := len(tweak); tlen != 7 {
+		return nil, TweakSizeError(tlen)
+	}
+
+	// FF3 runs the cipher on the byte-reversed key
+	var k [16]byte
+	for i := range k {
+		k[i] = key[15-i]
+	}
+
+	b, err := NewSEED(k[:])
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := newFF3(b, radix)
+	if err != nil {
+		return nil, err
+	}
+
+	// T_L is the first 28 bits and T_R the last 24 bits followed by bits 28..31
+	f.tl = [4]byte{tweak[0], tweak[1], tweak[2], tweak[3] & 0xf0}
+	f.tr = [4]byte{tweak[4], tweak[5], tweak[6], tweak[3] << 4}
+
+	return f, nil
+}
+
+// newFF3 returns FF3 over b, which must already have the reversed key
+func newFF3(b cipher.Block, radix int) (*FF3, error) {
+
+	if radix < 2 || radix > 1<<16 {
+		return nil, ErrRadix
+	}
+
+	f := &FF3{b: b, radix: radix}
+
+	// minLen is the least n with radix^n >= 1000000, and maxLen is twice the
+	// largest n with radix^n <= 2^96
+	r := big.NewInt(int64(radix))
+	lim := new(big.Int).Lsh(big.NewInt(1), 96)
+	p := big.NewInt(1)
+	for n := 1; ; n++ {
+		p.Mul(p, r)
+		if f.minLen == 0 && p.Cmp(big.NewInt(1000000)) >= 0 {
+			f.minLen = n
+		}
+		if p.Cmp(lim) > 0 {
+			f.maxLen = 2 * (n - 1)
+			break
+		}
+	}
+
+	if f.minLen < 2 {
+		f.minLen = 2
+	}
+
+	return f, nil
+}
+
+// MinLen returns the shortest input allowed for this radix.
+func (f *FF3) MinLen() int { return f.minLen }
+
+// MaxLen returns the longest input allowed for this radix.
+func (f *FF3) MaxLen() int { return f.maxLen }
+
+// Encrypt returns the encryption of the numerals in x.
+func (f *FF3) Encrypt(x []uint16) ([]uint16, error) {
+	return f.crypt(x, false)
+}
+
+// Decrypt returns the decryption of the numerals in x.
+func (f *FF3) Decrypt(x []uint16) ([]uint16, error) {
+	return f.crypt(x, true)
+}
+
+func (f *FF3) crypt(x []uint16, decrypt bool) ([]uint16, error) {
+
+	n := len(x)
+	if n < f.minLen || n > f.maxLen {
+		return nil, ErrFPEInput
+	}
+	for _, d := range x {
+		if int(d) >= f.radix {
+			return nil, ErrFPEInput
+		}
+	}
+
+	u := (n + 1) / 2
+	v := n - u
+
+	// A and B are kept as the numbers of the reversed halves, which is how
+	// every step uses them
+	a := f.num(x[:u])
+	b := f.num(x[u:])
+
+	r := big.NewInt(int64(f.radix))
+	modU := new(big.Int).Exp(r, big.NewInt(int64(u)), nil)
+	modV := new(big.Int).Exp(r, big.NewInt(int64(v)), nil)
+
+	var p [16]byte
+	y := new(big.Int)
+
+	for j := 0; j < 8; j++ {
+		i := j
+		if decrypt {
+			i = 7 - j
+		}
+
+		m, w := modU, f.tr
+		if i%2 == 1 {
+			m, w = modV, f.tl
+		}
+
+		// in the decryption direction B is derived from A, otherwise A from B
+		src := b
+		if decrypt {
+			src = a
+		}
+
+		// P = W ^ [i]^4 || [NUM(REV(B))]^12, and S = REVB(CIPH(REVB(P)))
+		copy(p[:4], w[:])
+		p[3] ^= byte(i)
+		for k := range p[4:] {
+			p[4+k] = 0
+		}
+		src.FillBytes(p[4:])
+		ff3Reverse(p[:])
+		f.b.Encrypt(p[:], p[:])
+		ff3Reverse(p[:])
+		y.SetBytes(p[:])
+
+		if decrypt {
+			// C = B, and A = (C - y) mod radix^m
+			y.Sub(b, y)
+			y.Mod(y, m)
+			a, b = y, a
+		} else {
+			y.Add(a, y)
+			y.Mod(y, m)
+			a, b = b, y
+		}
+		y = new(big.Int)
+	}
+
+	out := make([]uint16, n)
+	f.str(out[:u], a)
+	f.str(out[u:], b)
+	return out, nil
+}
+
+// num returns the number whose radix-digits, least significant first, are x
+func (f *FF3) num(x []uint16) *big.Int {
+	r := big.NewInt(int64(f.radix))
+	z := new(big.Int)
+	for i := len(x) - 1; i >= 0; i-- {
+		z.Mul(z, r)
+		z.Add(z, big.NewInt(int64(x[i])))
+	}
+	return z
+}
+
+// str writes z into out as radix-digits, least significant first; the inverse of num
+func (f *FF3) str(out []uint16, z *big.Int) {
+	r := big.NewInt(int64(f.radix))
+	z = new(big.Int).Set(z)
+	d := new(big.Int)
+	for i := range out {
+		z.DivMod(z, r, d)
+		out[i] = uint16(d.Int64())
+	}
+}
+
+func ff3Reverse(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
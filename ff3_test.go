@@ -0,0 +1,132 @@
+package krcrypt
+
+import (
+	"crypto/aes"
+	"encoding/hex"
+	"testing"
+)
+
+func digits(s string) []uint16 {
+	d := make([]uint16, len(s))
+	for i := range s {
+		d[i] = uint16(s[i] - '0')
+	}
+	return d
+}
+
+func TestFF3AES(t *testing.T) {
+
+	// Sample 1 from NIST's FF3 examples, with AES-128 and the original
+	// 64-bit tweak, to check the Feistel network against a known answer
+	key, _ := hex.DecodeString("ef4359d8d580aa4f7f036d6f04fc6a94")
+	ff3Reverse(key)
+	block, _ := aes.NewCipher(key)
+
+	f, _ := newFF3(block, 10)
+	f.tl = [4]byte{0xd8, 0xe7, 0x92, 0x0a}
+	f.tr = [4]byte{0xfa, 0x33, 0x0a, 0x73}
+
+	plain := digits("890121234567890000")
+	want := digits("750918814058654607")
+
+	c, err := f.Encrypt(plain)
+	if err != nil || !equalUint16(c, want) {
+		t.Errorf("FF3 encrypt failed: got %v (%v) wanted %v\n", c, err, want)
+	}
+
+	p, err := f.Decrypt(c)
+	if err != nil || !equalUint16(p, plain) {
+		t.Errorf("FF3 decrypt failed: got %v (%v) wanted %v\n", p, err, plain)
+	}
+}
+
+func equalUint16(a, b []uint16) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFF3(t *testing.T) {
+
+	key := []byte("YELLOW SUBMARINE")
+	tweak := []byte{1, 2, 3, 4, 5, 6, 7}
+
+	for _, radix := range []int{2, 10, 26, 36, 256, 65536} {
+		f, err := NewFF3(key, tweak, radix)
+		if err != nil {
+			t.Fatalf("NewFF3(radix=%d): %v", radix, err)
+		}
+
+		for _, n := range []int{f.MinLen(), f.MinLen() + 1, (f.MinLen() + f.MaxLen()) / 2, f.MaxLen()} {
+			x := make([]uint16, n)
+			for i := range x {
+				x[i] = uint16((i*7919 + 13) % radix)
+			}
+
+			c, err := f.Encrypt(x)
+			if err != nil {
+				t.Errorf("FF3(radix=%d, n=%d) encrypt: %v", radix, n, err)
+				continue
+			}
+			if len(c) != n || equalUint16(c, x) {
+				t.Errorf("FF3(radix=%d, n=%d) encrypt gave %v", radix, n, c)
+			}
+			for _, d := range c {
+				if int(d) >= radix {
+					t.Errorf("FF3(radix=%d, n=%d) numeral %d out of range", radix, n, d)
+				}
+			}
+
+			p, err := f.Decrypt(c)
+			if err != nil || !equalUint16(p, x) {
+				t.Errorf("FF3(radix=%d, n=%d) decrypt failed: got %v (%v) wanted %v", radix, n, p, err, x)
+			}
+		}
+
+		if _, err := f.Encrypt(make([]uint16, f.MinLen()-1)); err != ErrFPEInput {
+			t.Errorf("FF3(radix=%d) short input gave %v", radix, err)
+		}
+		if _, err := f.Encrypt(make([]uint16, f.MaxLen()+1)); err != ErrFPEInput {
+			t.Errorf("FF3(radix=%d) long input gave %v", radix, err)
+		}
+	}
+
+	f, _ := NewFF3(key, tweak, 10)
+	if f.MinLen() != 6 || f.MaxLen() != 56 {
+		t.Errorf("FF3 radix 10 lengths=%d,%d, wanted 6,56", f.MinLen(), f.MaxLen())
+	}
+	if _, err := f.Encrypt(digits("12345:")); err != ErrFPEInput {
+		t.Errorf("FF3 bad numeral gave %v", err)
+	}
+
+	// a different tweak gives a different encryption
+	f2, _ := NewFF3(key, []byte{1, 2, 3, 4, 5, 6, 8}, 10)
+	c1, _ := f.Encrypt(digits("0123456789"))
+	c2, _ := f2.Encrypt(digits("0123456789"))
+	if equalUint16(c1, c2) {
+		t.Errorf("FF3 tweak ignored")
+	}
+
+	for _, tlen := range []int{0, 6, 8} {
+		if _, err := NewFF3(key, make([]byte, tlen), 10); err != TweakSizeError(tlen) {
+			t.Errorf("NewFF3(tweak %d bytes)=%v, wanted %v", tlen, err, TweakSizeError(tlen))
+		}
+	}
+
+	if _, err := NewFF3(key, tweak, 1); err != ErrRadix {
+		t.Errorf("NewFF3(radix=1)=%v, wanted %v", err, ErrRadix)
+	}
+	if _, err := NewFF3(key, tweak, 1<<16+1); err != ErrRadix {
+		t.Errorf("NewFF3(radix=65537)=%v, wanted %v", err, ErrRadix)
+	}
+	if _, err := NewFF3(key[:15], tweak, 10); err != KeySizeError(15) {
+		t.Errorf("NewFF3(15-byte key)=%v, wanted %v", err, KeySizeError(15))
+	}
+
+}
@@ -0,0 +1,113 @@
+package krcrypt
+
+// Encrypting files in place
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// EncryptFile replaces the file at path with its SEED-GCM encryption under
+// the 16-byte key, a random nonce followed by the ciphertext.  The new
+// contents go to a temporary file in the same directory, which is synced to
+// disk and given the original's permissions before being renamed over it,
+// so a crash or error part way leaves either the old file or the new one,
+// never a mixture.  The whole file is held in memory.
+func EncryptFile(key []byte, path string) error {
+	return encryptFile(key, path, (*os.File).Write)
+}
+
+// encryptFile is EncryptFile, writing the new contents with write
+func encryptFile(key []byte, path string, write func(*os.File, []byte) (int, error)) error {
+
+	aead, err := NewGCM(key)
+	if err != nil {
+		return err
+	}
+
+	plain, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := sealRandomNonce(aead, plain, nil)
+	if err != nil {
+		return err
+	}
+
+	return replaceFile(path, sealed, write)
+}
+
+// DecryptFile reverses EncryptFile, replacing the file at path with its
+// decryption in the same way.  If the file doesn't authenticate under key it
+// is left untouched.
+func DecryptFile(key []byte, path string) error {
+
+	aead, err := NewGCM(key)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	plain, err := openPrefixedNonce(aead, sealed, nil)
+	if err != nil {
+		return err
+	}
+
+	return replaceFile(path, plain, (*os.File).Write)
+}
+
+// replaceFile atomically replaces the contents of the existing file at path,
+// writing data to the temporary file with write.  Once the rename is done the
+// directory is synced too, or the rename itself might not survive a crash.
+func replaceFile(path string, data []byte, write func(*os.File, []byte) (int, error)) (err error) {
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	if _, err = write(f, data); err != nil {
+		return err
+	}
+	if err = f.Chmod(fi.Mode().Perm()); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	if err = os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	if err = dir.Sync(); err != nil {
+		dir.Close()
+		return err
+	}
+	return dir.Close()
+}
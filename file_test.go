@@ -0,0 +1,83 @@
+package krcrypt
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptFile(t *testing.T) {
+
+	key := []byte("0123456789abcdef")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secret.txt")
+	data := bytes.Repeat([]byte("attack at dawn\n"), 1000)
+
+	if err := os.WriteFile(path, data, 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := EncryptFile(key, path); err != nil {
+		t.Fatal(err)
+	}
+
+	enc, _ := os.ReadFile(path)
+	if bytes.Contains(enc, []byte("attack at dawn")) {
+		t.Errorf("plaintext left in the encrypted file")
+	}
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != 0640 {
+		t.Errorf("EncryptFile changed the mode to %v, wanted %v", fi.Mode().Perm(), os.FileMode(0640))
+	}
+
+	// the wrong key fails and leaves the file alone
+	if err := DecryptFile([]byte("fedcba9876543210"), path); err == nil {
+		t.Errorf("DecryptFile with the wrong key succeeded")
+	}
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, enc) {
+		t.Errorf("failed DecryptFile changed the file")
+	}
+
+	if err := DecryptFile(key, path); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, data) {
+		t.Errorf("file after round trip differs from the original")
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("%d files in the directory, wanted only the original", len(entries))
+	}
+
+	if err := EncryptFile(key, filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Errorf("EncryptFile(missing file)=%v, wanted a not-exist error", err)
+	}
+}
+
+func TestEncryptFileFailedWrite(t *testing.T) {
+
+	key := []byte("0123456789abcdef")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secret.txt")
+	data := []byte("the original contents")
+	os.WriteFile(path, data, 0600)
+
+	// write half the new contents, then fail
+	errDisk := errors.New("disk full")
+	write := func(f *os.File, b []byte) (int, error) {
+		n, _ := f.Write(b[:len(b)/2])
+		return n, errDisk
+	}
+
+	if err := encryptFile(key, path, write); err != errDisk {
+		t.Errorf("EncryptFile with a failing write=%v, wanted %v", err, errDisk)
+	}
+
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, data) {
+		t.Errorf("failed EncryptFile changed the file to %q", got)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("failed EncryptFile left %d files, wanted only the original", len(entries))
+	}
+}
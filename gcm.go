@@ -0,0 +1,99 @@
+package krcrypt
+
+// SEED in Galois/Counter Mode
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// NewGCM returns SEED in Galois/Counter Mode with the standard 12-byte nonce
+// and 16-byte tag, using the 16-byte key.
+func NewGCM(key []byte) (cipher.AEAD, error) {
+	block, err := NewSEED(key)
+	if err != nil {
+		return nil, fmt.Errorf("krcrypt: NewGCM: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// ErrCiphertextTooShort is returned when a sealed message is too short to hold its nonce and tag.
+var ErrCiphertextTooShort = errors.New("krcrypt: ciphertext too short")
+
+// sealRandomNonce seals plain under a fresh random nonce, returning nonce||ciphertext
+func sealRandomNonce(aead cipher.AEAD, plain, aad []byte) ([]byte, error) {
+	return sealNonceFrom(rand.Reader, aead, plain, aad)
+}
+
+// sealNonceFrom is sealRandomNonce with the nonce read from r
+func sealNonceFrom(r io.Reader, aead cipher.AEAD, plain, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plain)+aead.Overhead())
+	if _, err := io.ReadFull(r, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plain, aad), nil
+}
+
+// A SealOption configures one of the Seal helpers that pick their own nonce.
+type SealOption func(*sealConfig)
+
+type sealConfig struct {
+	rand io.Reader
+}
+
+// WithRand makes a Seal helper read its nonce from r instead of crypto/rand.
+// It is for tests that need reproducible output; a predictable nonce in
+// production would be repeated sooner or later, which breaks GCM and CTR.
+func WithRand(r io.Reader) SealOption {
+	return func(c *sealConfig) { c.rand = r }
+}
+
+// newSealConfig applies opts over the defaults
+func newSealConfig(opts []SealOption) sealConfig {
+	c := sealConfig{rand: rand.Reader}
+	for _, o := range opts {
+		o(&c)
+	}
+	return c
+}
+
+// openPrefixedNonce opens a message produced by sealRandomNonce
+func openPrefixedNonce(aead cipher.AEAD, sealed, aad []byte) ([]byte, error) {
+	ns := aead.NonceSize()
+	if len(sealed) < ns+aead.Overhead() {
+		return nil, ErrCiphertextTooShort
+	}
+	return aead.Open(nil, sealed[:ns], sealed[ns:], aad)
+}
+
+// OpenWithKeys opens a SEED-GCM ciphertext that may be under any of several
+// keys, as during key rotation, returning the plaintext and the index of the
+// key that opened it.  Every key is tried even after one succeeds, so the
+// time taken doesn't reveal which one it was.  It returns ErrAuthentication
+// if none of them open it.
+func OpenWithKeys(nonce, ciphertext, aad []byte, keys ...[]byte) ([]byte, int, error) {
+
+	var plain []byte
+	index := -1
+
+	for i, key := range keys {
+		aead, err := NewGCM(key)
+		if err != nil {
+			return nil, -1, err
+		}
+		if p, err := aead.Open(nil, nonce, ciphertext, aad); err == nil && index < 0 {
+			plain, index = p, i
+		}
+	}
+
+	if index < 0 {
+		return nil, -1, ErrAuthentication
+	}
+
+	return plain, index, nil
+}
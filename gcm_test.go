@@ -0,0 +1,67 @@
+package krcrypt
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGCM(t *testing.T) {
+
+	key := make([]byte, 16)
+	nonce := make([]byte, 12)
+	plain := []byte("attack at dawn")
+	aad := []byte("header")
+
+	g, err := NewGCM(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed := g.Seal(nil, nonce, plain, aad)
+	if len(sealed) != len(plain)+g.Overhead() {
+		t.Errorf("len(sealed)=%d, wanted %d", len(sealed), len(plain)+g.Overhead())
+	}
+
+	if got, err := g.Open(nil, nonce, sealed, aad); err != nil || !bytes.Equal(got, plain) {
+		t.Errorf("Open=(%q,%v), wanted %q", got, err, plain)
+	}
+
+	sealed[0] ^= 1
+	if _, err := g.Open(nil, nonce, sealed, aad); err == nil {
+		t.Errorf("Open accepted a tampered ciphertext")
+	}
+
+	if _, err := NewGCM(key[:8]); !errors.Is(err, KeySizeError(8)) || !strings.HasPrefix(err.Error(), "krcrypt: NewGCM: ") {
+		t.Errorf("NewGCM(short key)=%v, wanted %v with the operation prefixed", err, KeySizeError(8))
+	}
+}
+
+func TestOpenWithKeys(t *testing.T) {
+
+	oldKey := []byte("0123456789abcdef")
+	newKey := []byte("fedcba9876543210")
+	nonce := make([]byte, 12)
+	aad := []byte("header")
+
+	g, _ := NewGCM(newKey)
+	sealed := g.Seal(nil, nonce, []byte("rotated secret"), aad)
+
+	plain, i, err := OpenWithKeys(nonce, sealed, aad, oldKey, newKey)
+	if err != nil || i != 1 || string(plain) != "rotated secret" {
+		t.Errorf("OpenWithKeys=%q, %d, %v, wanted \"rotated secret\", 1, nil", plain, i, err)
+	}
+
+	if _, i, err := OpenWithKeys(nonce, sealed, aad, oldKey); err != ErrAuthentication || i != -1 {
+		t.Errorf("OpenWithKeys(wrong key)=%d, %v, wanted -1, %v", i, err, ErrAuthentication)
+	}
+
+	if _, _, err := OpenWithKeys(nonce, sealed, aad); err != ErrAuthentication {
+		t.Errorf("OpenWithKeys(no keys)=%v, wanted %v", err, ErrAuthentication)
+	}
+
+	if _, _, err := OpenWithKeys(nonce, sealed, aad, oldKey[:8], newKey); !errors.Is(err, KeySizeError(8)) {
+		t.Errorf("OpenWithKeys(short key)=%v, wanted %v", err, KeySizeError(8))
+	}
+}
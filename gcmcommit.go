@@ -0,0 +1,116 @@
+package krcrypt
+
+// Key-committing SEED-GCM
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+https://eprint.iacr.org/2019/016 (invisible salamanders)
+https://eprint.iacr.org/2020/1456 (Albertini et al., "How to Abuse and Fix Authenticated Encryption Without Key Commitment")
+
+*/
+
+import (
+	"crypto/cipher"
+	"errors"
+)
+
+// ErrAuthentication is returned when a message fails authentication
+var ErrAuthentication = errors.New("krcrypt: message authentication failed")
+
+// the size of the key commitment
+const gcmCommitSize = 32
+
+// gcmCommitting is SEED-GCM under a derived key, with a commitment to the key
+// prepended to each message
+type gcmCommitting struct {
+	aead   cipher.AEAD
+	commit [gcmCommitSize]byte
+}
+
+// NewGCMCommitting returns SEED-GCM made key-committing: a message that opens
+// under one key can't be made to open under another.  Plain GCM doesn't have
+// this property; it is easy to craft a ciphertext that decrypts validly, to
+// different plaintexts, under two keys, which matters when a message goes to
+// several recipients who must all see the same thing.
+//
+// The 16-byte key is used only to derive, with SEED, a GCM key and a 32-byte
+// commitment, which is prepended to each ciphertext and checked first by Open.
+// Sealed messages are the commitment, the ciphertext, and the tag, so the
+// overhead is 48 bytes instead of GCM's 16; the derivation is done once, by
+// NewGCMCommitting.  The output is not compatible with NewGCM.
+func NewGCMCommitting(key []byte) (cipher.AEAD, error) {
+
+	b, err := NewSEED(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// the blocks are distinct from each other and from any input GCM gives
+	// the cipher, since GCM runs under the derived key
+	var in, k [16]byte
+	copy(in[:], "krcrypt commit 0")
+	b.Encrypt(k[:], in[:])
+
+	g := new(gcmCommitting)
+	in[15] = '1'
+	b.Encrypt(g.commit[:16], in[:])
+	in[15] = '2'
+	b.Encrypt(g.commit[16:], in[:])
+
+	if g.aead, err = NewGCM(k[:]); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+func (g *gcmCommitting) NonceSize() int { return g.aead.NonceSize() }
+
+func (g *gcmCommitting) Overhead() int { return gcmCommitSize + g.aead.Overhead() }
+
+// Seal and Open work on a copy of the data at the offset it has in the
+// output, since the commitment in front shifts the ciphertext against the
+// plaintext, and handing the inner GCM the shifted overlap of an in-place
+// call would make it panic.
+func (g *gcmCommitting) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+
+	n := len(plaintext)
+	total := len(dst) + gcmCommitSize + n + g.aead.Overhead()
+
+	out := dst[:cap(dst)]
+	if len(out) < total {
+		out = make([]byte, total)
+		copy(out, dst)
+	}
+	out = out[:total]
+	body := out[len(dst):]
+
+	// move the plaintext first: in place, the commitment goes over its start
+	copy(body[gcmCommitSize:], plaintext)
+	copy(body, g.commit[:])
+	g.aead.Seal(body[:gcmCommitSize], nonce, body[gcmCommitSize:gcmCommitSize+n], additionalData)
+
+	return out
+}
+
+func (g *gcmCommitting) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+
+	if len(ciphertext) < g.Overhead() {
+		return nil, ErrCiphertextTooShort
+	}
+
+	if _, err := verifyAndRelease(g.commit[:], ciphertext[:gcmCommitSize], nil); err != nil {
+		return nil, err
+	}
+
+	plain, err := g.aead.Open(nil, nonce, ciphertext[gcmCommitSize:], additionalData)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(dst, plain...), nil
+}
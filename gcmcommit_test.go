@@ -0,0 +1,82 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGCMCommitting(t *testing.T) {
+
+	keyA := []byte("YELLOW SUBMARINE")
+	keyB := []byte("PURPLE SUBMARINE")
+	nonce := make([]byte, 12)
+	plain := []byte("attack at dawn")
+	aad := []byte("header")
+
+	a, err := NewGCMCommitting(keyA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := NewGCMCommitting(keyB)
+
+	sealed := a.Seal(nil, nonce, plain, aad)
+	if len(sealed) != len(plain)+a.Overhead() || a.Overhead() != 48 {
+		t.Errorf("len(sealed)=%d, wanted %d", len(sealed), len(plain)+48)
+	}
+
+	if got, err := a.Open(nil, nonce, sealed, aad); err != nil || !bytes.Equal(got, plain) {
+		t.Errorf("Open=(%q,%v), wanted %q", got, err, plain)
+	}
+
+	if _, err := b.Open(nil, nonce, sealed, aad); err != ErrAuthentication {
+		t.Errorf("Open under the wrong key=%v, wanted %v", err, ErrAuthentication)
+	}
+
+	// a message crafted for key B with a valid GCM tag, under key A's
+	// commitment, is still refused by B
+	forged := b.Seal(nil, nonce, plain, aad)
+	copy(forged, sealed[:gcmCommitSize])
+	if _, err := b.Open(nil, nonce, forged, aad); err != ErrAuthentication {
+		t.Errorf("Open with another key's commitment=%v, wanted %v", err, ErrAuthentication)
+	}
+
+	sealed[len(sealed)-1] ^= 1
+	if _, err := a.Open(nil, nonce, sealed, aad); err == nil {
+		t.Errorf("Open accepted a tampered tag")
+	}
+
+	if _, err := a.Open(nil, nonce, sealed[:40], aad); err != ErrCiphertextTooShort {
+		t.Errorf("Open(short)=%v, wanted %v", err, ErrCiphertextTooShort)
+	}
+}
+
+func TestGCMCommittingInPlace(t *testing.T) {
+
+	g, _ := NewGCMCommitting([]byte("YELLOW SUBMARINE"))
+	nonce := make([]byte, 12)
+	plain := []byte("attack at dawn")
+	aad := []byte("header")
+
+	want := g.Seal(nil, nonce, plain, aad)
+
+	buf := make([]byte, len(plain), len(plain)+g.Overhead())
+	copy(buf, plain)
+	sealed := g.Seal(buf[:0], nonce, buf, aad)
+	if !bytes.Equal(sealed, want) {
+		t.Errorf("in-place Seal=%x, wanted %x", sealed, want)
+	}
+	if &sealed[0] != &buf[0] {
+		t.Errorf("in-place Seal didn't reuse the plaintext's storage")
+	}
+
+	// with a prefix already in dst
+	pre := append([]byte("prefix"), plain...)
+	if got := g.Seal(pre[:6], nonce, pre[6:], aad); !bytes.Equal(got[:6], []byte("prefix")) || !bytes.Equal(got[6:], want) {
+		t.Errorf("Seal after a prefix=%x, wanted prefix then %x", got, want)
+	}
+
+	opened, err := g.Open(sealed[:0], nonce, sealed, aad)
+	if err != nil || !bytes.Equal(opened, plain) {
+		t.Errorf("in-place Open=(%q,%v), wanted %q", opened, err, plain)
+	}
+}
@@ -0,0 +1,213 @@
+package krcrypt
+
+// Incremental SEED-GCM encryption
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+http://csrc.nist.gov/publications/nistpubs/800-38D/SP-800-38D.pdf
+
+*/
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+var (
+	// ErrSessionOrder is returned by AEADSession.WriteAAD once the
+	// plaintext has been started or the session finished.
+	ErrSessionOrder = errors.New("krcrypt: AEADSession: associated data after plaintext")
+
+	// ErrSessionTooLong is returned by AEADSession.WriteData when the
+	// plaintext would exceed GCM's limit for one message.
+	ErrSessionTooLong = errors.New("krcrypt: AEADSession: message too long for GCM")
+)
+
+// gcmMaxData is GCM's limit on the plaintext of one message, 2^32-2 blocks.
+// Any more and the 32-bit counter would wrap around to the block that masks
+// the tag.
+const gcmMaxData = (1<<32 - 2) * 16
+
+// An AEADSession encrypts with SEED-GCM incrementally, for associated data or
+// plaintext too large to hold in memory for a single Seal.  The associated
+// data is fed in with WriteAAD, then the plaintext with WriteData, which
+// writes the ciphertext to the underlying writer as it goes, and Finish
+// returns the 16-byte tag.  The ciphertext followed by the tag is exactly
+// what NewGCM's Seal returns for the same inputs, so the result can be opened
+// with Open or OpenDetached.
+//
+// There is no incremental decryption, since it would hand out plaintext before
+// the tag had been checked.
+type AEADSession struct {
+	b      cipher.Block
+	w      io.Writer
+	g      ghash
+	j0     [16]byte // the pre-counter block, which masks the tag
+	ctr    [16]byte // the next counter block
+	ks     [16]byte // the current keystream block
+	used   int      // how much of ks has been used
+	aadLen uint64
+	ctLen  uint64
+	data   bool // whether WriteData has been called
+	done   bool
+	err    error
+}
+
+// NewSession starts encrypting under the 16-byte key and 12-byte nonce, writing
+// the ciphertext to w.  As with Seal, the nonce must never be reused with the
+// same key.
+func NewSession(key, nonce []byte, w io.Writer) (*AEADSession, error) {
+
+	b, err := NewSEED(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if nlen := len(nonce); nlen != 12 {
+		return nil, IVSizeError(nlen)
+	}
+
+	s := &AEADSession{b: b, w: w, used: 16}
+
+	var h [16]byte
+	b.Encrypt(h[:], h[:])
+	s.g.h = GCMField.NewMultiplier(h)
+
+	copy(s.j0[:], nonce)
+	s.j0[15] = 1
+	s.ctr = s.j0
+	gcmInc32(&s.ctr)
+
+	return s, nil
+}
+
+// WriteAAD adds p to the associated data.  It returns ErrSessionOrder once
+// WriteData or Finish has been called.
+func (s *AEADSession) WriteAAD(p []byte) error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.data || s.done {
+		return ErrSessionOrder
+	}
+	s.g.write(p)
+	s.aadLen += uint64(len(p))
+	return nil
+}
+
+// WriteData encrypts p and writes the ciphertext to the underlying writer.
+// It returns ErrSessionTooLong, and encrypts none of p, if the plaintext would
+// pass GCM's limit of just under 64GB, as cipher.NewGCM's Seal would refuse.
+func (s *AEADSession) WriteData(p []byte) error {
+
+	if s.err != nil {
+		return s.err
+	}
+	if s.done {
+		return ErrClosed
+	}
+	if uint64(len(p)) > gcmMaxData-s.ctLen {
+		return ErrSessionTooLong
+	}
+
+	if !s.data {
+		// the associated data is padded to a whole block
+		s.g.flush()
+		s.data = true
+	}
+
+	out := make([]byte, len(p))
+	for i := range p {
+		if s.used == 16 {
+			s.b.Encrypt(s.ks[:], s.ctr[:])
+			gcmInc32(&s.ctr)
+			s.used = 0
+		}
+		out[i] = p[i] ^ s.ks[s.used]
+		s.used++
+	}
+
+	s.g.write(out)
+	s.ctLen += uint64(len(p))
+
+	if _, err := s.w.Write(out); err != nil {
+		s.err = err
+		return err
+	}
+	return nil
+}
+
+// Finish completes the encryption and returns the tag.  It does not close the
+// underlying writer.
+func (s *AEADSession) Finish() (tag []byte, err error) {
+
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.done {
+		return nil, ErrClosed
+	}
+	s.done = true
+
+	s.g.flush()
+
+	var lens [16]byte
+	binary.BigEndian.PutUint64(lens[:], s.aadLen*8)
+	binary.BigEndian.PutUint64(lens[8:], s.ctLen*8)
+	s.g.write(lens[:])
+
+	tag = make([]byte, 16)
+	s.b.Encrypt(tag, s.j0[:])
+	xorslice(tag, tag, s.g.y[:])
+
+	return tag, nil
+}
+
+// gcmInc32 increments the last 32 bits of the counter block, as GCM does
+func gcmInc32(ctr *[16]byte) {
+	binary.BigEndian.PutUint32(ctr[12:], binary.BigEndian.Uint32(ctr[12:])+1)
+}
+
+// ghash is GCM's universal hash, buffering a partial block.  Multiplying by
+// H goes through tables, as crypto/cipher's generic GCM does.
+type ghash struct {
+	h    *GFMultiplier
+	y    [16]byte
+	buf  [16]byte
+	nbuf int
+}
+
+// write hashes p, buffering any partial block
+func (g *ghash) write(p []byte) {
+	for len(p) > 0 {
+		n := copy(g.buf[g.nbuf:], p)
+		g.nbuf += n
+		p = p[n:]
+		if g.nbuf == 16 {
+			g.block()
+		}
+	}
+}
+
+// flush hashes any partial block, padded with zeros
+func (g *ghash) flush() {
+	if g.nbuf > 0 {
+		for i := g.nbuf; i < 16; i++ {
+			g.buf[i] = 0
+		}
+		g.block()
+	}
+}
+
+// block computes Y = (Y ^ buf) * H
+func (g *ghash) block() {
+	xorslice(g.y[:], g.y[:], g.buf[:])
+	g.y = g.h.Mul(g.y)
+	g.nbuf = 0
+}
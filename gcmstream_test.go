@@ -0,0 +1,98 @@
+package krcrypt
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestAEADSession(t *testing.T) {
+
+	key := []byte("YELLOW SUBMARINE")
+	nonce := []byte("twelve bytes")
+
+	aead, _ := NewGCM(key)
+
+	for _, sizes := range [][2]int{{0, 0}, {0, 5}, {7, 0}, {16, 16}, {20, 100}, {1000, 333}} {
+		aad := bytes.Repeat([]byte{0xa5}, sizes[0])
+		plain := bytes.Repeat([]byte{0x5a}, sizes[1])
+		for i := range plain {
+			plain[i] += byte(i)
+		}
+
+		var buf bytes.Buffer
+		s, err := NewSession(key, nonce, &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// feed both in odd-sized pieces
+		for i := 0; i < len(aad); i += 7 {
+			s.WriteAAD(aad[i:min(i+7, len(aad))])
+		}
+		for i := 0; i < len(plain); i += 11 {
+			s.WriteData(plain[i:min(i+11, len(plain))])
+		}
+
+		tag, err := s.Finish()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := append(buf.Bytes(), tag...)
+		if want := aead.Seal(nil, nonce, plain, aad); !bytes.Equal(got, want) {
+			t.Errorf("session(aad=%d, data=%d)=%x, wanted %x", sizes[0], sizes[1], got, want)
+		}
+	}
+
+	var buf bytes.Buffer
+	s, _ := NewSession(key, nonce, &buf)
+	s.WriteData([]byte("data"))
+	if err := s.WriteAAD([]byte("late")); err != ErrSessionOrder {
+		t.Errorf("WriteAAD after WriteData=%v, wanted %v", err, ErrSessionOrder)
+	}
+	s.Finish()
+	if _, err := s.Finish(); err != ErrClosed {
+		t.Errorf("second Finish=%v, wanted %v", err, ErrClosed)
+	}
+
+	if _, err := NewSession(key, nonce[:8], &buf); err != IVSizeError(8) {
+		t.Errorf("NewSession(short nonce)=%v, wanted %v", err, IVSizeError(8))
+	}
+}
+
+func TestAEADSessionLimits(t *testing.T) {
+
+	key := make([]byte, 16)
+	nonce := make([]byte, 12)
+
+	// pretend all but the last block of GCM's limit has been written
+	s, _ := NewSession(key, nonce, io.Discard)
+	s.WriteData(nil)
+	s.ctLen = gcmMaxData - 16
+
+	if err := s.WriteData(make([]byte, 17)); err != ErrSessionTooLong {
+		t.Errorf("WriteData past the limit=%v, wanted %v", err, ErrSessionTooLong)
+	}
+	if err := s.WriteData(make([]byte, 16)); err != nil {
+		t.Errorf("WriteData up to the limit=%v", err)
+	}
+	if err := s.WriteData(make([]byte, 1)); err != ErrSessionTooLong {
+		t.Errorf("WriteData at the limit=%v, wanted %v", err, ErrSessionTooLong)
+	}
+
+	// a write error sticks, and WriteAAD reports it too
+	s, _ = NewSession(key, nonce, failWriter{})
+	werr := s.WriteData([]byte("x"))
+	if werr == nil {
+		t.Fatal("WriteData to a failing writer succeeded")
+	}
+	if err := s.WriteAAD([]byte("y")); err != werr {
+		t.Errorf("WriteAAD after a write error=%v, wanted %v", err, werr)
+	}
+}
+
+type failWriter struct{}
+
+func (failWriter) Write(p []byte) (int, error) { return 0, errors.New("write failed") }
@@ -0,0 +1,181 @@
+package krcrypt
+
+// Arithmetic in GF(2^128)
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// GFOrder says how a 16-byte block represents an element of GF(2^128).
+// The modes that use the field disagree.
+type GFOrder int
+
+const (
+	// GFBigEndian is a 128-bit big-endian integer, the x^127 coefficient in
+	// the top bit of the first byte, as in CMAC and PMAC.
+	GFBigEndian GFOrder = iota
+
+	// GFLittleEndian is a 128-bit little-endian integer, the x^0
+	// coefficient in the bottom bit of the first byte, as in XTS and EME.
+	GFLittleEndian
+
+	// GFReflected has the x^0 coefficient in the top bit of the first byte
+	// and x^127 in the bottom bit of the last, as in GCM.
+	GFReflected
+)
+
+// A GFField is GF(2^128) with a particular reduction polynomial and block
+// representation.  Poly holds the polynomial's terms below x^128, so 0x87 is
+// x^128 + x^7 + x^2 + x + 1.
+type GFField struct {
+	Poly  uint64
+	Order GFOrder
+}
+
+var (
+	// GCMField is the field used by GCM's GHASH.
+	GCMField = GFField{0x87, GFReflected}
+
+	// XTSField is the field used for XTS tweaks, and by EME.
+	XTSField = GFField{0x87, GFLittleEndian}
+
+	// cmacField is the field CMAC and PMAC derive their subkeys in
+	cmacField = GFField{0x87, GFBigEndian}
+)
+
+// Mul returns the product of x and y.  It takes the same time for any input.
+func (f GFField) Mul(x, y [16]byte) [16]byte {
+	xh, xl := f.load(&x)
+	yh, yl := f.load(&y)
+	zh, zl := gfMul128(xh, xl, yh, yl, f.Poly)
+	return f.store(zh, zl)
+}
+
+// A GFMultiplier multiplies by a fixed element using precomputed tables,
+// several times faster than GFField.Mul.  The tables are indexed by the
+// other operand, so unlike Mul its timing may depend on it through the cache.
+type GFMultiplier struct {
+	f GFField
+	t [32][16][2]uint64 // t[j][n] = y·n·x^(4j)
+}
+
+// NewMultiplier returns a GFMultiplier for multiplying by y.
+func (f GFField) NewMultiplier(y [16]byte) *GFMultiplier {
+
+	m := &GFMultiplier{f: f}
+
+	h, l := f.load(&y)
+	for j := range m.t {
+		for b := 1; b < 16; b <<= 1 {
+			m.t[j][b] = [2]uint64{h, l}
+			h, l = gfDouble128(h, l, f.Poly)
+		}
+		for n := 3; n < 16; n++ {
+			if low := n & -n; low != n {
+				m.t[j][n][0] = m.t[j][low][0] ^ m.t[j][n^low][0]
+				m.t[j][n][1] = m.t[j][low][1] ^ m.t[j][n^low][1]
+			}
+		}
+	}
+
+	return m
+}
+
+// Mul returns x times the multiplier's fixed element.
+func (m *GFMultiplier) Mul(x [16]byte) [16]byte {
+
+	xh, xl := m.f.load(&x)
+
+	var zh, zl uint64
+	for j := 0; j < 16; j++ {
+		e := &m.t[j][xl>>(4*uint(j))&15]
+		zh ^= e[0]
+		zl ^= e[1]
+		e = &m.t[j+16][xh>>(4*uint(j))&15]
+		zh ^= e[0]
+		zl ^= e[1]
+	}
+
+	return m.f.store(zh, zl)
+}
+
+// gfMul multiplies x and y, as big-endian blocks, reducing by x^128 + poly
+func gfMul(x, y [16]byte, poly uint64) [16]byte {
+	f := GFField{poly, GFBigEndian}
+	return f.Mul(x, y)
+}
+
+// Double returns x times the polynomial x, as CMAC, PMAC and EME do to
+// derive their masks.
+func (f GFField) Double(x [16]byte) [16]byte {
+	h, l := f.load(&x)
+	return f.store(gfDouble128(h, l, f.Poly))
+}
+
+// Halve returns x divided by the polynomial x, the inverse of Double.  It
+// only works for a polynomial with a constant term, as all of these have.
+func (f GFField) Halve(x [16]byte) [16]byte {
+	h, l := f.load(&x)
+	return f.store(gfHalve128(h, l, f.Poly))
+}
+
+// gfMul128 multiplies polynomials held with the x^i coefficient in bit i of
+// h:l, one bit of y at a time from the top, with masks instead of branches
+func gfMul128(xh, xl, yh, yl, poly uint64) (uint64, uint64) {
+	var zh, zl uint64
+	for i := 127; i >= 0; i-- {
+		zh, zl = gfDouble128(zh, zl, poly)
+		var bit uint64
+		if i >= 64 {
+			bit = yh >> uint(i-64) & 1
+		} else {
+			bit = yl >> uint(i) & 1
+		}
+		zh ^= xh & -bit
+		zl ^= xl & -bit
+	}
+	return zh, zl
+}
+
+// gfDouble128 multiplies h:l by x
+func gfDouble128(h, l, poly uint64) (uint64, uint64) {
+	carry := h >> 63
+	return h<<1 | l>>63, l<<1 ^ poly&-carry
+}
+
+// gfHalve128 divides h:l by x
+func gfHalve128(h, l, poly uint64) (uint64, uint64) {
+	carry := l & 1
+	l ^= poly & -carry
+	return h>>1 | carry<<63, l>>1 | h<<63
+}
+
+// load converts a block to h:l, with the x^i coefficient in bit i
+func (f GFField) load(b *[16]byte) (h, l uint64) {
+	switch f.Order {
+	case GFLittleEndian:
+		return binary.LittleEndian.Uint64(b[8:]), binary.LittleEndian.Uint64(b[:8])
+	case GFReflected:
+		return bits.Reverse64(binary.BigEndian.Uint64(b[8:])), bits.Reverse64(binary.BigEndian.Uint64(b[:8]))
+	}
+	return binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+}
+
+// store is the inverse of load
+func (f GFField) store(h, l uint64) (b [16]byte) {
+	switch f.Order {
+	case GFLittleEndian:
+		binary.LittleEndian.PutUint64(b[8:], h)
+		binary.LittleEndian.PutUint64(b[:8], l)
+	case GFReflected:
+		binary.BigEndian.PutUint64(b[8:], bits.Reverse64(h))
+		binary.BigEndian.PutUint64(b[:8], bits.Reverse64(l))
+	default:
+		binary.BigEndian.PutUint64(b[:8], h)
+		binary.BigEndian.PutUint64(b[8:], l)
+	}
+	return b
+}
@@ -0,0 +1,124 @@
+package krcrypt
+
+import (
+	"encoding/hex"
+	"math/rand"
+	"testing"
+)
+
+func gfBlock(s string) (b [16]byte) {
+	h, _ := hex.DecodeString(s)
+	copy(b[:], h)
+	return b
+}
+
+func TestGFFieldMul(t *testing.T) {
+
+	var tests = []struct {
+		f       GFField
+		x, y, z string
+	}{
+		// GCM spec test case 2: X1 = C·H
+		{GCMField, "0388dace60b6a392f328c2b971b2fe78", "66e94bd4ef8a2c3b884cfa59ca342b2e", "5e2ec746917062882c85b0685353deb7"},
+		// 1 is the top bit in GCM's reflected order
+		{GCMField, "80000000000000000000000000000000", "66e94bd4ef8a2c3b884cfa59ca342b2e", "66e94bd4ef8a2c3b884cfa59ca342b2e"},
+		// x·x^127 = x^128 = x^7 + x^2 + x + 1
+		{GCMField, "40000000000000000000000000000000", "00000000000000000000000000000001", "e1000000000000000000000000000000"},
+
+		// XTS's alpha is x, little-endian: 02 00 .. 00
+		{XTSField, "02000000000000000000000000000000", "00000000000000000000000000000080", "87000000000000000000000000000000"},
+		{XTSField, "02000000000000000000000000000000", "0102030405060708090a0b0c0d0e0f10", "020406080a0c0e10121416181a1c1e20"},
+		{XTSField, "02000000000000000000000000000000", "000102030405060708090a0b0c0d0e8f", "87020406080a0c0e10121416181a1c1e"},
+		{XTSField, "01000000000000000000000000000000", "8f0e0d0c0b0a09080706050403020100", "8f0e0d0c0b0a09080706050403020100"},
+		// x^64 · x^64 = x^128
+		{XTSField, "00000000000000000100000000000000", "00000000000000000100000000000000", "87000000000000000000000000000000"},
+
+		// the CMAC field, where multiplying by x is doubling
+		{GFField{0x87, GFBigEndian}, "00000000000000000000000000000002", "80000000000000000000000000000000", "00000000000000000000000000000087"},
+	}
+
+	for _, tt := range tests {
+		x, y, want := gfBlock(tt.x), gfBlock(tt.y), gfBlock(tt.z)
+		if got := tt.f.Mul(x, y); got != want {
+			t.Errorf("%v.Mul(%s, %s)=%x, wanted %s", tt.f, tt.x, tt.y, got, tt.z)
+		}
+		if got := tt.f.Mul(y, x); got != want {
+			t.Errorf("%v.Mul(%s, %s)=%x, wanted %s", tt.f, tt.y, tt.x, got, tt.z)
+		}
+		if got := tt.f.NewMultiplier(y).Mul(x); got != want {
+			t.Errorf("%v.NewMultiplier(%s).Mul(%s)=%x, wanted %s", tt.f, tt.y, tt.x, got, tt.z)
+		}
+	}
+}
+
+func TestGFFieldProperties(t *testing.T) {
+
+	rnd := rand.New(rand.NewSource(1))
+	random := func() (b [16]byte) {
+		rnd.Read(b[:])
+		return b
+	}
+
+	for _, f := range []GFField{GCMField, XTSField, {0x87, GFBigEndian}, {0x1b, GFBigEndian}} {
+		for i := 0; i < 100; i++ {
+			a, b, c := random(), random(), random()
+
+			if f.Mul(f.Mul(a, b), c) != f.Mul(a, f.Mul(b, c)) {
+				t.Errorf("%v: multiplication isn't associative for %x %x %x", f, a, b, c)
+			}
+
+			var bc [16]byte
+			xorslice(bc[:], b[:], c[:])
+			ab, ac, abc := f.Mul(a, b), f.Mul(a, c), f.Mul(a, bc)
+			xorslice(ab[:], ab[:], ac[:])
+			if ab != abc {
+				t.Errorf("%v: multiplication doesn't distribute for %x %x %x", f, a, b, c)
+			}
+
+			if got := f.NewMultiplier(b).Mul(a); got != f.Mul(a, b) {
+				t.Errorf("%v: table multiply by %x of %x=%x, wanted %x", f, b, a, got, f.Mul(a, b))
+			}
+		}
+	}
+}
+
+func TestGFFieldDouble(t *testing.T) {
+
+	rnd := rand.New(rand.NewSource(1))
+
+	for _, f := range []GFField{GCMField, XTSField, cmacField, {0x1b, GFBigEndian}} {
+		// the polynomial x, in the field's representation
+		x := f.store(0, 2)
+
+		for i := 0; i < 100; i++ {
+			var a [16]byte
+			rnd.Read(a[:])
+			if got, want := f.Double(a), f.Mul(a, x); got != want {
+				t.Errorf("%v.Double(%x)=%x, wanted %x", f, a, got, want)
+			}
+			if got := f.Halve(f.Double(a)); got != a {
+				t.Errorf("%v.Halve(Double(%x))=%x", f, a, got)
+			}
+		}
+	}
+
+	// in the CMAC field, gfMul by x is Double
+	var two [16]byte
+	two[15] = 2
+	for i := 0; i < 100; i++ {
+		var a [16]byte
+		rnd.Read(a[:])
+		if got, want := gfMul(a, two, 0x87), cmacField.Double(a); got != want {
+			t.Errorf("gfMul(%x, x)=%x, wanted %x", a, got, want)
+		}
+	}
+
+	// the CMAC subkeys from RFC 4493, section 4
+	l := gfBlock("7df76b0c1ab899b33e42f047b91b546f")
+	if k1 := cmacField.Double(l); k1 != gfBlock("fbeed618357133667c85e08f7236a8de") {
+		t.Errorf("K1=%x", k1)
+	}
+	if k2 := cmacField.Double(cmacField.Double(l)); k2 != gfBlock("f7ddac306ae266ccf90bc11ee46d513b") {
+		t.Errorf("K2=%x", k2)
+	}
+}
@@ -0,0 +1,224 @@
+package krcrypt
+
+// The GIFT family of lightweight block ciphers
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+https://eprint.iacr.org/2017/622.pdf
+
+*/
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+)
+
+// A giftCipher is an instance of GIFT-64 or GIFT-128 with a particular key.
+//
+// The state is held bitsliced: slice j holds bit j of every nibble, so the
+// S-box layer is a handful of boolean operations across all nibbles at once,
+// and the bit permutation moves bits within each slice.  There are no table
+// lookups indexed by secret data, so the running time doesn't depend on the
+// key or the data.
+type giftCipher struct {
+	n      uint         // nibbles in a block, 16 or 32
+	u, v   []uint32     // the round keys, xored into slices 1 and 0 (or 2 and 1)
+	c      []uint32     // the round constants, xored into slice 3
+	perm   [4][32]uint8 // where each bit of each slice goes
+	rounds int
+}
+
+// NewGIFT creates and returns a new cipher.Block implementing GIFT with the
+// given block size in bits (64 or 128).  The key must be 16 bytes.  Blocks
+// and keys are big-endian, as in the paper.
+func NewGIFT(key []byte, blockBits int) (cipher.Block, error) {
+
+	c := new(giftCipher)
+
+	switch blockBits {
+	case 64:
+		c.n, c.rounds = 16, 28
+	case 128:
+		c.n, c.rounds = 32, 40
+	default:
+		return nil, BlockSizeError(blockBits)
+	}
+
+	if klen := len(key); klen != 16 {
+		return nil, KeySizeError(klen)
+	}
+
+	// bit 4i+j moves to bit 4(i/4 + (n/4)*((3*(i%4)+j)%4)) + j, which is
+	// still in slice j
+	for j := uint(0); j < 4; j++ {
+		for i := uint(0); i < c.n; i++ {
+			c.perm[j][i] = uint8(i/4 + c.n/4*((3*(i%4)+j)%4))
+		}
+	}
+
+	// k[7] is the first (most significant) word of the key
+	var k [8]uint16
+	for i := range k {
+		k[7-i] = binary.BigEndian.Uint16(key[2*i:])
+	}
+
+	c.u = make([]uint32, c.rounds)
+	c.v = make([]uint32, c.rounds)
+	c.c = make([]uint32, c.rounds)
+
+	var rc uint32
+	for r := 0; r < c.rounds; r++ {
+		if c.n == 16 {
+			c.u[r], c.v[r] = uint32(k[1]), uint32(k[0])
+		} else {
+			c.u[r] = uint32(k[5])<<16 | uint32(k[4])
+			c.v[r] = uint32(k[1])<<16 | uint32(k[0])
+		}
+
+		// the 6-bit LFSR, updated before use
+		rc = (rc<<1 | (rc>>5^rc>>4^1)&1) & 0x3f
+		c.c[r] = rc | 1<<(c.n-1)
+
+		k[0], k[1], k[2], k[3], k[4], k[5], k[6], k[7] =
+			k[2], k[3], k[4], k[5], k[6], k[7], k[0]>>12|k[0]<<4, k[1]>>2|k[1]<<14
+	}
+
+	return c, nil
+}
+
+// Reset zeroes the round keys, leaving the round constants.
+func (c *giftCipher) Reset() {
+	for i := range c.u {
+		c.u[i], c.v[i] = 0, 0
+	}
+}
+
+// IsWiped reports whether the round keys are all zero.
+func (c *giftCipher) IsWiped() bool {
+	for i := range c.u {
+		if c.u[i] != 0 || c.v[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *giftCipher) BlockSize() int { return int(c.n / 2) }
+
+// load splits a big-endian block into its four slices
+func (c *giftCipher) load(src []byte) [4]uint32 {
+
+	var s [4]uint32
+	for b := uint(0); b < c.n/2; b++ {
+		x := uint32(src[c.n/2-1-b])
+		for h := uint(0); h < 2; h++ {
+			i := 2*b + h // the nibble
+			for j := uint(0); j < 4; j++ {
+				s[j] |= (x >> (4*h + j) & 1) << i
+			}
+		}
+	}
+	return s
+}
+
+// store is the inverse of load
+func (c *giftCipher) store(dst []byte, s [4]uint32) {
+
+	for b := uint(0); b < c.n/2; b++ {
+		var x uint32
+		for h := uint(0); h < 2; h++ {
+			i := 2*b + h
+			for j := uint(0); j < 4; j++ {
+				x |= (s[j] >> i & 1) << (4*h + j)
+			}
+		}
+		dst[c.n/2-1-b] = byte(x)
+	}
+}
+
+// Encrypt encrypts the block in src and stores the resulting ciphertext in dst.
+func (c *giftCipher) Encrypt(dst, src []byte) {
+
+	s := c.load(src)
+
+	for r := 0; r < c.rounds; r++ {
+		giftS(&s)
+		c.permute(&s)
+		c.addRoundKey(&s, r)
+	}
+
+	c.store(dst, s)
+}
+
+// Decrypt decrypts the block in src and stores the resulting plaintext in dst.
+func (c *giftCipher) Decrypt(dst, src []byte) {
+
+	s := c.load(src)
+
+	for r := c.rounds - 1; r >= 0; r-- {
+		c.addRoundKey(&s, r)
+		c.permuteInv(&s)
+		giftSInv(&s)
+	}
+
+	c.store(dst, s)
+}
+
+func (c *giftCipher) addRoundKey(s *[4]uint32, r int) {
+	if c.n == 16 {
+		s[1] ^= c.u[r]
+		s[0] ^= c.v[r]
+	} else {
+		s[2] ^= c.u[r]
+		s[1] ^= c.v[r]
+	}
+	s[3] ^= c.c[r]
+}
+
+func (c *giftCipher) permute(s *[4]uint32) {
+	for j := range s {
+		var y uint32
+		for i := uint(0); i < c.n; i++ {
+			y |= (s[j] >> i & 1) << c.perm[j][i]
+		}
+		s[j] = y
+	}
+}
+
+func (c *giftCipher) permuteInv(s *[4]uint32) {
+	for j := range s {
+		var y uint32
+		for i := uint(0); i < c.n; i++ {
+			y |= (s[j] >> c.perm[j][i] & 1) << i
+		}
+		s[j] = y
+	}
+}
+
+// giftS applies the S-box to every nibble, as the circuit given in the paper
+func giftS(s *[4]uint32) {
+	s[1] ^= s[0] & s[2]
+	s[0] ^= s[1] & s[3]
+	s[2] ^= s[0] | s[1]
+	s[3] ^= s[2]
+	s[1] ^= s[3]
+	s[3] = ^s[3]
+	s[2] ^= s[0] & s[1]
+	s[0], s[3] = s[3], s[0]
+}
+
+// giftSInv undoes giftS, running its steps backwards
+func giftSInv(s *[4]uint32) {
+	s[0], s[3] = s[3], s[0]
+	s[2] ^= s[0] & s[1]
+	s[3] = ^s[3]
+	s[1] ^= s[3]
+	s[3] ^= s[2]
+	s[2] ^= s[0] | s[1]
+	s[0] ^= s[1] & s[3]
+	s[1] ^= s[0] & s[2]
+}
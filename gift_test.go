@@ -0,0 +1,103 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+// the test vectors from the GIFT paper
+var giftTestVectors = []struct {
+	bits   int
+	key    []byte
+	plain  []byte
+	cipher []byte
+}{
+	{
+		64,
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0xf6, 0x2b, 0xc3, 0xef, 0x34, 0xf7, 0x75, 0xac},
+	},
+	{
+		64,
+		[]byte{0xfe, 0xdc, 0xba, 0x98, 0x76, 0x54, 0x32, 0x10, 0xfe, 0xdc, 0xba, 0x98, 0x76, 0x54, 0x32, 0x10},
+		[]byte{0xfe, 0xdc, 0xba, 0x98, 0x76, 0x54, 0x32, 0x10},
+		[]byte{0xc1, 0xb7, 0x1f, 0x66, 0x16, 0x0f, 0xf5, 0x87},
+	},
+	{
+		64,
+		[]byte{0xbd, 0x91, 0x73, 0x1e, 0xb6, 0xbc, 0x27, 0x13, 0xa1, 0xf9, 0xf6, 0xff, 0xc7, 0x50, 0x44, 0xe7},
+		[]byte{0xc4, 0x50, 0xc7, 0x72, 0x7a, 0x9b, 0x8a, 0x7d},
+		[]byte{0xe3, 0x27, 0x28, 0x85, 0xfa, 0x94, 0xba, 0x8b},
+	},
+	{
+		128,
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0xcd, 0x0b, 0xd7, 0x38, 0x38, 0x8a, 0xd3, 0xf6, 0x68, 0xb1, 0x5a, 0x36, 0xce, 0xb6, 0xff, 0x92},
+	},
+	{
+		128,
+		[]byte{0xfe, 0xdc, 0xba, 0x98, 0x76, 0x54, 0x32, 0x10, 0xfe, 0xdc, 0xba, 0x98, 0x76, 0x54, 0x32, 0x10},
+		[]byte{0xfe, 0xdc, 0xba, 0x98, 0x76, 0x54, 0x32, 0x10, 0xfe, 0xdc, 0xba, 0x98, 0x76, 0x54, 0x32, 0x10},
+		[]byte{0x84, 0x22, 0x24, 0x1a, 0x6d, 0xbf, 0x5a, 0x93, 0x46, 0xaf, 0x46, 0x84, 0x09, 0xee, 0x01, 0x52},
+	},
+	{
+		128,
+		[]byte{0xd0, 0xf5, 0xc5, 0x9a, 0x77, 0x00, 0xd3, 0xe7, 0x99, 0x02, 0x8f, 0xa9, 0xf9, 0x0a, 0xd8, 0x37},
+		[]byte{0xe3, 0x9c, 0x14, 0x1f, 0xa5, 0x7d, 0xba, 0x43, 0xf0, 0x8a, 0x85, 0xb6, 0xa9, 0x1f, 0x86, 0xc1},
+		[]byte{0x13, 0xed, 0xe6, 0x7c, 0xbd, 0xcc, 0x3d, 0xbf, 0x40, 0x0a, 0x62, 0xd6, 0x97, 0x72, 0x65, 0xea},
+	},
+}
+
+func TestGIFTEncrypt(t *testing.T) {
+
+	for _, v := range giftTestVectors {
+		g, err := NewGIFT(v.key, v.bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c := make([]byte, g.BlockSize())
+		p := make([]byte, g.BlockSize())
+
+		g.Encrypt(c, v.plain)
+
+		if !bytes.Equal(v.cipher, c) {
+			t.Errorf("gift-%d encrypt failed: got %#v wanted %#v\n", v.bits, c, v.cipher)
+		}
+
+		g.Decrypt(p, c)
+
+		if !bytes.Equal(v.plain, p) {
+			t.Errorf("gift-%d decrypt failed: got %#v wanted %#v\n", v.bits, p, v.plain)
+		}
+	}
+
+	if _, err := NewGIFT(make([]byte, 16), 96); err != BlockSizeError(96) {
+		t.Errorf("NewGIFT(96 bit block)=%v, wanted %v", err, BlockSizeError(96))
+	}
+	if _, err := NewGIFT(make([]byte, 8), 64); err != KeySizeError(8) {
+		t.Errorf("NewGIFT(8 byte key)=%v, wanted %v", err, KeySizeError(8))
+	}
+}
+
+// the S-box as a table, which giftS computes as a circuit
+var giftSbox = [16]byte{0x1, 0xa, 0x4, 0xc, 0x6, 0xf, 0x3, 0x9, 0x2, 0xd, 0xb, 0x7, 0x5, 0x0, 0x8, 0xe}
+
+func TestGIFTSbox(t *testing.T) {
+
+	// run each input through the circuit in nibble 0, and its inverse
+	for x := uint32(0); x < 16; x++ {
+		s := [4]uint32{x & 1, x >> 1 & 1, x >> 2 & 1, x >> 3 & 1}
+		giftS(&s)
+		y := s[0]&1 | (s[1]&1)<<1 | (s[2]&1)<<2 | (s[3]&1)<<3
+		if byte(y) != giftSbox[x] {
+			t.Errorf("giftS(%x)=%x, wanted %x", x, y, giftSbox[x])
+		}
+		giftSInv(&s)
+		if z := s[0]&1 | (s[1]&1)<<1 | (s[2]&1)<<2 | (s[3]&1)<<3; z != x {
+			t.Errorf("giftSInv(giftS(%x))=%x", x, z)
+		}
+	}
+}
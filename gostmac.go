@@ -0,0 +1,49 @@
+package krcrypt
+
+// The GOST block cipher MAC (imitovstavka)
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+GOST R 34.13-2015, section 5.6
+https://tools.ietf.org/html/rfc8891 (Magma, with the GOST R 34.13-2015 examples)
+
+*/
+
+import (
+	"crypto/cipher"
+	"errors"
+	"hash"
+)
+
+// ErrMACSize is returned when a MAC can't be truncated to the requested size
+var ErrMACSize = errors.New("krcrypt: invalid MAC size")
+
+// NewGOSTMAC returns the GOST R 34.13-2015 MAC (the imitovstavka) over block,
+// truncated to the given number of bits.  The block should be Magma, with an
+// 8-byte block, or Kuznyechik, with a 16-byte block; this package doesn't
+// include either, but any cipher.Block with one of those sizes works.  The
+// bits must be a multiple of 8, up to the block size; Magma is commonly used
+// with 32.
+//
+// This is not the MAC of the older GOST 28147-89, which ran only the first
+// 16 rounds of the cipher and so can't be built on a cipher.Block.
+func NewGOSTMAC(block cipher.Block, bits int) (hash.Hash, error) {
+
+	bs := block.BlockSize()
+	if bs != 8 && bs != 16 {
+		return nil, BlockSizeError(bs)
+	}
+
+	if bits <= 0 || bits > 8*bs || bits%8 != 0 {
+		return nil, ErrMACSize
+	}
+
+	// the GOST MAC is CMAC, truncated
+	c := newCMAC(block)
+	c.size = bits / 8
+	return c, nil
+}
@@ -0,0 +1,132 @@
+package krcrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/binary"
+	"encoding/hex"
+	"math/bits"
+	"testing"
+)
+
+// magmaCipher is enough of Magma (GOST R 34.12-2015) to check NewGOSTMAC
+// against the examples in GOST R 34.13-2015.  It only encrypts.
+type magmaCipher struct {
+	k [8]uint32
+}
+
+var magmaPi = [8][16]byte{
+	{12, 4, 6, 2, 10, 5, 11, 9, 14, 8, 13, 7, 0, 3, 15, 1},
+	{6, 8, 2, 3, 9, 10, 5, 12, 1, 14, 4, 7, 11, 13, 0, 15},
+	{11, 3, 5, 8, 2, 15, 10, 13, 14, 1, 7, 4, 12, 9, 6, 0},
+	{12, 8, 2, 1, 13, 4, 15, 6, 7, 0, 10, 5, 3, 14, 9, 11},
+	{7, 15, 5, 10, 8, 1, 6, 13, 0, 9, 3, 14, 11, 4, 2, 12},
+	{5, 13, 15, 6, 9, 2, 12, 10, 11, 7, 8, 1, 4, 3, 14, 0},
+	{8, 14, 2, 5, 6, 9, 1, 12, 15, 4, 11, 0, 13, 10, 3, 7},
+	{1, 7, 14, 13, 0, 5, 8, 3, 4, 15, 10, 6, 9, 12, 11, 2},
+}
+
+func newMagma(key []byte) *magmaCipher {
+	c := new(magmaCipher)
+	for i := range c.k {
+		c.k[i] = binary.BigEndian.Uint32(key[4*i:])
+	}
+	return c
+}
+
+func (c *magmaCipher) BlockSize() int { return 8 }
+
+func (c *magmaCipher) Encrypt(dst, src []byte) {
+	a1 := binary.BigEndian.Uint32(src)
+	a0 := binary.BigEndian.Uint32(src[4:])
+	for i := 0; i < 32; i++ {
+		k := c.k[i%8]
+		if i >= 24 {
+			k = c.k[7-i%8]
+		}
+		x := a0 + k
+		var t uint32
+		for j := uint(0); j < 8; j++ {
+			t |= uint32(magmaPi[j][x>>(4*j)&0xf]) << (4 * j)
+		}
+		a1, a0 = a0, bits.RotateLeft32(t, 11)^a1
+	}
+	binary.BigEndian.PutUint32(dst, a0)
+	binary.BigEndian.PutUint32(dst[4:], a1)
+}
+
+func (c *magmaCipher) Decrypt(dst, src []byte) { panic("not needed") }
+
+func TestGOSTMACMagma(t *testing.T) {
+
+	key, _ := hex.DecodeString("ffeeddccbbaa99887766554433221100f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff")
+	c := newMagma(key)
+
+	var ct [8]byte
+	pt, _ := hex.DecodeString("fedcba9876543210")
+	c.Encrypt(ct[:], pt)
+	if got := hex.EncodeToString(ct[:]); got != "4ee901e5c2d8ca3d" {
+		t.Fatalf("magma encrypt failed: got %s", got)
+	}
+
+	msg, _ := hex.DecodeString("92def06b3c130a59db54c704f8189d204a98fb2e67a8024c8912409b17b57e41")
+	h, err := NewGOSTMAC(c, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Write(msg)
+	if got := hex.EncodeToString(h.Sum(nil)); got != "154e7210" {
+		t.Errorf("GOST MAC failed: got %s wanted 154e7210\n", got)
+	}
+}
+
+// The GOST R 34.13-2015 MAC is CMAC, so with AES it gives the RFC 4493 tags
+func TestGOSTMACAES(t *testing.T) {
+
+	key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	block, _ := aes.NewCipher(key)
+
+	msg, _ := hex.DecodeString("6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710")
+
+	var tests = []struct {
+		len int
+		tag string
+	}{
+		{0, "bb1d6929e95937287fa37d129b756746"},
+		{16, "070a16b46b4d4144f79bdd9dd04a287c"},
+		{40, "dfa66747de9ae63030ca32611497c827"},
+		{64, "51f0bebf7e3b9d92fc49741779363cfe"},
+	}
+
+	h, _ := NewGOSTMAC(block, 128)
+	for _, tt := range tests {
+		h.Reset()
+		// write in two pieces, to exercise the buffering
+		h.Write(msg[:tt.len/3])
+		h.Write(msg[tt.len/3 : tt.len])
+		if got := hex.EncodeToString(h.Sum(nil)); got != tt.tag {
+			t.Errorf("GOST MAC(AES, %d bytes) failed: got %s wanted %s\n", tt.len, got, tt.tag)
+		}
+	}
+
+	// a shorter tag is the full one truncated
+	h32, _ := NewGOSTMAC(block, 32)
+	h32.Write(msg)
+	full := NewSecureCBCMAC(block)
+	full.Write(msg)
+	if got, want := h32.Sum(nil), full.Sum(nil)[:4]; !bytes.Equal(got, want) {
+		t.Errorf("GOST MAC(AES, 32 bits)=%x, wanted %x", got, want)
+	}
+
+	if _, err := NewGOSTMAC(block, 12); err != ErrMACSize {
+		t.Errorf("NewGOSTMAC(12 bits)=%v, wanted %v", err, ErrMACSize)
+	}
+	if _, err := NewGOSTMAC(block, 136); err != ErrMACSize {
+		t.Errorf("NewGOSTMAC(136 bits)=%v, wanted %v", err, ErrMACSize)
+	}
+
+	b, _ := NewSpeck(make([]byte, 12), 48)
+	if _, err := NewGOSTMAC(b, 32); err != BlockSizeError(6) {
+		t.Errorf("NewGOSTMAC(6-byte block)=%v, wanted %v", err, BlockSizeError(6))
+	}
+}
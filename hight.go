@@ -32,6 +32,27 @@ func (k KeySizeError) Error() string {
 	return "krcrypt: invalid key size " + strconv.Itoa(int(k))
 }
 
+// RoundsError is returned for unsupported round counts
+type RoundsError int
+
+func (r RoundsError) Error() string {
+	return "krcrypt: invalid number of rounds " + strconv.Itoa(int(r))
+}
+
+// IVSizeError is returned for invalid IV or nonce sizes
+type IVSizeError int
+
+func (i IVSizeError) Error() string {
+	return "krcrypt: invalid IV size " + strconv.Itoa(int(i))
+}
+
+// TweakSizeError is returned for invalid tweak sizes
+type TweakSizeError int
+
+func (t TweakSizeError) Error() string {
+	return "krcrypt: invalid tweak size " + strconv.Itoa(int(t))
+}
+
 // NewHIGHT creates and returns a new cipher.Block implementing the HIGHT cipher.
 // The key argument should be 16 bytes.
 func NewHIGHT(key []byte) (cipher.Block, error) {
@@ -46,6 +67,17 @@ func NewHIGHT(key []byte) (cipher.Block, error) {
 	return c, nil
 }
 
+// Reset zeroes the whitening keys and subkeys.
+func (c *hightCipher) Reset() {
+	c.wk = [8]byte{}
+	c.sk = [128]byte{}
+}
+
+// IsWiped reports whether the whitening keys and subkeys are all zero.
+func (c *hightCipher) IsWiped() bool {
+	return c.wk == [8]byte{} && c.sk == [128]byte{}
+}
+
 func (c *hightCipher) BlockSize() int { return 8 }
 
 // rotate left
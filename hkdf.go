@@ -0,0 +1,70 @@
+package krcrypt
+
+// HKDF with SEED-CMAC
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+https://tools.ietf.org/html/rfc5869
+https://tools.ietf.org/html/rfc4615
+
+*/
+
+import "errors"
+
+// ErrKeyLength is returned by ExpandKey and DeriveKey when asked for more
+// than 255 blocks of output, the most HKDF-Expand can produce.
+var ErrKeyLength = errors.New("krcrypt: derived key length too large")
+
+// DeriveKey derives length bytes of key material from secret with HKDF, using
+// SEED-CMAC as the PRF in place of HMAC.  The extract step is the CMAC of
+// secret with salt as the key, reduced as in PBKDF2 when salt isn't 16 bytes,
+// giving a 16-byte pseudo-random key that is then passed to ExpandKey.
+//
+// Use DeriveKey when secret is not already a uniformly random key: a
+// Diffie-Hellman shared secret, say, or key material of uneven quality.  For
+// a password, use PBKDF2 or MemoryHardKDF instead.
+func DeriveKey(secret, salt, info []byte, length int) ([]byte, error) {
+
+	prf := newCMACPRF(NewSEED, salt)
+	prf.Write(secret)
+
+	return ExpandKey(prf.Sum(nil), info, length)
+}
+
+// ExpandKey is the expand step of DeriveKey on its own: it stretches the
+// 16-byte pseudo-random key prk into length bytes bound to info, each 16-byte
+// block being T(i) = CMAC(prk, T(i-1) || info || i) with T(0) empty.
+//
+// Skipping the extract step is only safe when prk is already a uniformly
+// random SEED key, such as one from crypto/rand or an earlier DeriveKey;
+// otherwise use DeriveKey.  Different info strings give independent keys from
+// the same prk.
+func ExpandKey(prk, info []byte, length int) ([]byte, error) {
+
+	if len(prk) != 16 {
+		return nil, KeySizeError(len(prk))
+	}
+	if length < 0 || length > 255*16 {
+		return nil, ErrKeyLength
+	}
+
+	mac, _ := NewCMAC(prk)
+
+	okm := make([]byte, 0, length+15)
+	var t []byte
+
+	for i := 1; len(okm) < length; i++ {
+		mac.Reset()
+		mac.Write(t)
+		mac.Write(info)
+		mac.Write([]byte{byte(i)})
+		t = mac.Sum(nil)
+		okm = append(okm, t...)
+	}
+
+	return okm[:length], nil
+}
@@ -0,0 +1,73 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestExpandKey(t *testing.T) {
+
+	prk := []byte("0123456789abcdef")
+	info := []byte("context")
+
+	okm, err := ExpandKey(prk, info, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// check the counter blocks by hand
+	mac, _ := NewCMAC(prk)
+	var want, prev []byte
+	for i := byte(1); i <= 3; i++ {
+		mac.Reset()
+		mac.Write(prev)
+		mac.Write(info)
+		mac.Write([]byte{i})
+		prev = mac.Sum(nil)
+		want = append(want, prev...)
+	}
+	if !bytes.Equal(okm, want[:40]) {
+		t.Errorf("ExpandKey=%x, wanted %x", okm, want[:40])
+	}
+
+	// shorter outputs are prefixes of longer ones
+	if short, _ := ExpandKey(prk, info, 5); !bytes.Equal(short, okm[:5]) {
+		t.Errorf("ExpandKey(5)=%x isn't a prefix of %x", short, okm)
+	}
+
+	if other, _ := ExpandKey(prk, []byte("contexu"), 40); bytes.Equal(other, okm) {
+		t.Errorf("ExpandKey ignored info")
+	}
+
+	if _, err := ExpandKey(prk[:8], info, 16); err != KeySizeError(8) {
+		t.Errorf("ExpandKey(short prk)=%v, wanted %v", err, KeySizeError(8))
+	}
+
+	if _, err := ExpandKey(prk, info, 255*16+1); err != ErrKeyLength {
+		t.Errorf("ExpandKey(too long)=%v, wanted %v", err, ErrKeyLength)
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+
+	secret := []byte("shared secret from a key exchange")
+	salt := []byte("salt")
+	info := []byte("context")
+
+	okm, err := DeriveKey(secret, salt, info, 48)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the output is the expand step over the extracted PRK
+	prf := newCMACPRF(NewSEED, salt)
+	prf.Write(secret)
+	want, _ := ExpandKey(prf.Sum(nil), info, 48)
+	if !bytes.Equal(okm, want) {
+		t.Errorf("DeriveKey=%x, wanted ExpandKey(extract)=%x", okm, want)
+	}
+
+	if other, _ := DeriveKey(secret, []byte("salu"), info, 48); bytes.Equal(other, okm) {
+		t.Errorf("DeriveKey ignored salt")
+	}
+}
@@ -0,0 +1,75 @@
+package krcrypt
+
+// A known-answer test harness for block ciphers
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/hex"
+	"errors"
+)
+
+// A BlockVector is a known-answer test vector for a block cipher.
+type BlockVector struct {
+	Key, Plaintext, Ciphertext []byte
+}
+
+// TestingTB is the part of testing.TB that RunBlockKAT uses, so that this
+// package doesn't have to import testing.
+type TestingTB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// RunBlockKAT checks a block cipher constructor against test vectors,
+// reporting failures through t.  For each vector it checks that encryption
+// gives the ciphertext and decryption the plaintext, both into a separate
+// buffer and in place, and that the constructor accepts the key.
+func RunBlockKAT(t TestingTB, ctor func([]byte) (cipher.Block, error), vectors []BlockVector) {
+	t.Helper()
+	for i, v := range vectors {
+		if err := checkBlockVector(ctor, v); err != nil {
+			t.Errorf("vector %d (key %x): %v", i, v.Key, err)
+		}
+	}
+}
+
+// checkBlockVector runs one vector, returning a description of the first failure
+func checkBlockVector(ctor func([]byte) (cipher.Block, error), v BlockVector) error {
+
+	b, err := ctor(v.Key)
+	if err != nil {
+		return err
+	}
+
+	if len(v.Plaintext) != b.BlockSize() || len(v.Ciphertext) != b.BlockSize() {
+		return errors.New("vector isn't one block long")
+	}
+
+	got := make([]byte, b.BlockSize())
+
+	b.Encrypt(got, v.Plaintext)
+	if !bytes.Equal(got, v.Ciphertext) {
+		return errors.New("encrypt gave " + hex.EncodeToString(got) + ", wanted " + hex.EncodeToString(v.Ciphertext))
+	}
+
+	b.Decrypt(got, v.Ciphertext)
+	if !bytes.Equal(got, v.Plaintext) {
+		return errors.New("decrypt gave " + hex.EncodeToString(got) + ", wanted " + hex.EncodeToString(v.Plaintext))
+	}
+
+	copy(got, v.Plaintext)
+	b.Encrypt(got, got)
+	if !bytes.Equal(got, v.Ciphertext) {
+		return errors.New("in-place encrypt gave " + hex.EncodeToString(got) + ", wanted " + hex.EncodeToString(v.Ciphertext))
+	}
+
+	b.Decrypt(got, got)
+	if !bytes.Equal(got, v.Plaintext) {
+		return errors.New("in-place decrypt gave " + hex.EncodeToString(got) + ", wanted " + hex.EncodeToString(v.Plaintext))
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package krcrypt
+
+import (
+	"crypto/cipher"
+	"fmt"
+	"testing"
+)
+
+// a TestingTB that records failures instead of failing the test
+type recordingTB struct{ errors []string }
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRunBlockKAT(t *testing.T) {
+
+	v := seedTestVectors[0]
+	good := BlockVector{v.key, v.plain, v.cipher}
+
+	var r recordingTB
+	RunBlockKAT(&r, NewSEED, []BlockVector{good})
+	if r.errors != nil {
+		t.Errorf("RunBlockKAT(good vector) failed: %v", r.errors)
+	}
+
+	bad := good
+	bad.Ciphertext = append([]byte{^good.Ciphertext[0]}, good.Ciphertext[1:]...)
+	short := BlockVector{make([]byte, 8), v.plain, v.cipher}
+
+	r = recordingTB{}
+	RunBlockKAT(&r, NewSEED, []BlockVector{good, bad, short})
+	if len(r.errors) != 2 {
+		t.Errorf("RunBlockKAT reported %d failures, wanted 2: %v", len(r.errors), r.errors)
+	}
+
+	// a cipher that only fails in place is caught too
+	r = recordingTB{}
+	RunBlockKAT(&r, func(k []byte) (cipher.Block, error) {
+		b, err := NewSEED(k)
+		return inPlaceBrokenBlock{b}, err
+	}, []BlockVector{good})
+	if len(r.errors) != 1 {
+		t.Errorf("RunBlockKAT(broken in place) reported %d failures, wanted 1: %v", len(r.errors), r.errors)
+	}
+}
+
+// a cipher whose Encrypt is wrong when dst and src are the same
+type inPlaceBrokenBlock struct{ cipher.Block }
+
+func (b inPlaceBrokenBlock) Encrypt(dst, src []byte) {
+	same := &dst[0] == &src[0]
+	b.Block.Encrypt(dst, src)
+	if same {
+		dst[0] ^= 1
+	}
+}
@@ -0,0 +1,79 @@
+package krcrypt
+
+// A process-wide SEED-GCM key, for values that encrypt themselves
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+import (
+	"crypto/cipher"
+	"encoding/base64"
+	"sync"
+)
+
+// keyedAEAD holds the SEED-GCM key that a kind of self-encrypting value, such
+// as EncryptedBlob or EncryptedString, is sealed under.  Its methods return
+// errNoKey until set has been called.
+type keyedAEAD struct {
+	sync.RWMutex
+	aead     cipher.AEAD
+	errNoKey error
+}
+
+// set replaces the key with the 16-byte SEED key
+func (k *keyedAEAD) set(key []byte) error {
+
+	aead, err := NewGCM(key)
+	if err != nil {
+		return err
+	}
+
+	k.Lock()
+	k.aead = aead
+	k.Unlock()
+
+	return nil
+}
+
+func (k *keyedAEAD) get() (cipher.AEAD, error) {
+	k.RLock()
+	defer k.RUnlock()
+	if k.aead == nil {
+		return nil, k.errNoKey
+	}
+	return k.aead, nil
+}
+
+// sealText returns the base64 of a random nonce followed by the ciphertext of plain
+func (k *keyedAEAD) sealText(plain []byte) ([]byte, error) {
+
+	aead, err := k.get()
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := sealRandomNonce(aead, plain, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	text := make([]byte, base64.StdEncoding.EncodedLen(len(sealed)))
+	base64.StdEncoding.Encode(text, sealed)
+	return text, nil
+}
+
+// openText reverses sealText
+func (k *keyedAEAD) openText(text []byte) ([]byte, error) {
+
+	aead, err := k.get()
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := make([]byte, base64.StdEncoding.DecodedLen(len(text)))
+	n, err := base64.StdEncoding.Decode(sealed, text)
+	if err != nil {
+		return nil, err
+	}
+
+	return openPrefixedNonce(aead, sealed[:n], nil)
+}
@@ -0,0 +1,121 @@
+package krcrypt
+
+// The Khazad block cipher
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+http://www.larc.usp.br/~pbarreto/KhazadPage.html
+https://www.cosic.esat.kuleuven.be/nessie/tweaks.html (the tweaked S-box)
+
+*/
+
+import "encoding/binary"
+
+// A KhazadCipher is an instance of Khazad encryption using a particular key.
+//
+// Khazad is the 64-bit block companion to Anubis from NESSIE, by the same
+// authors and with the same S-box.  Like Anubis every component is an
+// involution, and like Anubis it is mostly of research interest.
+type KhazadCipher struct {
+	ek [khazadRounds + 1]uint64 // encryption round keys
+	dk [khazadRounds + 1]uint64 // decryption round keys
+}
+
+const khazadRounds = 8
+
+// khazadT[i][x] is S[x] times row i of had(01, 03, 04, 05, 06, 08, 0b, 07),
+// as a big-endian word
+var khazadT [8][256]uint64
+
+func init() {
+	h := [8]byte{0x01, 0x03, 0x04, 0x05, 0x06, 0x08, 0x0b, 0x07}
+	for x := 0; x < 256; x++ {
+		s := anubisSbox[x]
+		for i := 0; i < 8; i++ {
+			var w uint64
+			for j := 0; j < 8; j++ {
+				w = w<<8 | uint64(clefiaMul(s, h[i^j]))
+			}
+			khazadT[i][x] = w
+		}
+	}
+}
+
+// NewKhazad creates and returns a new KhazadCipher.  The key must be 16 bytes.
+func NewKhazad(key []byte) (*KhazadCipher, error) {
+
+	if klen := len(key); klen != 16 {
+		return nil, KeySizeError(klen)
+	}
+
+	c := new(KhazadCipher)
+
+	// a Feistel network over the round function, with the round constants
+	// taken from the S-box, generates the round keys
+	k2 := binary.BigEndian.Uint64(key)
+	k1 := binary.BigEndian.Uint64(key[8:])
+	for r := range c.ek {
+		c.ek[r] = khazadRound(k1) ^ binary.BigEndian.Uint64(anubisSbox[8*r:]) ^ k2
+		k2, k1 = k1, c.ek[r]
+	}
+
+	// decryption runs the same rounds with the keys reversed and, except
+	// for the first and last, passed through theta
+	c.dk[0] = c.ek[khazadRounds]
+	c.dk[khazadRounds] = c.ek[0]
+	for r := 1; r < khazadRounds; r++ {
+		// khazadRound includes the S-box, so undo it first; it's an involution
+		c.dk[r] = khazadRound(khazadGamma(c.ek[khazadRounds-r]))
+	}
+
+	return c, nil
+}
+
+// khazadRound applies the S-box and then theta to x
+func khazadRound(x uint64) uint64 {
+	return khazadT[0][x>>56] ^ khazadT[1][x>>48&0xff] ^ khazadT[2][x>>40&0xff] ^ khazadT[3][x>>32&0xff] ^
+		khazadT[4][x>>24&0xff] ^ khazadT[5][x>>16&0xff] ^ khazadT[6][x>>8&0xff] ^ khazadT[7][x&0xff]
+}
+
+// khazadGamma applies the S-box to each byte of x
+func khazadGamma(x uint64) uint64 {
+	var y uint64
+	for i := uint(0); i < 64; i += 8 {
+		y |= uint64(anubisSbox[x>>i&0xff]) << i
+	}
+	return y
+}
+
+// Reset zeroes the encryption and decryption round keys.
+func (c *KhazadCipher) Reset() {
+	c.ek = [khazadRounds + 1]uint64{}
+	c.dk = [khazadRounds + 1]uint64{}
+}
+
+// IsWiped reports whether the encryption and decryption round keys are all zero.
+func (c *KhazadCipher) IsWiped() bool {
+	return c.ek == [khazadRounds + 1]uint64{} && c.dk == [khazadRounds + 1]uint64{}
+}
+
+func (c *KhazadCipher) BlockSize() int { return 8 }
+
+// Encrypt encrypts the 8-byte block in src and stores the resulting ciphertext in dst.
+func (c *KhazadCipher) Encrypt(dst, src []byte) { khazadCrypt(dst, src, &c.ek) }
+
+// Decrypt decrypts the 8-byte block in src and stores the resulting plaintext in dst.
+func (c *KhazadCipher) Decrypt(dst, src []byte) { khazadCrypt(dst, src, &c.dk) }
+
+func khazadCrypt(dst, src []byte, rk *[khazadRounds + 1]uint64) {
+
+	x := binary.BigEndian.Uint64(src) ^ rk[0]
+	for r := 1; r < khazadRounds; r++ {
+		x = khazadRound(x) ^ rk[r]
+	}
+	x = khazadGamma(x) ^ rk[khazadRounds]
+
+	binary.BigEndian.PutUint64(dst, x)
+}
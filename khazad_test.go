@@ -0,0 +1,50 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+var khazadTestVectors = []struct {
+	key    []byte
+	plain  []byte
+	cipher []byte
+}{
+	// NESSIE set 1, vector 0
+	{
+		[]byte{0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0x49, 0xa4, 0xce, 0x32, 0xac, 0x19, 0x0e, 0x3f},
+	},
+	// from the Linux kernel's tcrypt
+	{
+		bytes.Repeat([]byte{0x38}, 16),
+		bytes.Repeat([]byte{0x38}, 8),
+		[]byte{0x7e, 0x82, 0x12, 0xa1, 0xd9, 0x5b, 0xe4, 0xf9},
+	},
+}
+
+func TestKhazad(t *testing.T) {
+
+	for _, v := range khazadTestVectors {
+		c, err := NewKhazad(v.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var b [8]byte
+		c.Encrypt(b[:], v.plain)
+		if !bytes.Equal(b[:], v.cipher) {
+			t.Errorf("khazad encrypt failed: got %#v wanted %#v\n", b, v.cipher)
+		}
+
+		c.Decrypt(b[:], b[:])
+		if !bytes.Equal(b[:], v.plain) {
+			t.Errorf("khazad decrypt failed: got %#v wanted %#v\n", b, v.plain)
+		}
+	}
+
+	if _, err := NewKhazad(make([]byte, 24)); err != KeySizeError(24) {
+		t.Errorf("NewKhazad(24-byte key)=%v, wanted %v", err, KeySizeError(24))
+	}
+}
@@ -0,0 +1,119 @@
+package krcrypt
+
+// A memory-hard key derivation function in the style of scrypt, over SEED
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+https://tools.ietf.org/html/rfc7914 (scrypt)
+
+*/
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+)
+
+// ErrKDFParams is returned for unusable key derivation parameters
+var ErrKDFParams = errors.New("krcrypt: invalid key derivation parameters")
+
+// the most memory MemoryHardKDF will use, 1GB
+const memoryHardMaxBytes = 1 << 30
+
+// MemoryHardKDF derives a keyLen-byte key from the password and salt with the
+// structure of scrypt (RFC 7914) with p = 1: PBKDF2 expands the password into
+// a 128*r byte block, ROMix fills 128*r*N bytes of memory with successive
+// BlockMix outputs and then reads them back in a data-dependent order, and
+// PBKDF2 compresses the result.  N must be a power of two greater than 1, and
+// the memory used, 128*r*N bytes, may be at most 1GB.
+//
+// Where scrypt's BlockMix uses the Salsa20/8 core, this uses SEED under the
+// all-zero key as a fixed public permutation, run over each 64-byte chunk in
+// CBC order forwards and then backwards, with a feed-forward.  PBKDF2 is the
+// SEED-CMAC version in this package.  That keeps the whole derivation inside
+// SEED, but the mixing function is non-standard and unanalysed: nothing is
+// known about it beyond the generic argument for scrypt's structure.  It is
+// also far slower than Salsa20/8, so for the same time budget an attacker
+// faces less memory than with real scrypt.  Use golang.org/x/crypto/scrypt
+// or Argon2 wherever they are available.
+func MemoryHardKDF(password, salt []byte, N, r int, keyLen int) ([]byte, error) {
+
+	if N < 2 || N&(N-1) != 0 || r < 1 || keyLen < 1 {
+		return nil, ErrKDFParams
+	}
+
+	if uint64(r)*uint64(N) > memoryHardMaxBytes/128 {
+		return nil, ErrKDFParams
+	}
+
+	block, err := NewSEED(make([]byte, 16))
+	if err != nil {
+		return nil, err
+	}
+
+	bs := 128 * r
+
+	x := PBKDF2(password, salt, 1, bs)
+	y := make([]byte, bs)
+	v := make([]byte, bs*N)
+
+	// ROMix
+	for i := 0; i < N; i++ {
+		copy(v[i*bs:], x)
+		memoryHardBlockMix(block, y, x, r)
+		x, y = y, x
+	}
+
+	for i := 0; i < N; i++ {
+		j := int(binary.LittleEndian.Uint64(x[bs-64:]) & uint64(N-1))
+		xorslice(x, x, v[j*bs:])
+		memoryHardBlockMix(block, y, x, r)
+		x, y = y, x
+	}
+
+	return PBKDF2(password, x, 1, keyLen), nil
+}
+
+// memoryHardBlockMix is scrypt's BlockMix over 2r 64-byte chunks, with the
+// SEED mixing function in place of Salsa20/8
+func memoryHardBlockMix(block cipher.Block, out, in []byte, r int) {
+
+	var x [64]byte
+	copy(x[:], in[(2*r-1)*64:])
+
+	for i := 0; i < 2*r; i++ {
+		xorslice(x[:], x[:], in[i*64:])
+		memoryHardMix(block, &x)
+
+		// even chunks go to the first half of the output, odd to the second
+		copy(out[(i/2+(i%2)*r)*64:], x[:])
+	}
+}
+
+// memoryHardMix replaces x with x ^ P(x), where P runs SEED over the four
+// blocks of x in CBC order forwards and then backwards, so that every output
+// byte depends on every input byte
+func memoryHardMix(block cipher.Block, x *[64]byte) {
+
+	t := *x
+
+	for k := 0; k < 64; k += 16 {
+		if k > 0 {
+			xorslice(t[k:k+16], t[k:k+16], t[k-16:k])
+		}
+		block.Encrypt(t[k:], t[k:k+16])
+	}
+
+	for k := 48; k >= 0; k -= 16 {
+		if k < 48 {
+			xorslice(t[k:k+16], t[k:k+16], t[k+16:k+32])
+		}
+		block.Encrypt(t[k:], t[k:k+16])
+	}
+
+	xorslice(x[:], x[:], t[:])
+}
@@ -0,0 +1,58 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMemoryHardKDF(t *testing.T) {
+
+	password := []byte("password")
+	salt := []byte("NaCl")
+
+	dk, err := MemoryHardKDF(password, salt, 16, 2, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dk) != 40 {
+		t.Errorf("MemoryHardKDF returned %d bytes, wanted 40", len(dk))
+	}
+
+	if again, _ := MemoryHardKDF(password, salt, 16, 2, 40); !bytes.Equal(dk, again) {
+		t.Errorf("MemoryHardKDF isn't deterministic")
+	}
+
+	for _, other := range []struct {
+		password, salt []byte
+		N, r           int
+	}{
+		{[]byte("passwore"), salt, 16, 2},
+		{password, []byte("NaCm"), 16, 2},
+		{password, salt, 32, 2},
+		{password, salt, 16, 1},
+	} {
+		got, err := MemoryHardKDF(other.password, other.salt, other.N, other.r, 40)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(got, dk) {
+			t.Errorf("MemoryHardKDF ignored a change in its inputs: %+v", other)
+		}
+	}
+
+	for _, bad := range []struct {
+		N, r, keyLen int
+	}{
+		{0, 1, 16},
+		{1, 1, 16},
+		{15, 1, 16},
+		{-16, 1, 16},
+		{16, 0, 16},
+		{16, 1, 0},
+		{1 << 20, 16, 16}, // 2GB
+	} {
+		if _, err := MemoryHardKDF(password, salt, bad.N, bad.r, bad.keyLen); err != ErrKDFParams {
+			t.Errorf("MemoryHardKDF(N=%d, r=%d, keyLen=%d)=%v, wanted %v", bad.N, bad.r, bad.keyLen, err, ErrKDFParams)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package krcrypt
+
+// The Midori family of low-energy block ciphers
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+https://eprint.iacr.org/2015/1142.pdf
+
+*/
+
+import "crypto/cipher"
+
+// A midoriCipher is an instance of Midori64 or Midori128 with a particular
+// key.  The state is sixteen cells, nibbles for Midori64 and bytes for
+// Midori128, numbered column by column.
+type midoriCipher struct {
+	wide   bool       // Midori128, with 8-bit cells
+	wk     [16]byte   // whitening key
+	rk     [][16]byte // round keys
+	rounds int
+}
+
+// the 4-bit S-boxes; Sb0 is Midori64's, and Sb1 is used inside Midori128's
+// 8-bit S-boxes.  Both are involutions.
+var (
+	midoriSb0 = [16]byte{0xc, 0xa, 0xd, 0x3, 0xe, 0xb, 0xf, 0x7, 0x8, 0x9, 0x1, 0x5, 0x0, 0x2, 0x4, 0x6}
+	midoriSb1 = [16]byte{0x1, 0x0, 0x5, 0x3, 0xe, 0x2, 0xf, 0x7, 0xd, 0xa, 0x9, 0xb, 0xc, 0x8, 0x4, 0x6}
+)
+
+// Midori128's four 8-bit S-boxes, built at init
+var midoriSSb [4][256]byte
+
+// the bit permutations for SSb0..SSb3: which input bits (0 being the most
+// significant) feed the high and low Sb1
+var midoriSSbPerm = [4][8]uint{
+	{4, 1, 6, 3, 0, 5, 2, 7},
+	{1, 6, 7, 0, 5, 2, 3, 4},
+	{2, 3, 4, 1, 6, 7, 0, 5},
+	{7, 4, 1, 2, 3, 0, 5, 6},
+}
+
+// the round constants, one bit per cell, added to the cells' low bits
+var midoriBeta = [19][16]byte{
+	{0, 0, 0, 1, 0, 1, 0, 1, 1, 0, 1, 1, 0, 0, 1, 1},
+	{0, 1, 1, 1, 1, 0, 0, 0, 1, 1, 0, 0, 0, 0, 0, 0},
+	{1, 0, 1, 0, 0, 1, 0, 0, 0, 0, 1, 1, 0, 1, 0, 1},
+	{0, 1, 1, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 1, 1},
+	{0, 0, 0, 1, 0, 0, 0, 0, 0, 1, 0, 0, 1, 1, 1, 1},
+	{1, 1, 0, 1, 0, 0, 0, 1, 0, 1, 1, 1, 0, 0, 0, 0},
+	{0, 0, 0, 0, 0, 0, 1, 0, 0, 1, 1, 0, 0, 1, 1, 0},
+	{0, 0, 0, 0, 1, 0, 1, 1, 1, 1, 0, 0, 1, 1, 0, 0},
+	{1, 0, 0, 1, 0, 1, 0, 0, 1, 0, 0, 0, 0, 0, 0, 1},
+	{0, 1, 0, 0, 0, 0, 0, 0, 1, 0, 1, 1, 1, 0, 0, 0},
+	{0, 1, 1, 1, 0, 0, 0, 1, 1, 0, 0, 1, 0, 1, 1, 1},
+	{0, 0, 1, 0, 0, 0, 1, 0, 1, 0, 0, 0, 1, 1, 1, 0},
+	{0, 1, 0, 1, 0, 0, 0, 1, 0, 0, 1, 1, 0, 0, 0, 0},
+	{1, 1, 1, 1, 1, 0, 0, 0, 1, 1, 0, 0, 1, 0, 1, 0},
+	{1, 1, 0, 1, 1, 1, 1, 1, 1, 0, 0, 1, 0, 0, 0, 0},
+	{0, 1, 1, 1, 1, 1, 0, 0, 1, 0, 0, 0, 0, 0, 0, 1},
+	{0, 0, 0, 1, 1, 1, 0, 0, 0, 0, 1, 0, 0, 1, 0, 0},
+	{0, 0, 1, 0, 0, 0, 1, 1, 1, 0, 1, 1, 0, 1, 0, 0},
+	{0, 1, 1, 0, 0, 0, 1, 0, 1, 0, 0, 0, 1, 0, 1, 0},
+}
+
+// ShuffleCell, and its inverse
+var (
+	midoriShuffle    = [16]int{0, 10, 5, 15, 14, 4, 11, 1, 9, 3, 12, 6, 7, 13, 2, 8}
+	midoriShuffleInv [16]int
+)
+
+func init() {
+	for i, p := range midoriShuffle {
+		midoriShuffleInv[p] = i
+	}
+
+	for i, perm := range midoriSSbPerm {
+		for x := range midoriSSb[i] {
+			// gather the input bits, run the two halves through Sb1, and
+			// put the bits back where they came from
+			var n uint
+			for j, b := range perm {
+				n |= uint(x>>(7-b)&1) << (7 - uint(j))
+			}
+			n = uint(midoriSb1[n>>4])<<4 | uint(midoriSb1[n&15])
+			var y byte
+			for j, b := range perm {
+				y |= byte(n>>(7-uint(j))&1) << (7 - b)
+			}
+			midoriSSb[i][x] = y
+		}
+	}
+}
+
+// NewMidori creates and returns a new cipher.Block implementing Midori with
+// the given block size in bits (64 or 128).  The key must be 16 bytes.
+func NewMidori(key []byte, blockBits int) (cipher.Block, error) {
+
+	c := new(midoriCipher)
+
+	switch blockBits {
+	case 64:
+		c.rounds = 16
+	case 128:
+		c.wide, c.rounds = true, 20
+	default:
+		return nil, BlockSizeError(blockBits)
+	}
+
+	if klen := len(key); klen != 16 {
+		return nil, KeySizeError(klen)
+	}
+
+	c.rk = make([][16]byte, c.rounds-1)
+
+	if c.wide {
+		copy(c.wk[:], key)
+		for i := range c.rk {
+			for j := range c.rk[i] {
+				c.rk[i][j] = key[j] ^ midoriBeta[i][j]
+			}
+		}
+		return c, nil
+	}
+
+	// Midori64 splits the key into two 64-bit halves, used alternately
+	var k [2][16]byte
+	for i := 0; i < 16; i++ {
+		k[i/8][2*(i%8)] = key[i] >> 4
+		k[i/8][2*(i%8)+1] = key[i] & 15
+	}
+	for j := range c.wk {
+		c.wk[j] = k[0][j] ^ k[1][j]
+	}
+	for i := range c.rk {
+		for j := range c.rk[i] {
+			c.rk[i][j] = k[i%2][j] ^ midoriBeta[i][j]
+		}
+	}
+
+	return c, nil
+}
+
+// Reset zeroes the whitening and round keys.
+func (c *midoriCipher) Reset() {
+	c.wk = [16]byte{}
+	for i := range c.rk {
+		c.rk[i] = [16]byte{}
+	}
+}
+
+// IsWiped reports whether the whitening and round keys are all zero.
+func (c *midoriCipher) IsWiped() bool {
+	for _, rk := range c.rk {
+		if rk != [16]byte{} {
+			return false
+		}
+	}
+	return c.wk == [16]byte{}
+}
+
+func (c *midoriCipher) BlockSize() int {
+	if c.wide {
+		return 16
+	}
+	return 8
+}
+
+// load splits a block into cells
+func (c *midoriCipher) load(src []byte) [16]byte {
+	var s [16]byte
+	if c.wide {
+		copy(s[:], src[:16])
+		return s
+	}
+	for i := 0; i < 8; i++ {
+		s[2*i] = src[i] >> 4
+		s[2*i+1] = src[i] & 15
+	}
+	return s
+}
+
+// store is the inverse of load
+func (c *midoriCipher) store(dst []byte, s [16]byte) {
+	if c.wide {
+		copy(dst, s[:])
+		return
+	}
+	for i := 0; i < 8; i++ {
+		dst[i] = s[2*i]<<4 | s[2*i+1]
+	}
+}
+
+// subCell is its own inverse
+func (c *midoriCipher) subCell(s *[16]byte) {
+	if c.wide {
+		for i := range s {
+			s[i] = midoriSSb[i%4][s[i]]
+		}
+		return
+	}
+	for i := range s {
+		s[i] = midoriSb0[s[i]]
+	}
+}
+
+// mixColumn replaces each cell with the sum of the other three in its
+// column.  It is its own inverse.
+func midoriMixColumn(s *[16]byte) {
+	for i := 0; i < 16; i += 4 {
+		t := s[i] ^ s[i+1] ^ s[i+2] ^ s[i+3]
+		s[i] ^= t
+		s[i+1] ^= t
+		s[i+2] ^= t
+		s[i+3] ^= t
+	}
+}
+
+func midoriShuffleCell(s *[16]byte, perm *[16]int) {
+	var t [16]byte
+	for i, p := range perm {
+		t[i] = s[p]
+	}
+	*s = t
+}
+
+func midoriAddKey(s, k *[16]byte) {
+	for i := range s {
+		s[i] ^= k[i]
+	}
+}
+
+// Encrypt encrypts the block in src and stores the resulting ciphertext in dst.
+func (c *midoriCipher) Encrypt(dst, src []byte) {
+
+	s := c.load(src)
+
+	midoriAddKey(&s, &c.wk)
+	for i := range c.rk {
+		c.subCell(&s)
+		midoriShuffleCell(&s, &midoriShuffle)
+		midoriMixColumn(&s)
+		midoriAddKey(&s, &c.rk[i])
+	}
+	c.subCell(&s)
+	midoriAddKey(&s, &c.wk)
+
+	c.store(dst, s)
+}
+
+// Decrypt decrypts the block in src and stores the resulting plaintext in dst.
+func (c *midoriCipher) Decrypt(dst, src []byte) {
+
+	s := c.load(src)
+
+	midoriAddKey(&s, &c.wk)
+	c.subCell(&s)
+	for i := len(c.rk) - 1; i >= 0; i-- {
+		midoriAddKey(&s, &c.rk[i])
+		midoriMixColumn(&s)
+		midoriShuffleCell(&s, &midoriShuffleInv)
+		c.subCell(&s)
+	}
+	midoriAddKey(&s, &c.wk)
+
+	c.store(dst, s)
+}
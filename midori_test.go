@@ -0,0 +1,71 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+// the test vectors from the appendix of the Midori paper
+var midoriTestVectors = []struct {
+	bits   int
+	key    []byte
+	plain  []byte
+	cipher []byte
+}{
+	{
+		64,
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0x3c, 0x9c, 0xce, 0xda, 0x2b, 0xbd, 0x44, 0x9a},
+	},
+	{
+		64,
+		[]byte{0x68, 0x7d, 0xed, 0x3b, 0x3c, 0x85, 0xb3, 0xf3, 0x5b, 0x10, 0x09, 0x86, 0x3e, 0x2a, 0x8c, 0xbf},
+		[]byte{0x42, 0xc2, 0x0f, 0xd3, 0xb5, 0x86, 0x87, 0x9e},
+		[]byte{0x66, 0xbc, 0xdc, 0x62, 0x70, 0xd9, 0x01, 0xcd},
+	},
+	{
+		128,
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0xc0, 0x55, 0xcb, 0xb9, 0x59, 0x96, 0xd1, 0x49, 0x02, 0xb6, 0x05, 0x74, 0xd5, 0xe7, 0x28, 0xd6},
+	},
+	{
+		128,
+		[]byte{0x68, 0x7d, 0xed, 0x3b, 0x3c, 0x85, 0xb3, 0xf3, 0x5b, 0x10, 0x09, 0x86, 0x3e, 0x2a, 0x8c, 0xbf},
+		[]byte{0x51, 0x08, 0x4c, 0xe6, 0xe7, 0x3a, 0x5c, 0xa2, 0xec, 0x87, 0xd7, 0xba, 0xbc, 0x29, 0x75, 0x43},
+		[]byte{0x1e, 0x0a, 0xc4, 0xfd, 0xdf, 0xf7, 0x1b, 0x4c, 0x18, 0x01, 0xb7, 0x3e, 0xe4, 0xaf, 0xc8, 0x3d},
+	},
+}
+
+func TestMidoriEncrypt(t *testing.T) {
+
+	for _, v := range midoriTestVectors {
+		m, err := NewMidori(v.key, v.bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c := make([]byte, m.BlockSize())
+		p := make([]byte, m.BlockSize())
+
+		m.Encrypt(c, v.plain)
+
+		if !bytes.Equal(v.cipher, c) {
+			t.Errorf("midori%d encrypt failed: got %#v wanted %#v\n", v.bits, c, v.cipher)
+		}
+
+		m.Decrypt(p, c)
+
+		if !bytes.Equal(v.plain, p) {
+			t.Errorf("midori%d decrypt failed: got %#v wanted %#v\n", v.bits, p, v.plain)
+		}
+	}
+
+	if _, err := NewMidori(make([]byte, 16), 96); err != BlockSizeError(96) {
+		t.Errorf("NewMidori(96 bit block)=%v, wanted %v", err, BlockSizeError(96))
+	}
+	if _, err := NewMidori(make([]byte, 8), 64); err != KeySizeError(8) {
+		t.Errorf("NewMidori(8 byte key)=%v, wanted %v", err, KeySizeError(8))
+	}
+}
@@ -0,0 +1,177 @@
+package krcrypt
+
+// The Noekeon block cipher
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+http://gro.noekeon.org/Noekeon-spec.pdf
+
+*/
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// A NoekeonCipher is an instance of Noekeon encryption using a particular key.
+//
+// Noekeon uses no table lookups and no data-dependent branches, so unlike the
+// S-box based ciphers in this package its running time doesn't depend on the
+// key or the data.
+type NoekeonCipher struct {
+	ek [4]uint32 // the working key
+	dk [4]uint32 // the working key with theta applied, for decryption
+}
+
+// round constants
+var noekeonRC = [...]uint32{
+	0x80, 0x1b, 0x36, 0x6c, 0xd8, 0xab, 0x4d, 0x9a,
+	0x2f, 0x5e, 0xbc, 0x63, 0xc6, 0x97, 0x35, 0x6a,
+	0xd4,
+}
+
+// NewNoekeon creates and returns a new NoekeonCipher in direct-key mode, where
+// the 16-byte key is used as the working key.  This is the mode to use when
+// related-key attacks are not a concern, e.g. when the key is random.
+func NewNoekeon(key []byte) (*NoekeonCipher, error) {
+
+	if klen := len(key); klen != 16 {
+		return nil, KeySizeError(klen)
+	}
+
+	c := new(NoekeonCipher)
+	c.setKey(key)
+	return c, nil
+}
+
+// NewNoekeonIndirect creates and returns a new NoekeonCipher in indirect-key
+// mode, where the working key is the encryption of the 16-byte key under the
+// all-zero key.  Use this mode if an attacker may be able to choose or
+// influence related keys.
+func NewNoekeonIndirect(key []byte) (*NoekeonCipher, error) {
+
+	if klen := len(key); klen != 16 {
+		return nil, KeySizeError(klen)
+	}
+
+	var zero [16]byte
+	var wk [16]byte
+
+	c := new(NoekeonCipher)
+	c.setKey(zero[:])
+	c.Encrypt(wk[:], key)
+	c.setKey(wk[:])
+	return c, nil
+}
+
+func (c *NoekeonCipher) setKey(key []byte) {
+	for i := range c.ek {
+		c.ek[i] = binary.BigEndian.Uint32(key[4*i:])
+	}
+	c.dk = c.ek
+	noekeonTheta(&c.dk, &[4]uint32{})
+}
+
+// Reset zeroes the working key and its decryption form.
+func (c *NoekeonCipher) Reset() {
+	c.ek = [4]uint32{}
+	c.dk = [4]uint32{}
+}
+
+// IsWiped reports whether the working key and its decryption form are all zero.
+func (c *NoekeonCipher) IsWiped() bool {
+	return c.ek == [4]uint32{} && c.dk == [4]uint32{}
+}
+
+func (c *NoekeonCipher) BlockSize() int { return 16 }
+
+// Encrypt encrypts the 16-byte block in src and stores the resulting ciphertext in dst.
+func (c *NoekeonCipher) Encrypt(dst, src []byte) {
+
+	var a [4]uint32
+	for i := range a {
+		a[i] = binary.BigEndian.Uint32(src[4*i:])
+	}
+
+	for i := 0; i < 16; i++ {
+		noekeonRound(&a, &c.ek, noekeonRC[i], 0)
+	}
+	a[0] ^= noekeonRC[16]
+	noekeonTheta(&a, &c.ek)
+
+	for i := range a {
+		binary.BigEndian.PutUint32(dst[4*i:], a[i])
+	}
+}
+
+// Decrypt decrypts the 16-byte block in src and stores the resulting plaintext in dst.
+func (c *NoekeonCipher) Decrypt(dst, src []byte) {
+
+	var a [4]uint32
+	for i := range a {
+		a[i] = binary.BigEndian.Uint32(src[4*i:])
+	}
+
+	for i := 16; i > 0; i-- {
+		noekeonRound(&a, &c.dk, 0, noekeonRC[i])
+	}
+	noekeonTheta(&a, &c.dk)
+	a[0] ^= noekeonRC[0]
+
+	for i := range a {
+		binary.BigEndian.PutUint32(dst[4*i:], a[i])
+	}
+}
+
+func noekeonRound(a, k *[4]uint32, c1, c2 uint32) {
+	a[0] ^= c1
+	noekeonTheta(a, k)
+	a[0] ^= c2
+	noekeonPi1(a)
+	noekeonGamma(a)
+	noekeonPi2(a)
+}
+
+// the linear layer
+func noekeonTheta(a, k *[4]uint32) {
+	t := a[0] ^ a[2]
+	t ^= bits.RotateLeft32(t, -8) ^ bits.RotateLeft32(t, 8)
+	a[1] ^= t
+	a[3] ^= t
+
+	a[0] ^= k[0]
+	a[1] ^= k[1]
+	a[2] ^= k[2]
+	a[3] ^= k[3]
+
+	t = a[1] ^ a[3]
+	t ^= bits.RotateLeft32(t, -8) ^ bits.RotateLeft32(t, 8)
+	a[0] ^= t
+	a[2] ^= t
+}
+
+// the nonlinear layer, a bitsliced 4-bit S-box
+func noekeonGamma(a *[4]uint32) {
+	a[1] ^= ^a[3] & ^a[2]
+	a[0] ^= a[2] & a[1]
+	a[0], a[3] = a[3], a[0]
+	a[2] ^= a[0] ^ a[1] ^ a[3]
+	a[1] ^= ^a[3] & ^a[2]
+	a[0] ^= a[2] & a[1]
+}
+
+func noekeonPi1(a *[4]uint32) {
+	a[1] = bits.RotateLeft32(a[1], 1)
+	a[2] = bits.RotateLeft32(a[2], 5)
+	a[3] = bits.RotateLeft32(a[3], 2)
+}
+
+func noekeonPi2(a *[4]uint32) {
+	a[1] = bits.RotateLeft32(a[1], -1)
+	a[2] = bits.RotateLeft32(a[2], -5)
+	a[3] = bits.RotateLeft32(a[3], -2)
+}
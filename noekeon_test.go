@@ -0,0 +1,93 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+// http://gro.noekeon.org/Noekeon-spec.pdf, direct-key mode test vectors
+var noekeonTestVectors = []struct {
+	key    []byte
+	plain  []byte
+	cipher []byte
+}{
+	{
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0xb1, 0x65, 0x68, 0x51, 0x69, 0x9e, 0x29, 0xfa, 0x24, 0xb7, 0x01, 0x48, 0x50, 0x3d, 0x2d, 0xfc},
+	},
+	{
+		[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		[]byte{0x2a, 0x78, 0x42, 0x1b, 0x87, 0xc7, 0xd0, 0x92, 0x4f, 0x26, 0x11, 0x3f, 0x1d, 0x13, 0x49, 0xb2},
+	},
+	{
+		[]byte{0xb1, 0x65, 0x68, 0x51, 0x69, 0x9e, 0x29, 0xfa, 0x24, 0xb7, 0x01, 0x48, 0x50, 0x3d, 0x2d, 0xfc},
+		[]byte{0x2a, 0x78, 0x42, 0x1b, 0x87, 0xc7, 0xd0, 0x92, 0x4f, 0x26, 0x11, 0x3f, 0x1d, 0x13, 0x49, 0xb2},
+		[]byte{0xe2, 0xf6, 0x87, 0xe0, 0x7b, 0x75, 0x66, 0x0f, 0xfc, 0x37, 0x22, 0x33, 0xbc, 0x47, 0x53, 0x2c},
+	},
+}
+
+func TestNoekeonEncrypt(t *testing.T) {
+
+	for _, v := range noekeonTestVectors {
+		n, _ := NewNoekeon(v.key)
+
+		var c, p [16]byte
+
+		n.Encrypt(c[:], v.plain)
+
+		if !bytes.Equal(v.cipher, c[:]) {
+			t.Errorf("noekeon encrypt failed: got %#v wanted %#v\n", c, v.cipher)
+		}
+
+		n.Decrypt(p[:], c[:])
+
+		if !bytes.Equal(v.plain, p[:]) {
+			t.Errorf("noekeon decrypt failed: got %#v wanted %#v\n", p, v.plain)
+		}
+	}
+}
+
+// indirect-key mode is direct-key mode under the key encrypted with the zero key
+func TestNoekeonIndirect(t *testing.T) {
+
+	zero, _ := NewNoekeon(make([]byte, 16))
+
+	for _, v := range noekeonTestVectors {
+		var wk, want, got [16]byte
+
+		zero.Encrypt(wk[:], v.key)
+		d, _ := NewNoekeon(wk[:])
+		d.Encrypt(want[:], v.plain)
+
+		n, err := NewNoekeonIndirect(v.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n.Encrypt(got[:], v.plain)
+
+		if got != want {
+			t.Errorf("noekeon indirect: got %x wanted %x", got, want)
+		}
+
+		if err := VerifyInverse(n, 100); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestNoekeonReset(t *testing.T) {
+
+	v := noekeonTestVectors[2]
+	n, _ := NewNoekeon(v.key)
+	n.Reset()
+
+	if *n != (NoekeonCipher{}) {
+		t.Errorf("Reset left key material behind: %+v", *n)
+	}
+
+	if _, err := NewNoekeon(v.key[:8]); err != KeySizeError(8) {
+		t.Errorf("NewNoekeon(short key)=%v, wanted %v", err, KeySizeError(8))
+	}
+}
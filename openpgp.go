@@ -0,0 +1,61 @@
+package krcrypt
+
+// OpenPGP symmetric algorithm IDs
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+https://tools.ietf.org/html/rfc4880#section-9.2
+https://tools.ietf.org/html/rfc5581 (Camellia)
+
+*/
+
+import (
+	"crypto/cipher"
+	"strconv"
+)
+
+// OpenPGPAlgorithmError is returned for an OpenPGP symmetric algorithm ID
+// that this package has no cipher for.
+type OpenPGPAlgorithmError uint8
+
+func (a OpenPGPAlgorithmError) Error() string {
+	return "krcrypt: unsupported OpenPGP symmetric algorithm " + strconv.Itoa(int(a))
+}
+
+// the OpenPGP symmetric algorithms this package implements, with the key
+// size OpenPGP uses for each
+var openPGPCiphers = map[uint8]struct {
+	klen int
+	ctor func([]byte) (cipher.Block, error)
+}{
+	4:  {16, func(k []byte) (cipher.Block, error) { return NewBlowfish(k) }},
+	10: {32, func(k []byte) (cipher.Block, error) { return NewTwofish(k) }},
+	11: {16, func(k []byte) (cipher.Block, error) { return NewCamellia(k) }},
+	12: {24, func(k []byte) (cipher.Block, error) { return NewCamellia(k) }},
+	13: {32, func(k []byte) (cipher.Block, error) { return NewCamellia(k) }},
+}
+
+// NewOpenPGPCipher returns the cipher for an OpenPGP symmetric algorithm ID,
+// for decrypting messages from a PGP implementation: Blowfish (4), Twofish
+// (10), or Camellia-128, -192 or -256 (11, 12 and 13).  The key must be the
+// size OpenPGP uses for that algorithm: 16 bytes for Blowfish and 32 for
+// Twofish.  Any other ID, including IDEA (1) and CAST5 (3), which this
+// package doesn't implement, gives an OpenPGPAlgorithmError.  SEED, ARIA and
+// HIGHT have no OpenPGP IDs.
+func NewOpenPGPCipher(algo uint8, key []byte) (cipher.Block, error) {
+
+	c, ok := openPGPCiphers[algo]
+	if !ok {
+		return nil, OpenPGPAlgorithmError(algo)
+	}
+
+	if klen := len(key); klen != c.klen {
+		return nil, KeySizeError(klen)
+	}
+
+	return c.ctor(key)
+}
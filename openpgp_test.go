@@ -0,0 +1,60 @@
+package krcrypt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestOpenPGPCipher(t *testing.T) {
+
+	for _, tt := range []struct {
+		algo   uint8
+		key    string
+		plain  string
+		cipher string
+	}{
+		// Blowfish, Eric Young's set_key vectors
+		{4, "f0e1d2c3b4a5968778695a4b3c2d1e0f", "fedcba9876543210", "93142887ee3be15c"},
+		// Twofish, the 256-bit all-zero vector from the paper
+		{10, "0000000000000000000000000000000000000000000000000000000000000000", "00000000000000000000000000000000", "57ff739d4dc92c1bd7fc01700cc8216f"},
+		// Camellia, RFC 3713 appendix A
+		{11, "0123456789abcdeffedcba9876543210", "0123456789abcdeffedcba9876543210", "67673138549669730857065648eabe43"},
+		{12, "0123456789abcdeffedcba98765432100011223344556677", "0123456789abcdeffedcba9876543210", "b4993401b3e996f84ee5cee7d79b09b9"},
+		{13, "0123456789abcdeffedcba987654321000112233445566778899aabbccddeeff", "0123456789abcdeffedcba9876543210", "9acc237dff16d76c20ef7c919e3a7509"},
+	} {
+		key, _ := hex.DecodeString(tt.key)
+		plain, _ := hex.DecodeString(tt.plain)
+		want, _ := hex.DecodeString(tt.cipher)
+
+		b, err := NewOpenPGPCipher(tt.algo, key)
+		if err != nil {
+			t.Fatalf("NewOpenPGPCipher(%d): %v", tt.algo, err)
+		}
+
+		got := make([]byte, len(plain))
+		b.Encrypt(got, plain)
+		if !bytes.Equal(got, want) {
+			t.Errorf("NewOpenPGPCipher(%d) encrypts to %x, wanted %x", tt.algo, got, want)
+		}
+
+		b.Decrypt(got, got)
+		if !bytes.Equal(got, plain) {
+			t.Errorf("NewOpenPGPCipher(%d) decrypts to %x, wanted %x", tt.algo, got, plain)
+		}
+
+		// only the size OpenPGP uses for the ID is accepted, even where the
+		// cipher itself takes others
+		for _, klen := range []int{len(key) - 1, len(key) + 1, len(key) - 8, len(key) + 8} {
+			if _, err := NewOpenPGPCipher(tt.algo, make([]byte, klen)); err != KeySizeError(klen) {
+				t.Errorf("NewOpenPGPCipher(%d, %d-byte key)=%v, wanted %v", tt.algo, klen, err, KeySizeError(klen))
+			}
+		}
+	}
+
+	for _, algo := range []uint8{0, 1, 2, 3, 7, 9, 14, 255} {
+		if _, err := NewOpenPGPCipher(algo, make([]byte, 16)); err != OpenPGPAlgorithmError(algo) {
+			t.Errorf("NewOpenPGPCipher(%d)=%v, wanted %v", algo, err, OpenPGPAlgorithmError(algo))
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package krcrypt
+
+// Block padding schemes
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"math/bits"
+)
+
+// ErrPadding is returned when a decrypted message does not end in valid padding.
+var ErrPadding = errors.New("krcrypt: invalid padding")
+
+// pkcs7Pad appends PKCS#7 padding to b, bringing it to a multiple of blockSize.
+// A full block of padding is added if b is already aligned.
+func pkcs7Pad(b []byte, blockSize int) []byte {
+	n := blockSize - len(b)%blockSize
+	for i := 0; i < n; i++ {
+		b = append(b, byte(n))
+	}
+	return b
+}
+
+// pkcs7Unpad strips the PKCS#7 padding from b, which must be a non-empty
+// multiple of blockSize.  Whether the padding is valid, and how long it is,
+// are worked out without branching on the contents of the last block, so a
+// decryptor doesn't become a padding oracle through its timing.
+func pkcs7Unpad(b []byte, blockSize int) ([]byte, error) {
+
+	if len(b) == 0 || len(b)%blockSize != 0 {
+		return nil, ErrPadding
+	}
+
+	last := b[len(b)-blockSize:]
+	n := int(last[blockSize-1])
+
+	good := subtle.ConstantTimeLessOrEq(1, n) & subtle.ConstantTimeLessOrEq(n, blockSize)
+	for i := 0; i < blockSize; i++ {
+		inPadding := subtle.ConstantTimeLessOrEq(blockSize-i, n)
+		matches := subtle.ConstantTimeByteEq(last[i], byte(n))
+		good &= matches | (inPadding ^ 1)
+	}
+
+	if good != 1 {
+		return nil, ErrPadding
+	}
+
+	return b[:len(b)-n], nil
+}
+
+// PadMe pads data for length hiding with the PADMÉ scheme, returning a new
+// slice holding the 8-byte big-endian length of data, then data, then zeros.
+// The total length is rounded up so that its binary representation ends in
+// roughly as many zeros as there are significant bits in the number of bits
+// of the length.  That leaks only O(log log n) bits about the original
+// length, for at most 12% overhead.  Encrypt the result with an AEAD, such as
+// the one from NewGCM, so the padding is authenticated along with the data.
+//
+// See https://lbarman.ch/blog/padme/ and "Reducing Metadata Leakage from
+// Encrypted Files and Communication with PURBs" by Nikitin et al.
+func PadMe(data []byte) []byte {
+	b := make([]byte, padmeLen(8+len(data)))
+	binary.BigEndian.PutUint64(b, uint64(len(data)))
+	copy(b[8:], data)
+	return b
+}
+
+// UnpadMe returns the original data from a message padded by PadMe, or
+// ErrPadding if it isn't one.  The result is a subslice of b.
+func UnpadMe(b []byte) ([]byte, error) {
+
+	if len(b) < 8 {
+		return nil, ErrPadding
+	}
+
+	n := binary.BigEndian.Uint64(b)
+	if n > uint64(len(b)-8) || padmeLen(8+int(n)) != len(b) {
+		return nil, ErrPadding
+	}
+
+	for _, p := range b[8+n:] {
+		if p != 0 {
+			return nil, ErrPadding
+		}
+	}
+
+	return b[8 : 8+n], nil
+}
+
+// padmeLen rounds l up to the next PADMÉ length
+func padmeLen(l int) int {
+
+	if l < 2 {
+		return l
+	}
+
+	e := bits.Len(uint(l)) - 1 // floor(log2(l))
+	s := bits.Len(uint(e))     // floor(log2(e)) + 1
+	mask := 1<<uint(e-s) - 1
+
+	return (l + mask) &^ mask
+}
@@ -0,0 +1,105 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPadmeLen(t *testing.T) {
+
+	var tests = []struct {
+		l, want int
+	}{
+		{1, 1},
+		{8, 8},
+		{9, 10},
+		{17, 18},
+		{100, 104},
+		{1000, 1024},
+		{1025, 1088},
+		{100000, 100352},
+		{1 << 20, 1 << 20},
+		{1<<20 + 1, 1<<20 + 1<<15},
+	}
+
+	for _, tt := range tests {
+		if got := padmeLen(tt.l); got != tt.want {
+			t.Errorf("padmeLen(%d)=%d, wanted %d", tt.l, got, tt.want)
+		}
+	}
+
+	// the overhead is never more than 12%
+	for l := 2; l < 1<<16; l++ {
+		p := padmeLen(l)
+		if p < l || float64(p-l) > 0.12*float64(l) {
+			t.Fatalf("padmeLen(%d)=%d", l, p)
+		}
+	}
+}
+
+func TestPadMe(t *testing.T) {
+
+	for _, n := range []int{0, 1, 15, 16, 100, 1000, 5000} {
+		data := bytes.Repeat([]byte{0xa5}, n)
+
+		p := PadMe(data)
+		if len(p) != padmeLen(8+n) {
+			t.Errorf("len(PadMe(%d bytes))=%d, wanted %d", n, len(p), padmeLen(8+n))
+		}
+
+		got, err := UnpadMe(p)
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("UnpadMe(PadMe(%d bytes))=(%d bytes, %v)", n, len(got), err)
+		}
+	}
+
+	p := PadMe(make([]byte, 100))
+	for _, bad := range [][]byte{
+		nil,
+		p[:7],
+		p[:len(p)-1],
+		append(p[:len(p):len(p)], 0),
+	} {
+		if _, err := UnpadMe(bad); err != ErrPadding {
+			t.Errorf("UnpadMe(%d bytes)=%v, wanted %v", len(bad), err, ErrPadding)
+		}
+	}
+
+	p[len(p)-1] = 1
+	if _, err := UnpadMe(p); err != ErrPadding {
+		t.Errorf("UnpadMe(nonzero padding)=%v, wanted %v", err, ErrPadding)
+	}
+}
+
+func TestPKCS7Unpad(t *testing.T) {
+
+	var tests = []struct {
+		in   string
+		out  string
+		fail bool
+	}{
+		{"abcd\x04\x04\x04\x04", "abcd", false},
+		{"abcdefg\x01", "abcdefg", false},
+		{"\x08\x08\x08\x08\x08\x08\x08\x08", "", false},
+		{"abcdefgh\x08\x08\x08\x08\x08\x08\x08\x08", "abcdefgh", false},
+		{"abcdefg\x00", "", true},
+		{"abcdefg\x09", "", true},
+		{"abcd\x03\x04\x04\x04", "", true},
+		{"abc\x05\x04\x04\x04\x04", "abc\x05", false},
+		{"abcdefg", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := pkcs7Unpad([]byte(tt.in), 8)
+		if tt.fail {
+			if err != ErrPadding {
+				t.Errorf("pkcs7Unpad(%q) err=%v, wanted ErrPadding", tt.in, err)
+			}
+			continue
+		}
+		if err != nil || string(got) != tt.out {
+			t.Errorf("pkcs7Unpad(%q)=%q, %v, wanted %q", tt.in, got, err, tt.out)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package krcrypt
+
+// PBKDF2 with SEED-CMAC
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+https://tools.ietf.org/html/rfc8018#section-5.2
+
+*/
+
+import "encoding/binary"
+
+// PBKDF2 derives a keyLen-byte key from the password and salt with PBKDF2 from
+// PKCS #5, using SEED-CMAC as the pseudo-random function in place of HMAC.
+// The password may be any length: as in RFC 4615's AES-CMAC-PRF-128, it is
+// first reduced to a SEED key with CMAC under the all-zero key unless it is
+// exactly 16 bytes.  An iter less than 1 is treated as 1.
+//
+// This is for deployments limited to SEED.  Where a hash function is
+// available, PBKDF2 with HMAC-SHA-256 (golang.org/x/crypto/pbkdf2) is better
+// studied and should be preferred, and a memory-hard function such as scrypt
+// or Argon2 is better still for passwords.
+func PBKDF2(password, salt []byte, iter, keyLen int) []byte {
+
+	prf := newCMACPRF(NewSEED, password)
+
+	dk := make([]byte, 0, keyLen+15)
+
+	var ctr [4]byte
+	var u, t [16]byte
+
+	for block := uint32(1); len(dk) < keyLen; block++ {
+
+		// U_1 = PRF(P, S || INT(i))
+		binary.BigEndian.PutUint32(ctr[:], block)
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(ctr[:])
+		prf.Sum(u[:0])
+		t = u
+
+		// U_j = PRF(P, U_{j-1}), and T_i is their xor
+		for n := 2; n <= iter; n++ {
+			prf.Reset()
+			prf.Write(u[:])
+			prf.Sum(u[:0])
+			xorslice(t[:], t[:], u[:])
+		}
+
+		dk = append(dk, t[:]...)
+	}
+
+	return dk[:keyLen]
+}
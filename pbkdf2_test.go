@@ -0,0 +1,57 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPBKDF2(t *testing.T) {
+
+	password := []byte("password")
+	salt := []byte("salt")
+
+	for _, keyLen := range []int{1, 16, 20, 32, 40, 64} {
+		dk := PBKDF2(password, salt, 100, keyLen)
+		if len(dk) != keyLen {
+			t.Errorf("PBKDF2 returned %d bytes, wanted %d", len(dk), keyLen)
+		}
+
+		// shorter keys are prefixes of longer ones
+		if long := PBKDF2(password, salt, 100, 64); !bytes.Equal(dk, long[:keyLen]) {
+			t.Errorf("PBKDF2(%d) isn't a prefix of PBKDF2(64)", keyLen)
+		}
+	}
+
+	// the blocks of a multi-block key differ
+	dk := PBKDF2(password, salt, 1, 48)
+	if bytes.Equal(dk[:16], dk[16:32]) || bytes.Equal(dk[16:32], dk[32:]) {
+		t.Errorf("PBKDF2 blocks repeat: %x", dk)
+	}
+
+	// check one iteration by hand: T_1 = PRF(P, S || 00000001)
+	prf := newCMACPRF(NewSEED, password)
+	prf.Write(append(salt, 0, 0, 0, 1))
+	u1 := prf.Sum(nil)
+	if !bytes.Equal(dk[:16], u1) {
+		t.Errorf("PBKDF2 first block=%x, wanted %x", dk[:16], u1)
+	}
+
+	// and two: T_1 = U_1 ^ PRF(P, U_1)
+	prf.Reset()
+	prf.Write(u1)
+	u2 := prf.Sum(nil)
+	xorslice(u2, u2, u1)
+	if got := PBKDF2(password, salt, 2, 16); !bytes.Equal(got, u2) {
+		t.Errorf("PBKDF2(iter=2)=%x, wanted %x", got, u2)
+	}
+
+	for _, other := range [][]byte{
+		PBKDF2([]byte("passwore"), salt, 100, 16),
+		PBKDF2(password, []byte("salu"), 100, 16),
+		PBKDF2(password, salt, 101, 16),
+	} {
+		if bytes.Equal(other, PBKDF2(password, salt, 100, 16)) {
+			t.Errorf("PBKDF2 ignored a change in its inputs")
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package krcrypt
+
+// PMAC, a parallelizable MAC, over SEED
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+http://web.cs.ucdavis.edu/~rogaway/ocb/pmac.htm
+http://web.cs.ucdavis.edu/~rogaway/ocb/pmac-bak.htm
+
+*/
+
+import (
+	"crypto/cipher"
+	"hash"
+	"math/bits"
+	"runtime"
+	"sync"
+)
+
+// PMAC is an instance of the PMAC1 message authentication code.  Unlike CMAC,
+// each message block is encrypted independently of the others, so the
+// implementation is free to spread the work for large messages across several
+// goroutines; the tag does not depend on how the work was divided.
+type PMAC struct {
+	block  cipher.Block
+	l      [64][16]byte // l[i] = L·x^i, for the offsets
+	linv   [16]byte     // L·x^-1, for a full final block
+	offset [16]byte
+	sum    [16]byte
+	buf    [16]byte // the last block written, which may be the final one
+	nbuf   int
+	ctr    uint64 // blocks processed so far
+}
+
+var _ hash.Hash = (*PMAC)(nil)
+
+// NewPMAC returns a PMAC computing a 16-byte tag using SEED with the given key.
+func NewPMAC(key []byte) (*PMAC, error) {
+	block, err := NewSEED(key)
+	if err != nil {
+		return nil, err
+	}
+	return newPMAC(block), nil
+}
+
+// newPMAC returns a PMAC over any block cipher with a 16-byte block size
+func newPMAC(block cipher.Block) *PMAC {
+
+	p := &PMAC{block: block}
+
+	var zero [16]byte
+	block.Encrypt(p.l[0][:], zero[:])
+	for i := 1; i < len(p.l); i++ {
+		p.l[i] = cmacField.Double(p.l[i-1])
+	}
+	p.linv = cmacField.Halve(p.l[0])
+
+	return p
+}
+
+func (p *PMAC) Size() int      { return 16 }
+func (p *PMAC) BlockSize() int { return 16 }
+
+// Reset clears the state so a new message can be authenticated with the same key.
+func (p *PMAC) Reset() {
+	p.offset = [16]byte{}
+	p.sum = [16]byte{}
+	p.nbuf = 0
+	p.ctr = 0
+}
+
+// Write adds more data to the running MAC.  It never returns an error.
+func (p *PMAC) Write(m []byte) (int, error) {
+
+	n := len(m)
+
+	for len(m) > 0 {
+		// only process the buffered block once we know it isn't the last one
+		if p.nbuf == 16 {
+			p.processBlock()
+		}
+		c := copy(p.buf[p.nbuf:], m)
+		p.nbuf += c
+		m = m[c:]
+	}
+
+	return n, nil
+}
+
+// processBlock folds the full, non-final block in p.buf into the sum
+func (p *PMAC) processBlock() {
+
+	p.ctr++
+	xorslice(p.offset[:], p.offset[:], p.l[bits.TrailingZeros64(p.ctr)][:])
+
+	var y [16]byte
+	xorslice(y[:], p.buf[:], p.offset[:])
+	p.block.Encrypt(y[:], y[:])
+	xorslice(p.sum[:], p.sum[:], y[:])
+
+	p.nbuf = 0
+}
+
+// Sum appends the tag for the data written so far to b.  It does not change
+// the underlying state, so more data can be written afterwards.
+func (p *PMAC) Sum(b []byte) []byte {
+
+	sum := p.sum
+
+	if p.nbuf == 16 {
+		xorslice(sum[:], sum[:], p.buf[:])
+		xorslice(sum[:], sum[:], p.linv[:])
+	} else {
+		xorslice(sum[:p.nbuf], sum[:p.nbuf], p.buf[:p.nbuf])
+		sum[p.nbuf] ^= 0x80
+	}
+
+	p.block.Encrypt(sum[:], sum[:])
+	return append(b, sum[:]...)
+}
+
+// how many blocks each goroutine of ParallelMAC handles, at least
+var parallelMACChunk = 4096
+
+// ParallelMAC returns the SEED-PMAC tag of data, the same tag NewPMAC would
+// give, spreading the block encryptions over up to GOMAXPROCS goroutines.
+// It is for large buffers already in memory; small ones are done serially.
+// The tag is PMAC1, so it does not interoperate with CMAC: a verifier needs
+// NewPMAC or ParallelMAC, never NewCMAC.
+func ParallelMAC(key, data []byte) ([]byte, error) {
+
+	block, err := NewSEED(key)
+	if err != nil {
+		return nil, err
+	}
+	p := newPMAC(block)
+
+	// every full block but the last goes through processBlock
+	var nblocks int
+	if len(data) > 0 {
+		nblocks = (len(data) - 1) / 16
+	}
+
+	workers := runtime.GOMAXPROCS(0)
+	if w := nblocks / parallelMACChunk; w < workers {
+		workers = w
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	sums := make([][16]byte, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		start, end := nblocks*w/workers, nblocks*(w+1)/workers
+		wg.Add(1)
+		go func(sum *[16]byte) {
+			defer wg.Done()
+			p.sumBlocks(sum, data[16*start:16*end], uint64(start))
+		}(&sums[w])
+	}
+	wg.Wait()
+
+	for w := range sums {
+		xorslice(p.sum[:], p.sum[:], sums[w][:])
+	}
+	p.nbuf = copy(p.buf[:], data[16*nblocks:])
+
+	return p.Sum(nil), nil
+}
+
+// sumBlocks folds the full blocks in m, which follow ctr earlier blocks of
+// the message, into sum.  It only reads p, so several can run at once.
+func (p *PMAC) sumBlocks(sum *[16]byte, m []byte, ctr uint64) {
+
+	// the offset after ctr blocks is L times the Gray code of ctr
+	var offset [16]byte
+	for g := ctr ^ ctr>>1; g != 0; g &= g - 1 {
+		xorslice(offset[:], offset[:], p.l[bits.TrailingZeros64(g)][:])
+	}
+
+	var y [16]byte
+	for ; len(m) > 0; m = m[16:] {
+		ctr++
+		xorslice(offset[:], offset[:], p.l[bits.TrailingZeros64(ctr)][:])
+		xorslice(y[:], m[:16], offset[:])
+		p.block.Encrypt(y[:], y[:])
+		xorslice(sum[:], sum[:], y[:])
+	}
+}
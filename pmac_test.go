@@ -0,0 +1,130 @@
+package krcrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"runtime"
+	"testing"
+)
+
+// PMAC-AES-128 reference vectors, as published with the miscreant library.
+// The key is 000102..0f, and the message is the first 'len' bytes of 00 01 02 ...,
+// except for the last vector which is 1000 zero bytes.
+var pmacTestVectors = []struct {
+	len int
+	tag string
+}{
+	{0, "4399572cd6ea5341b8d35876a7098af7"},
+	{3, "256ba5193c1b991b4df0c51f388a9e27"},
+	{16, "ebbd822fa458daf6dfdad7c27da76338"},
+	{20, "0412ca150bbf79058d8c75a58c993f55"},
+	{32, "e97ac04e9e5e3399ce5355cd7407bc75"},
+	{34, "5cba7d5eb24f7c86ccc54604e53d5512"},
+	{-1000, "c2c9fa1d9985f6f0d2aff915a0e8d910"},
+}
+
+func TestPMACVectors(t *testing.T) {
+
+	block, _ := aes.NewCipher([]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f})
+
+	for _, v := range pmacTestVectors {
+		var m []byte
+		if v.len < 0 {
+			m = make([]byte, -v.len)
+		} else {
+			m = make([]byte, v.len)
+			for i := range m {
+				m[i] = byte(i)
+			}
+		}
+
+		p := newPMAC(block)
+		p.Write(m)
+		if got := hex.EncodeToString(p.Sum(nil)); got != v.tag {
+			t.Errorf("pmac(len=%d)=%s, wanted %s", len(m), got, v.tag)
+		}
+	}
+}
+
+func TestPMACIncremental(t *testing.T) {
+
+	key := make([]byte, 16)
+	m := make([]byte, 100)
+	for i := range m {
+		m[i] = byte(i)
+	}
+
+	p, err := NewPMAC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Write(m)
+	want := p.Sum(nil)
+
+	// Sum must not disturb the state
+	if again := p.Sum(nil); !bytes.Equal(again, want) {
+		t.Errorf("second Sum=%x, wanted %x", again, want)
+	}
+
+	for _, split := range []int{1, 15, 16, 17, 32, 99} {
+		p.Reset()
+		for b := m; len(b) > 0; {
+			n := split
+			if n > len(b) {
+				n = len(b)
+			}
+			p.Write(b[:n])
+			b = b[n:]
+		}
+		if got := p.Sum([]byte("prefix")); !bytes.Equal(got[6:], want) || string(got[:6]) != "prefix" {
+			t.Errorf("pmac(writes of %d)=%x, wanted %x", split, got, want)
+		}
+	}
+}
+
+func TestGFHalve(t *testing.T) {
+
+	x := [16]byte{0x80, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f}
+	for i := 0; i < 300; i++ {
+		if y := cmacField.Halve(cmacField.Double(x)); y != x {
+			t.Fatalf("halve(double(%x))=%x", x, y)
+		}
+		x = cmacField.Double(x)
+	}
+}
+
+func TestParallelMAC(t *testing.T) {
+
+	key := make([]byte, 16)
+	m := make([]byte, 16*1000+5)
+	for i := range m {
+		m[i] = byte(i * 7)
+	}
+
+	defer func(c int) { parallelMACChunk = c }(parallelMACChunk)
+	parallelMACChunk = 10
+
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+
+	for _, n := range []int{0, 1, 16, 17, 32, 160, 16 * 999, 16 * 1000, len(m)} {
+		p, _ := NewPMAC(key)
+		p.Write(m[:n])
+		want := p.Sum(nil)
+
+		for _, procs := range []int{1, 2, 3, 8} {
+			runtime.GOMAXPROCS(procs)
+			got, err := ParallelMAC(key, m[:n])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("ParallelMAC(len=%d, GOMAXPROCS=%d)=%x, wanted %x", n, procs, got, want)
+			}
+		}
+	}
+
+	if _, err := ParallelMAC(make([]byte, 15), m); err != KeySizeError(15) {
+		t.Errorf("ParallelMAC(15-byte key) err=%v, wanted KeySizeError(15)", err)
+	}
+}
@@ -0,0 +1,36 @@
+package krcrypt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Ciphertext must not depend on the host byte order.  Host-endian word loads
+// need unsafe or binary.NativeEndian, so refuse both outright; a faster path
+// that wants them must come with a portable fallback and build-tagged tests.
+func TestNoHostEndianLoads(t *testing.T) {
+
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		src, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, bad := range []string{`"unsafe"`, "NativeEndian"} {
+			if strings.Contains(string(src), bad) {
+				t.Errorf("%s uses %s", name, bad)
+			}
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package krcrypt
+
+// A pseudo-random function interface
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+// A PRF is a keyed pseudo-random function: the same input always gives the
+// same output under the same key, and without the key its outputs can't be
+// told from random.  Protocol code can depend on it rather than on CMAC, so
+// the function behind it can be changed.
+type PRF interface {
+	Evaluate(input []byte) []byte
+}
+
+// seedPRF is a PRF computing SEED-CMAC.  mac is kept freshly reset and
+// copied for each input, which is safe since it holds only arrays and the
+// read-only cipher, so Evaluate may be called concurrently.
+type seedPRF struct {
+	mac CMAC
+}
+
+// NewSEEDPRF returns a PRF with a 16-byte output, computing SEED-CMAC under
+// the 16-byte key.
+func NewSEEDPRF(key []byte) (PRF, error) {
+	mac, err := NewCMAC(key)
+	if err != nil {
+		return nil, err
+	}
+	return &seedPRF{mac: *mac}, nil
+}
+
+func (p *seedPRF) Evaluate(input []byte) []byte {
+	mac := p.mac
+	mac.Write(input)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,44 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSEEDPRF(t *testing.T) {
+
+	key := []byte("0123456789abcdef")
+
+	p, err := NewSEEDPRF(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := p.Evaluate([]byte("input"))
+	if len(a) != 16 {
+		t.Fatalf("Evaluate returned %d bytes, wanted 16", len(a))
+	}
+
+	if b := p.Evaluate([]byte("input")); !bytes.Equal(a, b) {
+		t.Errorf("Evaluate not deterministic: %x then %x", a, b)
+	}
+
+	mac, _ := NewCMAC(key)
+	mac.Write([]byte("input"))
+	if want := mac.Sum(nil); !bytes.Equal(a, want) {
+		t.Errorf("Evaluate=%x, wanted the SEED-CMAC %x", a, want)
+	}
+
+	if b := p.Evaluate([]byte("inpuu")); bytes.Equal(a, b) {
+		t.Errorf("Evaluate ignored its input")
+	}
+
+	q, _ := NewSEEDPRF([]byte("0123456789abcdeg"))
+	if b := q.Evaluate([]byte("input")); bytes.Equal(a, b) {
+		t.Errorf("Evaluate gave the same output under different keys")
+	}
+
+	if _, err := NewSEEDPRF(key[:8]); err != KeySizeError(8) {
+		t.Errorf("NewSEEDPRF(short key)=%v, wanted %v", err, KeySizeError(8))
+	}
+}
@@ -0,0 +1,62 @@
+package krcrypt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// from RFC 2268, section 5
+var rc2TestVectors = []struct {
+	key    string
+	t1     int
+	plain  string
+	cipher string
+}{
+	{"0000000000000000", 63, "0000000000000000", "ebb773f993278eff"},
+	{"ffffffffffffffff", 64, "ffffffffffffffff", "278b27e42e2f0d49"},
+	{"3000000000000000", 64, "1000000000000001", "30649edf9be7d2c2"},
+	{"88", 64, "0000000000000000", "61a8a244adacccf0"},
+	{"88bca90e90875a", 64, "0000000000000000", "6ccf4308974c267f"},
+	{"88bca90e90875a7f0f79c384627bafb2", 64, "0000000000000000", "1a807d272bbe5db1"},
+	{"88bca90e90875a7f0f79c384627bafb2", 128, "0000000000000000", "2269552ab0f85ca6"},
+	{"88bca90e90875a7f0f79c384627bafb216f80a6f85920584c42fceb0be255daf1e", 129, "0000000000000000", "5b78d3a43dfff1f1"},
+}
+
+func TestRC2(t *testing.T) {
+
+	for _, v := range rc2TestVectors {
+		key, _ := hex.DecodeString(v.key)
+		plain, _ := hex.DecodeString(v.plain)
+		want, _ := hex.DecodeString(v.cipher)
+
+		c, err := NewRC2(key, v.t1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var b [8]byte
+		c.Encrypt(b[:], plain)
+		if !bytes.Equal(b[:], want) {
+			t.Errorf("rc2 encrypt failed: got %#v wanted %#v\n", b, want)
+		}
+
+		c.Decrypt(b[:], b[:])
+		if !bytes.Equal(b[:], plain) {
+			t.Errorf("rc2 decrypt failed: got %#v wanted %#v\n", b, plain)
+		}
+	}
+
+	if _, err := NewRC2(nil, 64); err != KeySizeError(0) {
+		t.Errorf("NewRC2(empty key)=%v, wanted %v", err, KeySizeError(0))
+	}
+	if _, err := NewRC2(make([]byte, 129), 64); err != KeySizeError(129) {
+		t.Errorf("NewRC2(129-byte key)=%v, wanted %v", err, KeySizeError(129))
+	}
+	if _, err := NewRC2(make([]byte, 16), 0); err != EffectiveKeyBitsError(0) {
+		t.Errorf("NewRC2(t1=0)=%v, wanted %v", err, EffectiveKeyBitsError(0))
+	}
+	if _, err := NewRC2(make([]byte, 16), 1025); err != EffectiveKeyBitsError(1025) {
+		t.Errorf("NewRC2(t1=1025)=%v, wanted %v", err, EffectiveKeyBitsError(1025))
+	}
+}
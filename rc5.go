@@ -0,0 +1,175 @@
+package krcrypt
+
+// The RC5 block cipher
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+http://people.csail.mit.edu/rivest/Rivest-rc5rev.pdf
+https://tools.ietf.org/html/rfc2040
+
+*/
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+)
+
+// An rc5Cipher is an instance of RC5-w/r/b.  Words are held in uint64s and
+// masked down to the word size.
+type rc5Cipher struct {
+	w    uint   // word size in bits
+	mask uint64 // w one bits
+	s    []uint64
+}
+
+// the magic constants for each word size, from e and the golden ratio
+var rc5Magic = map[int]struct{ p, q uint64 }{
+	32: {0xb7e15163, 0x9e3779b9},
+	64: {0xb7e151628aed2a6b, 0x9e3779b97f4a7c15},
+}
+
+// NewRC5 creates and returns a new cipher.Block implementing RC5 with the given
+// word size in bits (32 or 64) and number of rounds (0 to 255).  The block
+// size is two words, and the key may be up to 255 bytes.  RC5-32/12 with a
+// 16-byte key is the variant usually meant by just "RC5".
+func NewRC5(key []byte, wordBits, rounds int) (cipher.Block, error) {
+
+	magic, ok := rc5Magic[wordBits]
+	if !ok {
+		return nil, BlockSizeError(2 * wordBits)
+	}
+
+	if rounds < 0 || rounds > 255 {
+		return nil, RoundsError(rounds)
+	}
+
+	if klen := len(key); klen > 255 {
+		return nil, KeySizeError(klen)
+	}
+
+	c := &rc5Cipher{
+		w:    uint(wordBits),
+		mask: 1<<uint(wordBits) - 1,
+		s:    make([]uint64, 2*rounds+2),
+	}
+	if wordBits == 64 {
+		c.mask = ^uint64(0)
+	}
+
+	// the key as little-endian words, with at least one word
+	u := wordBits / 8
+	l := make([]uint64, (len(key)+u-1)/u)
+	if len(l) == 0 {
+		l = make([]uint64, 1)
+	}
+	for i := len(key) - 1; i >= 0; i-- {
+		l[i/u] = l[i/u]<<8 | uint64(key[i])
+	}
+
+	s := c.s
+	s[0] = magic.p & c.mask
+	for i := 1; i < len(s); i++ {
+		s[i] = (s[i-1] + magic.q) & c.mask
+	}
+
+	n := 3 * len(s)
+	if len(l) > len(s) {
+		n = 3 * len(l)
+	}
+
+	var a, b uint64
+	i, j := 0, 0
+	for k := 0; k < n; k++ {
+		s[i] = c.rotl((s[i]+a+b)&c.mask, 3)
+		a = s[i]
+		l[j] = c.rotl((l[j]+a+b)&c.mask, a+b)
+		b = l[j]
+		i = (i + 1) % len(s)
+		j = (j + 1) % len(l)
+	}
+
+	return c, nil
+}
+
+// Reset zeroes the expanded key table.
+func (c *rc5Cipher) Reset() {
+	for i := range c.s {
+		c.s[i] = 0
+	}
+}
+
+// IsWiped reports whether the expanded key table are all zero.
+func (c *rc5Cipher) IsWiped() bool {
+	for _, s := range c.s {
+		if s != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *rc5Cipher) BlockSize() int { return int(c.w / 4) }
+
+// rotations by the low bits of r, which need not be reduced
+func (c *rc5Cipher) rotl(x, r uint64) uint64 {
+	n := uint(r) & (c.w - 1)
+	return (x<<n | x>>(c.w-n)) & c.mask
+}
+
+func (c *rc5Cipher) rotr(x, r uint64) uint64 {
+	n := uint(r) & (c.w - 1)
+	return (x>>n | x<<(c.w-n)) & c.mask
+}
+
+// load a little-endian word
+func (c *rc5Cipher) load(b []byte) uint64 {
+	if c.w == 32 {
+		return uint64(binary.LittleEndian.Uint32(b))
+	}
+	return binary.LittleEndian.Uint64(b)
+}
+
+// store a little-endian word
+func (c *rc5Cipher) store(b []byte, x uint64) {
+	if c.w == 32 {
+		binary.LittleEndian.PutUint32(b, uint32(x))
+		return
+	}
+	binary.LittleEndian.PutUint64(b, x)
+}
+
+// Encrypt encrypts the block in src and stores the resulting ciphertext in dst.
+func (c *rc5Cipher) Encrypt(dst, src []byte) {
+
+	wb := int(c.w / 8)
+	a := (c.load(src) + c.s[0]) & c.mask
+	b := (c.load(src[wb:]) + c.s[1]) & c.mask
+
+	for i := 2; i < len(c.s); i += 2 {
+		a = (c.rotl(a^b, b) + c.s[i]) & c.mask
+		b = (c.rotl(b^a, a) + c.s[i+1]) & c.mask
+	}
+
+	c.store(dst, a)
+	c.store(dst[wb:], b)
+}
+
+// Decrypt decrypts the block in src and stores the resulting plaintext in dst.
+func (c *rc5Cipher) Decrypt(dst, src []byte) {
+
+	wb := int(c.w / 8)
+	a := c.load(src)
+	b := c.load(src[wb:])
+
+	for i := len(c.s) - 2; i >= 2; i -= 2 {
+		b = c.rotr((b-c.s[i+1])&c.mask, a) ^ a
+		a = c.rotr((a-c.s[i])&c.mask, b) ^ b
+	}
+
+	c.store(dst, (a-c.s[0])&c.mask)
+	c.store(dst[wb:], (b-c.s[1])&c.mask)
+}
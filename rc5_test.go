@@ -0,0 +1,101 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+// the RC5-32/12/16 examples from Rivest's paper, each using the previous
+// ciphertext as its plaintext
+var rc5TestVectors = []struct {
+	key    []byte
+	plain  []byte
+	cipher []byte
+}{
+	{
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0x21, 0xa5, 0xdb, 0xee, 0x15, 0x4b, 0x8f, 0x6d},
+	},
+	{
+		[]byte{0x91, 0x5f, 0x46, 0x19, 0xbe, 0x41, 0xb2, 0x51, 0x63, 0x55, 0xa5, 0x01, 0x10, 0xa9, 0xce, 0x91},
+		[]byte{0x21, 0xa5, 0xdb, 0xee, 0x15, 0x4b, 0x8f, 0x6d},
+		[]byte{0xf7, 0xc0, 0x13, 0xac, 0x5b, 0x2b, 0x89, 0x52},
+	},
+	{
+		[]byte{0x78, 0x33, 0x48, 0xe7, 0x5a, 0xeb, 0x0f, 0x2f, 0xd7, 0xb1, 0x69, 0xbb, 0x8d, 0xc1, 0x67, 0x87},
+		[]byte{0xf7, 0xc0, 0x13, 0xac, 0x5b, 0x2b, 0x89, 0x52},
+		[]byte{0x2f, 0x42, 0xb3, 0xb7, 0x03, 0x69, 0xfc, 0x92},
+	},
+	{
+		[]byte{0xdc, 0x49, 0xdb, 0x13, 0x75, 0xa5, 0x58, 0x4f, 0x64, 0x85, 0xb4, 0x13, 0xb5, 0xf1, 0x2b, 0xaf},
+		[]byte{0x2f, 0x42, 0xb3, 0xb7, 0x03, 0x69, 0xfc, 0x92},
+		[]byte{0x65, 0xc1, 0x78, 0xb2, 0x84, 0xd1, 0x97, 0xcc},
+	},
+	{
+		[]byte{0x52, 0x69, 0xf1, 0x49, 0xd4, 0x1b, 0xa0, 0x15, 0x24, 0x97, 0x57, 0x4d, 0x7f, 0x15, 0x31, 0x25},
+		[]byte{0x65, 0xc1, 0x78, 0xb2, 0x84, 0xd1, 0x97, 0xcc},
+		[]byte{0xeb, 0x44, 0xe4, 0x15, 0xda, 0x31, 0x98, 0x24},
+	},
+}
+
+func TestRC5Encrypt(t *testing.T) {
+
+	for _, v := range rc5TestVectors {
+		r, err := NewRC5(v.key, 32, 12)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var c, p [8]byte
+
+		r.Encrypt(c[:], v.plain)
+
+		if !bytes.Equal(v.cipher, c[:]) {
+			t.Errorf("rc5 encrypt failed: got %#v wanted %#v\n", c, v.cipher)
+		}
+
+		r.Decrypt(p[:], c[:])
+
+		if !bytes.Equal(v.plain, p[:]) {
+			t.Errorf("rc5 decrypt failed: got %#v wanted %#v\n", p, v.plain)
+		}
+	}
+}
+
+func TestRC5Params(t *testing.T) {
+
+	pt := []byte("sixteen byte blk")
+
+	for _, w := range []int{32, 64} {
+		for _, rounds := range []int{0, 1, 12, 16, 20, 255} {
+			for _, klen := range []int{0, 1, 5, 16, 255} {
+				r, err := NewRC5(make([]byte, klen), w, rounds)
+				if err != nil {
+					t.Fatalf("NewRC5(%d, %d, %d): %v", klen, w, rounds, err)
+				}
+				bs := r.BlockSize()
+				if bs != w/4 {
+					t.Fatalf("RC5-%d BlockSize()=%d", w, bs)
+				}
+				c := make([]byte, bs)
+				p := make([]byte, bs)
+				r.Encrypt(c, pt[:bs])
+				r.Decrypt(p, c)
+				if !bytes.Equal(p, pt[:bs]) {
+					t.Errorf("RC5-%d/%d/%d decrypt(encrypt(pt))=%x", w, rounds, klen, p)
+				}
+			}
+		}
+	}
+
+	if _, err := NewRC5(make([]byte, 16), 16, 12); err != BlockSizeError(32) {
+		t.Errorf("NewRC5(w=16)=%v, wanted %v", err, BlockSizeError(32))
+	}
+	if _, err := NewRC5(make([]byte, 16), 32, 256); err != RoundsError(256) {
+		t.Errorf("NewRC5(r=256)=%v, wanted %v", err, RoundsError(256))
+	}
+	if _, err := NewRC5(make([]byte, 256), 32, 12); err != KeySizeError(256) {
+		t.Errorf("NewRC5(256 byte key)=%v, wanted %v", err, KeySizeError(256))
+	}
+}
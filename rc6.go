@@ -0,0 +1,132 @@
+package krcrypt
+
+// The RC6 block cipher
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+http://people.csail.mit.edu/rivest/pubs/RRSY98.pdf
+
+*/
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// An RC6Cipher is an instance of RC6-32/20 encryption using a particular key.
+type RC6Cipher struct {
+	s [2*rc6Rounds + 4]uint32 // the expanded key table
+}
+
+const rc6Rounds = 20
+
+// the magic constants, from e and the golden ratio
+const (
+	rc6P32 = 0xb7e15163
+	rc6Q32 = 0x9e3779b9
+)
+
+// NewRC6 creates and returns a new RC6Cipher.  The key argument should be
+// 16, 24, or 32 bytes.
+func NewRC6(key []byte) (*RC6Cipher, error) {
+
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, KeySizeError(len(key))
+	}
+
+	l := make([]uint32, len(key)/4)
+	for i := range l {
+		l[i] = binary.LittleEndian.Uint32(key[4*i:])
+	}
+
+	c := new(RC6Cipher)
+	s := c.s[:]
+
+	s[0] = rc6P32
+	for i := 1; i < len(s); i++ {
+		s[i] = s[i-1] + rc6Q32
+	}
+
+	var a, b uint32
+	i, j := 0, 0
+	for k := 0; k < 3*len(s); k++ {
+		s[i] = bits.RotateLeft32(s[i]+a+b, 3)
+		a = s[i]
+		l[j] = bits.RotateLeft32(l[j]+a+b, int((a+b)&31))
+		b = l[j]
+		i = (i + 1) % len(s)
+		j = (j + 1) % len(l)
+	}
+
+	return c, nil
+}
+
+// Reset zeroes the expanded key table.
+func (c *RC6Cipher) Reset() {
+	c.s = [2*rc6Rounds + 4]uint32{}
+}
+
+// IsWiped reports whether the expanded key table are all zero.
+func (c *RC6Cipher) IsWiped() bool {
+	return c.s == [2*rc6Rounds + 4]uint32{}
+}
+
+func (c *RC6Cipher) BlockSize() int { return 16 }
+
+// Encrypt encrypts the 16-byte block in src and stores the resulting ciphertext in dst.
+func (c *RC6Cipher) Encrypt(dst, src []byte) {
+
+	a := binary.LittleEndian.Uint32(src[0:])
+	b := binary.LittleEndian.Uint32(src[4:])
+	cc := binary.LittleEndian.Uint32(src[8:])
+	d := binary.LittleEndian.Uint32(src[12:])
+
+	b += c.s[0]
+	d += c.s[1]
+	for i := 1; i <= rc6Rounds; i++ {
+		t := bits.RotateLeft32(b*(2*b+1), 5)
+		u := bits.RotateLeft32(d*(2*d+1), 5)
+		a = bits.RotateLeft32(a^t, int(u&31)) + c.s[2*i]
+		cc = bits.RotateLeft32(cc^u, int(t&31)) + c.s[2*i+1]
+		a, b, cc, d = b, cc, d, a
+	}
+	a += c.s[2*rc6Rounds+2]
+	cc += c.s[2*rc6Rounds+3]
+
+	binary.LittleEndian.PutUint32(dst[0:], a)
+	binary.LittleEndian.PutUint32(dst[4:], b)
+	binary.LittleEndian.PutUint32(dst[8:], cc)
+	binary.LittleEndian.PutUint32(dst[12:], d)
+}
+
+// Decrypt decrypts the 16-byte block in src and stores the resulting plaintext in dst.
+func (c *RC6Cipher) Decrypt(dst, src []byte) {
+
+	a := binary.LittleEndian.Uint32(src[0:])
+	b := binary.LittleEndian.Uint32(src[4:])
+	cc := binary.LittleEndian.Uint32(src[8:])
+	d := binary.LittleEndian.Uint32(src[12:])
+
+	cc -= c.s[2*rc6Rounds+3]
+	a -= c.s[2*rc6Rounds+2]
+	for i := rc6Rounds; i >= 1; i-- {
+		a, b, cc, d = d, a, b, cc
+		u := bits.RotateLeft32(d*(2*d+1), 5)
+		t := bits.RotateLeft32(b*(2*b+1), 5)
+		cc = bits.RotateLeft32(cc-c.s[2*i+1], -int(t&31)) ^ u
+		a = bits.RotateLeft32(a-c.s[2*i], -int(u&31)) ^ t
+	}
+	d -= c.s[1]
+	b -= c.s[0]
+
+	binary.LittleEndian.PutUint32(dst[0:], a)
+	binary.LittleEndian.PutUint32(dst[4:], b)
+	binary.LittleEndian.PutUint32(dst[8:], cc)
+	binary.LittleEndian.PutUint32(dst[12:], d)
+}
@@ -0,0 +1,65 @@
+package krcrypt
+
+// SEED-OFB and SEED-CFB streams that can be resynchronized
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+import "crypto/cipher"
+
+// A ResyncStream is SEED in OFB or CFB mode whose feedback register can be
+// reloaded with a new IV, for radio or UDP links where a lost or corrupted
+// packet desynchronizes the two ends and the sender periodically sends a
+// fresh IV to recover.
+//
+// Every IV passed to Resync must be new: resyncing OFB to an IV that has been
+// used before with the same key repeats the keystream, which gives away the
+// xor of the two plaintexts.
+type ResyncStream struct {
+	b      cipher.Block
+	mode   func(cipher.Block, []byte) cipher.Stream
+	stream cipher.Stream
+}
+
+// NewResyncOFB returns a ResyncStream for SEED in OFB mode with the 16-byte
+// key and iv.  OFB encryption and decryption are the same operation.
+func NewResyncOFB(key, iv []byte) (*ResyncStream, error) {
+	return newResyncStream(key, iv, cipher.NewOFB)
+}
+
+// NewResyncCFB returns a ResyncStream for SEED in CFB mode with the 16-byte
+// key and iv, decrypting if decrypt is set.
+func NewResyncCFB(key, iv []byte, decrypt bool) (*ResyncStream, error) {
+	if decrypt {
+		return newResyncStream(key, iv, cipher.NewCFBDecrypter)
+	}
+	return newResyncStream(key, iv, cipher.NewCFBEncrypter)
+}
+
+func newResyncStream(key, iv []byte, mode func(cipher.Block, []byte) cipher.Stream) (*ResyncStream, error) {
+
+	b, err := NewSEED(key)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &ResyncStream{b: b, mode: mode}
+	if err := r.Resync(iv); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Resync reloads the feedback register with iv, discarding any buffered
+// keystream, so the stream continues exactly as a new one with this IV would.
+func (r *ResyncStream) Resync(iv []byte) error {
+
+	if len(iv) != r.b.BlockSize() {
+		return IVSizeError(len(iv))
+	}
+
+	r.stream = r.mode(r.b, iv)
+	return nil
+}
+
+func (r *ResyncStream) XORKeyStream(dst, src []byte) { r.stream.XORKeyStream(dst, src) }
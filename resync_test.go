@@ -0,0 +1,64 @@
+package krcrypt
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+)
+
+func TestResyncStream(t *testing.T) {
+
+	key := []byte("YELLOW SUBMARINE")
+	iv1 := bytes.Repeat([]byte{1}, 16)
+	iv2 := bytes.Repeat([]byte{2}, 16)
+	data := []byte("The quick brown fox jumps over the lazy dog")
+
+	ctors := []struct {
+		name string
+		new  func(key, iv []byte) (*ResyncStream, error)
+	}{
+		{"OFB", NewResyncOFB},
+		{"CFB", func(key, iv []byte) (*ResyncStream, error) { return NewResyncCFB(key, iv, false) }},
+		{"CFB decrypt", func(key, iv []byte) (*ResyncStream, error) { return NewResyncCFB(key, iv, true) }},
+	}
+
+	for _, c := range ctors {
+		r, err := c.new(key, iv1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// part way through a block, so there's buffered keystream to drop
+		junk := make([]byte, 21)
+		r.XORKeyStream(junk, junk)
+
+		if err := r.Resync(iv2); err != nil {
+			t.Fatal(err)
+		}
+		got := make([]byte, len(data))
+		r.XORKeyStream(got, data)
+
+		fresh, _ := c.new(key, iv2)
+		want := make([]byte, len(data))
+		fresh.XORKeyStream(want, data)
+
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s after Resync=%x, wanted %x", c.name, got, want)
+		}
+
+		if err := r.Resync(iv2[:8]); err != IVSizeError(8) {
+			t.Errorf("%s Resync(short iv)=%v, wanted %v", c.name, err, IVSizeError(8))
+		}
+	}
+
+	// and the modes are the standard ones
+	block, _ := NewSEED(key)
+	r, _ := NewResyncOFB(key, iv1)
+	got := make([]byte, len(data))
+	r.XORKeyStream(got, data)
+	want := make([]byte, len(data))
+	cipher.NewOFB(block, iv1).XORKeyStream(want, data)
+	if !bytes.Equal(got, want) {
+		t.Errorf("ResyncStream OFB=%x, wanted %x", got, want)
+	}
+}
@@ -0,0 +1,253 @@
+package krcrypt
+
+// The SAFER K and SAFER SK block ciphers
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+J. L. Massey, "SAFER K-64: A Byte-Oriented Block-Ciphering Algorithm", FSE 1993
+J. L. Massey, "SAFER K-64: One Year Later", FSE 1994
+ftp://ftp.isi.ee.ethz.ch/pub/simpl/safer.V1.2.tar.Z (reference code, with the SK key schedule)
+
+*/
+
+// A SaferCipher is an instance of SAFER K-64, K-128, SK-64, or SK-128 using a
+// particular key.  Blocks are 8 bytes.
+//
+// This is not SAFER+, the AES candidate used in Bluetooth's E1, E21, and E22,
+// which has a 16-byte block.
+type SaferCipher struct {
+	rounds int
+	k      []byte // 2*rounds+1 subkeys of 8 bytes
+}
+
+// the most rounds the key schedule supports
+const saferMaxRounds = 13
+
+// saferExp[i] = 45^i mod 257, with 256 stored as 0, and saferLog is its inverse
+var saferExp, saferLog [256]byte
+
+func init() {
+	x := 1
+	for i := 0; i < 256; i++ {
+		saferExp[i] = byte(x)
+		saferLog[byte(x)] = byte(i)
+		x = x * 45 % 257
+	}
+}
+
+// NewSafer creates and returns a new SaferCipher with the strengthened (SK)
+// key schedule, which fixes a weakness Knudsen found in the original.  The key
+// must be 8 bytes for SAFER SK-64 or 16 bytes for SAFER SK-128, and rounds
+// must be 1 to 13.  The recommended numbers of rounds are 8 for SK-64 and 10
+// for SK-128.
+func NewSafer(key []byte, rounds int) (*SaferCipher, error) {
+	return newSafer(key, rounds, true)
+}
+
+// NewSaferK creates and returns a new SaferCipher with the original SAFER K
+// key schedule, for interoperability.  The key must be 8 bytes for K-64 or 16
+// bytes for K-128; the recommended numbers of rounds are 6 and 10.
+func NewSaferK(key []byte, rounds int) (*SaferCipher, error) {
+	return newSafer(key, rounds, false)
+}
+
+func newSafer(key []byte, rounds int, strengthened bool) (*SaferCipher, error) {
+
+	var k1, k2 []byte
+
+	switch len(key) {
+	case 8:
+		k1, k2 = key, key
+	case 16:
+		k1, k2 = key[:8], key[8:]
+	default:
+		return nil, KeySizeError(len(key))
+	}
+
+	if rounds < 1 || rounds > saferMaxRounds {
+		return nil, RoundsError(rounds)
+	}
+
+	c := &SaferCipher{
+		rounds: rounds,
+		k:      make([]byte, 0, 8*(2*rounds+1)),
+	}
+
+	// ka and kb hold a key half and its parity byte
+	var ka, kb [9]byte
+	for j := 0; j < 8; j++ {
+		ka[j] = rotl8(k1[j], 5)
+		ka[8] ^= ka[j]
+		kb[j] = k2[j]
+		kb[8] ^= kb[j]
+	}
+	c.k = append(c.k, k2...)
+
+	for i := 1; i <= rounds; i++ {
+		for j := range ka {
+			ka[j] = rotl8(ka[j], 6)
+			kb[j] = rotl8(kb[j], 6)
+		}
+
+		// the SK schedule starts each subkey at a different byte of the 9
+		for j := 0; j < 8; j++ {
+			b := ka[j]
+			if strengthened {
+				b = ka[(2*i-1+j)%9]
+			}
+			c.k = append(c.k, b+saferExp[saferExp[byte(18*i+j+1)]])
+		}
+		for j := 0; j < 8; j++ {
+			b := kb[j]
+			if strengthened {
+				b = kb[(2*i+j)%9]
+			}
+			c.k = append(c.k, b+saferExp[saferExp[byte(18*i+j+10)]])
+		}
+	}
+
+	return c, nil
+}
+
+// Reset zeroes the subkeys.
+func (c *SaferCipher) Reset() {
+	for i := range c.k {
+		c.k[i] = 0
+	}
+}
+
+// IsWiped reports whether the subkeys are all zero.
+func (c *SaferCipher) IsWiped() bool {
+	for _, k := range c.k {
+		if k != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *SaferCipher) BlockSize() int { return 8 }
+
+// Encrypt encrypts the 8-byte block in src and stores the resulting ciphertext in dst.
+func (c *SaferCipher) Encrypt(dst, src []byte) {
+
+	var x [8]byte
+	copy(x[:], src[:8])
+
+	k := c.k
+	for r := 0; r < c.rounds; r++ {
+		saferMix(&x, k[:8])
+
+		x[0] = saferExp[x[0]] + k[8]
+		x[1] = saferLog[x[1]] ^ k[9]
+		x[2] = saferLog[x[2]] ^ k[10]
+		x[3] = saferExp[x[3]] + k[11]
+		x[4] = saferExp[x[4]] + k[12]
+		x[5] = saferLog[x[5]] ^ k[13]
+		x[6] = saferLog[x[6]] ^ k[14]
+		x[7] = saferExp[x[7]] + k[15]
+
+		// three layers of 2-point pseudo-Hadamard transforms
+		saferPHT(&x[0], &x[1])
+		saferPHT(&x[2], &x[3])
+		saferPHT(&x[4], &x[5])
+		saferPHT(&x[6], &x[7])
+		saferPHT(&x[0], &x[2])
+		saferPHT(&x[4], &x[6])
+		saferPHT(&x[1], &x[3])
+		saferPHT(&x[5], &x[7])
+		saferPHT(&x[0], &x[4])
+		saferPHT(&x[1], &x[5])
+		saferPHT(&x[2], &x[6])
+		saferPHT(&x[3], &x[7])
+
+		x = [8]byte{x[0], x[4], x[1], x[5], x[2], x[6], x[3], x[7]}
+
+		k = k[16:]
+	}
+	saferMix(&x, k[:8])
+
+	copy(dst, x[:])
+}
+
+// Decrypt decrypts the 8-byte block in src and stores the resulting plaintext in dst.
+func (c *SaferCipher) Decrypt(dst, src []byte) {
+
+	var x [8]byte
+	copy(x[:], src[:8])
+
+	k := c.k[16*c.rounds:]
+	saferUnmix(&x, k[:8])
+
+	for r := c.rounds - 1; r >= 0; r-- {
+		k = c.k[16*r:]
+
+		x = [8]byte{x[0], x[2], x[4], x[6], x[1], x[3], x[5], x[7]}
+
+		saferIPHT(&x[0], &x[4])
+		saferIPHT(&x[1], &x[5])
+		saferIPHT(&x[2], &x[6])
+		saferIPHT(&x[3], &x[7])
+		saferIPHT(&x[0], &x[2])
+		saferIPHT(&x[4], &x[6])
+		saferIPHT(&x[1], &x[3])
+		saferIPHT(&x[5], &x[7])
+		saferIPHT(&x[0], &x[1])
+		saferIPHT(&x[2], &x[3])
+		saferIPHT(&x[4], &x[5])
+		saferIPHT(&x[6], &x[7])
+
+		x[0] = saferLog[x[0]-k[8]]
+		x[1] = saferExp[x[1]^k[9]]
+		x[2] = saferExp[x[2]^k[10]]
+		x[3] = saferLog[x[3]-k[11]]
+		x[4] = saferLog[x[4]-k[12]]
+		x[5] = saferExp[x[5]^k[13]]
+		x[6] = saferExp[x[6]^k[14]]
+		x[7] = saferLog[x[7]-k[15]]
+
+		saferUnmix(&x, k[:8])
+	}
+
+	copy(dst, x[:])
+}
+
+// saferMix combines x with the subkey k, mixing xor and addition
+func saferMix(x *[8]byte, k []byte) {
+	x[0] ^= k[0]
+	x[1] += k[1]
+	x[2] += k[2]
+	x[3] ^= k[3]
+	x[4] ^= k[4]
+	x[5] += k[5]
+	x[6] += k[6]
+	x[7] ^= k[7]
+}
+
+// saferUnmix is the inverse of saferMix
+func saferUnmix(x *[8]byte, k []byte) {
+	x[0] ^= k[0]
+	x[1] -= k[1]
+	x[2] -= k[2]
+	x[3] ^= k[3]
+	x[4] ^= k[4]
+	x[5] -= k[5]
+	x[6] -= k[6]
+	x[7] ^= k[7]
+}
+
+// the 2-point pseudo-Hadamard transform: (a, b) = (2a+b, a+b)
+func saferPHT(a, b *byte) {
+	*b += *a
+	*a += *b
+}
+
+// the inverse of saferPHT
+func saferIPHT(a, b *byte) {
+	*a -= *b
+	*b -= *a
+}
@@ -0,0 +1,57 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+// from the libtomcrypt self-tests
+var saferTestVectors = []struct {
+	sk     bool
+	rounds int
+	key    []byte
+	plain  []byte
+	cipher []byte
+}{
+	{false, 6, []byte{8, 7, 6, 5, 4, 3, 2, 1}, []byte{1, 2, 3, 4, 5, 6, 7, 8}, []byte{200, 242, 156, 221, 135, 120, 62, 217}},
+	{true, 6, []byte{1, 2, 3, 4, 5, 6, 7, 8}, []byte{1, 2, 3, 4, 5, 6, 7, 8}, []byte{95, 206, 155, 162, 5, 132, 56, 199}},
+	{true, 10, []byte{1, 2, 3, 4, 5, 6, 7, 8, 0, 0, 0, 0, 0, 0, 0, 0}, []byte{1, 2, 3, 4, 5, 6, 7, 8}, []byte{255, 120, 17, 228, 179, 167, 46, 113}},
+}
+
+func TestSafer(t *testing.T) {
+
+	for _, v := range saferTestVectors {
+		newf := NewSaferK
+		if v.sk {
+			newf = NewSafer
+		}
+		c, err := newf(v.key, v.rounds)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var b [8]byte
+		c.Encrypt(b[:], v.plain)
+		if !bytes.Equal(b[:], v.cipher) {
+			t.Errorf("safer encrypt failed: got %#v wanted %#v\n", b, v.cipher)
+		}
+
+		c.Decrypt(b[:], b[:])
+		if !bytes.Equal(b[:], v.plain) {
+			t.Errorf("safer decrypt failed: got %#v wanted %#v\n", b, v.plain)
+		}
+	}
+}
+
+func TestSaferErrors(t *testing.T) {
+
+	if _, err := NewSafer(make([]byte, 12), 8); err != KeySizeError(12) {
+		t.Errorf("NewSafer(12-byte key)=%v, wanted %v", err, KeySizeError(12))
+	}
+
+	for _, r := range []int{0, 14} {
+		if _, err := NewSaferK(make([]byte, 8), r); err != RoundsError(r) {
+			t.Errorf("NewSaferK(rounds=%d)=%v, wanted %v", r, err, RoundsError(r))
+		}
+	}
+}
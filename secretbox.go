@@ -0,0 +1,54 @@
+package krcrypt
+
+// A NaCl-style secretbox over SEED-GCM
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+https://nacl.cr.yp.to/secretbox.html
+
+*/
+
+// SecretboxSeal encrypts and authenticates plaintext with SEED-GCM under the
+// 16-byte key and 12-byte nonce, returning the box: the ciphertext followed by
+// the 16-byte tag.  The nonce isn't included, so it must be stored or sent
+// alongside the box, and it must never be reused with the same key; a random
+// nonce is fine for up to about 2^32 messages per key.
+//
+// This is modeled on NaCl's crypto_secretbox, which uses XSalsa20-Poly1305,
+// and is just as simple to use, but the boxes aren't compatible with it.
+// SecretboxSeal panics if the key or nonce is the wrong size.
+func SecretboxSeal(key, nonce, plaintext []byte) []byte {
+
+	aead, err := NewGCM(key)
+	if err != nil {
+		panic("krcrypt: secretbox key must be 16 bytes")
+	}
+
+	if len(nonce) != aead.NonceSize() {
+		panic("krcrypt: secretbox nonce must be 12 bytes")
+	}
+
+	return aead.Seal(nil, nonce, plaintext, nil)
+}
+
+// SecretboxOpen authenticates and decrypts a box made by SecretboxSeal,
+// returning the plaintext and true, or nil and false if the box has been
+// tampered with or the key or nonce is wrong.
+func SecretboxOpen(key, nonce, box []byte) ([]byte, bool) {
+
+	aead, err := NewGCM(key)
+	if err != nil || len(nonce) != aead.NonceSize() {
+		return nil, false
+	}
+
+	plain, err := aead.Open(nil, nonce, box, nil)
+	if err != nil {
+		return nil, false
+	}
+
+	return plain, true
+}
@@ -0,0 +1,52 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSecretbox(t *testing.T) {
+
+	key := []byte("YELLOW SUBMARINE")
+	nonce := make([]byte, 12)
+	msg := []byte("attack at dawn")
+
+	box := SecretboxSeal(key, nonce, msg)
+	if len(box) != len(msg)+16 {
+		t.Errorf("box is %d bytes, wanted %d", len(box), len(msg)+16)
+	}
+
+	plain, ok := SecretboxOpen(key, nonce, box)
+	if !ok || !bytes.Equal(plain, msg) {
+		t.Errorf("SecretboxOpen=%q, %v, wanted %q, true", plain, ok, msg)
+	}
+
+	// flip each bit in turn
+	for i := 0; i < 8*len(box); i++ {
+		box[i/8] ^= 1 << uint(i%8)
+		if _, ok := SecretboxOpen(key, nonce, box); ok {
+			t.Fatalf("SecretboxOpen accepted a box with bit %d flipped", i)
+		}
+		box[i/8] ^= 1 << uint(i%8)
+	}
+
+	if _, ok := SecretboxOpen(key, []byte("other nonce!"), box); ok {
+		t.Errorf("SecretboxOpen accepted the wrong nonce")
+	}
+	if _, ok := SecretboxOpen([]byte("YELLOW SUBMARINF"), nonce, box); ok {
+		t.Errorf("SecretboxOpen accepted the wrong key")
+	}
+	if _, ok := SecretboxOpen(key, nonce, box[:15]); ok {
+		t.Errorf("SecretboxOpen accepted a truncated box")
+	}
+	if _, ok := SecretboxOpen(key[:8], nonce, box); ok {
+		t.Errorf("SecretboxOpen accepted a short key")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("SecretboxSeal with an 8-byte nonce didn't panic")
+		}
+	}()
+	SecretboxSeal(key, nonce[:8], msg)
+}
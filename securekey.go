@@ -0,0 +1,64 @@
+package krcrypt
+
+// Key storage that stays out of swap
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+// A SecureKey holds key bytes in memory that is locked into RAM where the
+// operating system allows it, so the key can't be written to swap.  Call
+// Destroy when the key is no longer needed.
+//
+// On Unix-like systems the memory is mapped separately from the Go heap and
+// locked with mlock.  Locking can fail, typically because RLIMIT_MEMLOCK is
+// too low, and on other systems it isn't attempted; the key is then held in
+// ordinary memory and Locked reports false.
+//
+// This protects only the raw key.  A cipher built from it, such as the one
+// returned by NewSEEDSecure, keeps its expanded key schedule on the Go heap.
+type SecureKey struct {
+	b      []byte
+	locked bool
+	mapped bool
+}
+
+// NewSecureKey copies key into locked memory.  The caller should wipe its own
+// copy afterwards.
+func NewSecureKey(key []byte) *SecureKey {
+
+	k := new(SecureKey)
+	k.b, k.locked, k.mapped = allocLocked(len(key))
+	copy(k.b, key)
+
+	return k
+}
+
+// Bytes returns the key.  The slice refers to the locked memory and must not
+// be retained after Destroy.
+func (k *SecureKey) Bytes() []byte { return k.b }
+
+// Locked reports whether the key's memory is locked into RAM.
+func (k *SecureKey) Locked() bool { return k.locked }
+
+// Destroy zeroes the key, then unlocks and releases its memory.  The
+// SecureKey is empty afterwards.
+func (k *SecureKey) Destroy() {
+
+	for i := range k.b {
+		k.b[i] = 0
+	}
+
+	freeLocked(k.b, k.locked, k.mapped)
+	k.b, k.locked, k.mapped = nil, false, false
+}
+
+// NewSEEDSecure creates a SEEDCipher from the key held in k.
+func NewSEEDSecure(k *SecureKey) (*SEEDCipher, error) {
+	c := new(SEEDCipher)
+
+	if klen := len(k.b); klen != 16 {
+		return nil, KeySizeError(klen)
+	}
+
+	c.subkeys(k.b, &kc)
+	return c, nil
+}
@@ -0,0 +1,14 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+
+package krcrypt
+
+// Key memory for systems without mlock
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+// allocLocked can't lock memory here, so it returns ordinary memory
+func allocLocked(n int) (b []byte, locked, mapped bool) {
+	return make([]byte, n), false, false
+}
+
+func freeLocked(b []byte, locked, mapped bool) {}
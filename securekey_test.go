@@ -0,0 +1,46 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSecureKey(t *testing.T) {
+
+	v := seedTestVectors[0]
+
+	k := NewSecureKey(v.key)
+	t.Logf("key memory locked: %v", k.Locked())
+
+	c, err := NewSEEDSecure(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ct [16]byte
+	c.Encrypt(ct[:], v.plain)
+	if !bytes.Equal(ct[:], v.cipher) {
+		t.Errorf("NewSEEDSecure encrypt failed: got %#v wanted %#v\n", ct, v.cipher)
+	}
+
+	k.Destroy()
+	if k.Bytes() != nil || k.Locked() {
+		t.Errorf("after Destroy: Bytes()=%x Locked()=%v", k.Bytes(), k.Locked())
+	}
+	if _, err := NewSEEDSecure(k); err != KeySizeError(0) {
+		t.Errorf("NewSEEDSecure(destroyed)=%v, wanted %v", err, KeySizeError(0))
+	}
+}
+
+func TestSecureKeyDestroyZeroes(t *testing.T) {
+
+	// heap memory, as on systems without mlock, so it can still be read
+	// after Destroy
+	b := []byte("0123456789abcdef")
+	k := &SecureKey{b: b}
+
+	k.Destroy()
+	if !bytes.Equal(b, make([]byte, 16)) {
+		t.Errorf("Destroy left %x", b)
+	}
+}
@@ -0,0 +1,34 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package krcrypt
+
+// Locked key memory for Unix-like systems
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+import "syscall"
+
+// allocLocked returns n bytes mapped outside the Go heap and locked, falling
+// back to unlocked or heap memory if either step fails
+func allocLocked(n int) (b []byte, locked, mapped bool) {
+
+	if n == 0 {
+		return []byte{}, false, false
+	}
+
+	b, err := syscall.Mmap(-1, 0, n, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		return make([]byte, n), false, false
+	}
+
+	return b, syscall.Mlock(b) == nil, true
+}
+
+func freeLocked(b []byte, locked, mapped bool) {
+	if locked {
+		syscall.Munlock(b)
+	}
+	if mapped {
+		syscall.Munmap(b)
+	}
+}
@@ -18,8 +18,17 @@ http://seed.kisa.or.kr/eng/about/about.jsp
 import (
 	"crypto/cipher"
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"runtime"
 )
 
+// The 64-bit key halves are kept as pairs of uint32s rather than a uint64.
+// Rotating a packed uint64 instead measured no better (~2.9ns vs ~2.7ns per
+// left+right pair on amd64, ~2.7ns vs ~2.8ns on 386), and the key schedule is
+// a small fraction of NewSEED (~120ns amd64, ~250ns 386), so there is one
+// implementation for all platforms and no build tags.
+
 // rotate 64 bits left by one byte
 func rotlbyte32(x0, x1 uint32) (uint32, uint32) {
 	b0 := (x0 & 0xff000000) >> 24
@@ -39,45 +48,162 @@ func rotrbyte32(x0, x1 uint32) (uint32, uint32) {
 }
 
 // A SEEDCipher is an instance of SEED encryption using a particular key
-type seedCipher struct {
-	k0 [16]uint32
-	k1 [16]uint32
+type SEEDCipher struct {
+	k0       [16]uint32
+	k1       [16]uint32
+	rounds   int         // 0 means the standard 16
+	mask     *seedMasker // non-nil for NewSEEDMasked
+	prefetch bool        // touch every line of the S-boxes before each block
+	compact  bool        // use gCompact and its single table
+	little   bool        // load and store the block's words little-endian
 }
 
 // NewSEED creates and returns a new cipher.Block implementing SEED encryption
-// with a particular key.  The key argument should be 16 bytes.
+// with a particular key.  The key argument should be 16 bytes.  The returned
+// Block is a *SEEDCipher.
 func NewSEED(key []byte) (cipher.Block, error) {
-	c := new(seedCipher)
+	c := new(SEEDCipher)
+
+	if klen := len(key); klen != 16 {
+		return nil, KeySizeError(klen)
+	}
+
+	c.subkeys(key, &kc)
+	return c, nil
+}
+
+// NewSEEDWithConstants is like NewSEED, but derives the round keys using the
+// supplied key schedule constants in place of the standard KC_i values.
+//
+// THIS IS NOT SEED.  Ciphertext produced with anything other than the
+// standard constants will not interoperate with any other SEED implementation.
+// It exists for cryptanalysis experiments on the key schedule.
+func NewSEEDWithConstants(key []byte, constants [16]uint32) (*SEEDCipher, error) {
+	c := new(SEEDCipher)
 
 	if klen := len(key); klen != 16 {
 		return nil, KeySizeError(klen)
 	}
 
-	c.subkeys(key)
+	c.subkeys(key, &constants)
 	return c, nil
 }
 
+// NewSEEDRounds returns SEED reduced to the given number of Feistel rounds,
+// which must be between 1 and 16.  The round keys are the first 'rounds' of
+// the standard schedule, so with 16 rounds this is exactly NewSEED.  Anything
+// less is not secure; it is meant for studying the diffusion of the cipher.
+func NewSEEDRounds(key []byte, rounds int) (*SEEDCipher, error) {
+	c := new(SEEDCipher)
+
+	if klen := len(key); klen != 16 {
+		return nil, KeySizeError(klen)
+	}
+
+	if rounds < 1 || rounds > 16 {
+		return nil, RoundsError(rounds)
+	}
+
+	c.subkeys(key, &kc)
+	c.rounds = rounds
+	return c, nil
+}
+
+// NewSEEDBatch keys a SEED cipher for each of keys, as NewSEED does, with the
+// ciphers allocated together in one array rather than one at a time.  That
+// is a single allocation, and keeps the schedules adjacent in memory for
+// code that cycles through many keys; for 1000 keys it is about 40% faster
+// (~121us vs ~196us on amd64, see BenchmarkSEEDBatch).  All the keys are
+// checked first, and if any isn't 16 bytes the error wraps its KeySizeError
+// and names its index.
+func NewSEEDBatch(keys [][]byte) ([]*SEEDCipher, error) {
+
+	for i, key := range keys {
+		if klen := len(key); klen != 16 {
+			return nil, fmt.Errorf("krcrypt: NewSEEDBatch: key %d: %w", i, KeySizeError(klen))
+		}
+	}
+
+	all := make([]SEEDCipher, len(keys))
+	cs := make([]*SEEDCipher, len(keys))
+	for i, key := range keys {
+		all[i].subkeys(key, &kc)
+		cs[i] = &all[i]
+	}
+
+	return cs, nil
+}
+
+// NewSEEDPrefetch is like NewSEED, but each Encrypt and Decrypt first reads
+// one entry from every cache line of the S-box tables, so that all of them are
+// cached before the key-dependent lookups start.  An attacker timing a single
+// block then can't tell which lines the lookups needed from which ones had to
+// be fetched.
+//
+// This is only a partial mitigation, and the cipher is still not constant
+// time: lines can be evicted during the block by another process on the same
+// core, and lookups within a line can vary in timing on some CPUs.  It costs
+// about 10% (~280ns vs ~255ns per block on amd64, see BenchmarkSEEDPrefetch).
+func NewSEEDPrefetch(key []byte) (*SEEDCipher, error) {
+	c := new(SEEDCipher)
+
+	if klen := len(key); klen != 16 {
+		return nil, KeySizeError(klen)
+	}
+
+	c.subkeys(key, &kc)
+	c.prefetch = true
+	return c, nil
+}
+
+// seedPrefetch reads one entry from each 64-byte line of ss0..ss3
+func seedPrefetch() {
+	var x uint32
+	for i := 0; i < 256; i += 16 {
+		x ^= ss0[i] ^ ss1[i] ^ ss2[i] ^ ss3[i]
+	}
+	runtime.KeepAlive(x)
+}
+
+// the number of rounds this instance uses
+func (c *SEEDCipher) numRounds() int {
+	if c.rounds == 0 {
+		return 16
+	}
+	return c.rounds
+}
+
 // BlockSize returns the HIGHT block size.  It is needed to satisfy the Block interface in crypto/cipher.
-func (c *seedCipher) BlockSize() int { return 16 }
+func (c *SEEDCipher) BlockSize() int { return 16 }
 
 // Encrypt encrypts the 16-byte block in src and stores the resulting ciphertext in dst.
-func (c *seedCipher) Encrypt(dst, src []byte) {
+func (c *SEEDCipher) Encrypt(dst, src []byte) { c.EncryptInto(dst, src) }
 
-	l0 := binary.BigEndian.Uint32(src)
-	l1 := binary.BigEndian.Uint32(src[4:])
-	r0 := binary.BigEndian.Uint32(src[8:])
-	r1 := binary.BigEndian.Uint32(src[12:])
+// EncryptInto is Encrypt with a stronger contract: it never allocates, so it
+// is safe to call in tight loops without worrying about escape analysis.
+// Both dst and src must be at least 16 bytes long.
+func (c *SEEDCipher) EncryptInto(dst, src []byte) {
 
-	for i := 0; i < 15; i++ {
-		t0, t1 := r0, r1
-		f0, f1 := f(c.k0[i], c.k1[i], r0, r1)
-		r0, r1 = l0^f0, l1^f1
-		l0, l1 = t0, t1
+	if c.mask != nil {
+		c.mask.crypt(c, dst, src, false)
+		return
 	}
 
-	f0, f1 := f(c.k0[15], c.k1[15], r0, r1)
-	l0 ^= f0
-	l1 ^= f1
+	if c.compact {
+		c.cryptCompact(dst, src, false)
+		return
+	}
+
+	if c.little {
+		c.cryptLittleEndian(dst, src, false)
+		return
+	}
+
+	l0, l1, r0, r1 := c.encryptWords(
+		binary.BigEndian.Uint32(src),
+		binary.BigEndian.Uint32(src[4:]),
+		binary.BigEndian.Uint32(src[8:]),
+		binary.BigEndian.Uint32(src[12:]))
 
 	binary.BigEndian.PutUint32(dst, l0)
 	binary.BigEndian.PutUint32(dst[4:], l1)
@@ -85,33 +211,228 @@ func (c *seedCipher) Encrypt(dst, src []byte) {
 	binary.BigEndian.PutUint32(dst[12:], r1)
 }
 
-// Decrypt decrypts the 16-byte block in src and stores the resulting plaintext in dst.
-func (c *seedCipher) Decrypt(dst, src []byte) {
+// EncryptWords encrypts the block l0||l1||r0||r1, given as big-endian words,
+// without going through a byte slice.  It is the cheapest way to encrypt a
+// block the caller already holds as words.
+func (c *SEEDCipher) EncryptWords(l0, l1, r0, r1 uint32) (uint32, uint32, uint32, uint32) {
+	if c.mask != nil || c.compact {
+		return c.cryptWordsSlow(l0, l1, r0, r1, false)
+	}
+	return c.encryptWords(l0, l1, r0, r1)
+}
 
-	l0 := binary.BigEndian.Uint32(src)
-	l1 := binary.BigEndian.Uint32(src[4:])
-	r0 := binary.BigEndian.Uint32(src[8:])
-	r1 := binary.BigEndian.Uint32(src[12:])
+// DecryptWords is the inverse of EncryptWords.
+func (c *SEEDCipher) DecryptWords(l0, l1, r0, r1 uint32) (uint32, uint32, uint32, uint32) {
+	if c.mask != nil || c.compact {
+		return c.cryptWordsSlow(l0, l1, r0, r1, true)
+	}
+	return c.decryptWords(l0, l1, r0, r1)
+}
+
+// cryptWordsSlow runs the masked and compact variants, which only work on bytes
+func (c *SEEDCipher) cryptWordsSlow(l0, l1, r0, r1 uint32, decrypt bool) (uint32, uint32, uint32, uint32) {
+	var b [16]byte
+	binary.BigEndian.PutUint32(b[0:], l0)
+	binary.BigEndian.PutUint32(b[4:], l1)
+	binary.BigEndian.PutUint32(b[8:], r0)
+	binary.BigEndian.PutUint32(b[12:], r1)
+	if decrypt {
+		c.Decrypt(b[:], b[:])
+	} else {
+		c.Encrypt(b[:], b[:])
+	}
+	return binary.BigEndian.Uint32(b[0:]), binary.BigEndian.Uint32(b[4:]), binary.BigEndian.Uint32(b[8:]), binary.BigEndian.Uint32(b[12:])
+}
+
+// encryptWords is the rounds of the unmasked, table-per-box cipher
+func (c *SEEDCipher) encryptWords(l0, l1, r0, r1 uint32) (uint32, uint32, uint32, uint32) {
+
+	if c.prefetch {
+		seedPrefetch()
+	}
+
+	last := c.numRounds() - 1
 
-	f0, f1 := f(c.k0[15], c.k1[15], r0, r1)
+	for i := 0; i < last; i++ {
+		t0, t1 := r0, r1
+		f0, f1 := f(c.k0[i], c.k1[i], r0, r1)
+		r0, r1 = l0^f0, l1^f1
+		l0, l1 = t0, t1
+	}
+
+	f0, f1 := f(c.k0[last], c.k1[last], r0, r1)
+	return l0 ^ f0, l1 ^ f1, r0, r1
+}
+
+// decryptWords is the inverse of encryptWords
+func (c *SEEDCipher) decryptWords(l0, l1, r0, r1 uint32) (uint32, uint32, uint32, uint32) {
+
+	if c.prefetch {
+		seedPrefetch()
+	}
+
+	last := c.numRounds() - 1
+
+	f0, f1 := f(c.k0[last], c.k1[last], r0, r1)
 	l0 ^= f0
 	l1 ^= f1
 
-	for i := 14; i >= 0; i-- {
+	for i := last - 1; i >= 0; i-- {
 		t0, t1 := l0, l1
 		f0, f1 := f(c.k0[i], c.k1[i], t0, t1)
 		l0, l1 = r0^f0, r1^f1
 		r0, r1 = t0, t1
 	}
 
+	return l0, l1, r0, r1
+}
+
+// EncryptChecked is Encrypt with a check for injected faults; see the
+// package-level EncryptChecked.
+func (c *SEEDCipher) EncryptChecked(dst, src []byte) error { return EncryptChecked(c, dst, src) }
+
+// EncryptTrace encrypts the 16-byte block src like Encrypt, but also returns
+// the state L||R after each round.  As with the ciphertext, the halves are not
+// swapped after the last round, so the final entry equals dst.  This is for
+// debugging and teaching; it is much slower than Encrypt.
+func (c *SEEDCipher) EncryptTrace(src []byte) (dst []byte, rounds [][16]byte) {
+
+	l0 := binary.BigEndian.Uint32(src)
+	l1 := binary.BigEndian.Uint32(src[4:])
+	r0 := binary.BigEndian.Uint32(src[8:])
+	r1 := binary.BigEndian.Uint32(src[12:])
+
+	n := c.numRounds()
+	rounds = make([][16]byte, n)
+
+	for i := 0; i < n; i++ {
+		f0, f1 := f(c.k0[i], c.k1[i], r0, r1)
+		if i == n-1 {
+			l0, l1 = l0^f0, l1^f1
+		} else {
+			l0, l1, r0, r1 = r0, r1, l0^f0, l1^f1
+		}
+
+		binary.BigEndian.PutUint32(rounds[i][0:], l0)
+		binary.BigEndian.PutUint32(rounds[i][4:], l1)
+		binary.BigEndian.PutUint32(rounds[i][8:], r0)
+		binary.BigEndian.PutUint32(rounds[i][12:], r1)
+	}
+
+	dst = make([]byte, 16)
+	copy(dst, rounds[n-1][:])
+	return dst, rounds
+}
+
+// Decrypt decrypts the 16-byte block in src and stores the resulting plaintext in dst.
+func (c *SEEDCipher) Decrypt(dst, src []byte) {
+
+	if c.mask != nil {
+		c.mask.crypt(c, dst, src, true)
+		return
+	}
+
+	if c.compact {
+		c.cryptCompact(dst, src, true)
+		return
+	}
+
+	if c.little {
+		c.cryptLittleEndian(dst, src, true)
+		return
+	}
+
+	l0, l1, r0, r1 := c.decryptWords(
+		binary.BigEndian.Uint32(src),
+		binary.BigEndian.Uint32(src[4:]),
+		binary.BigEndian.Uint32(src[8:]),
+		binary.BigEndian.Uint32(src[12:]))
+
 	binary.BigEndian.PutUint32(dst, l0)
 	binary.BigEndian.PutUint32(dst[4:], l1)
 	binary.BigEndian.PutUint32(dst[8:], r0)
 	binary.BigEndian.PutUint32(dst[12:], r1)
 }
 
-// compute the round subkeys
-func (c *seedCipher) subkeys(key []byte) {
+// Reset zeroes the key schedule and clears the options the constructor set,
+// such as the rounds and byte order.
+func (c *SEEDCipher) Reset() {
+	*c = SEEDCipher{}
+}
+
+// IsWiped reports whether the key schedule is all zero.
+func (c *SEEDCipher) IsWiped() bool {
+	return c.k0 == [16]uint32{} && c.k1 == [16]uint32{}
+}
+
+// the MarshalBinary format version
+const seedStateVersion = 1
+
+// MarshalBinary returns the expanded key schedule and round count, so a cipher
+// can be rebuilt elsewhere with UnmarshalBinary without the original key.  The
+// result is as sensitive as the key.  A masked cipher can't be marshaled,
+// since the receiver would get an unmasked one.
+func (c *SEEDCipher) MarshalBinary() ([]byte, error) {
+
+	if c.mask != nil {
+		return nil, errors.New("krcrypt: can't marshal a masked SEEDCipher")
+	}
+
+	b := make([]byte, 3, 3+4*32)
+	b[0] = seedStateVersion
+	b[1] = byte(c.numRounds())
+	if c.prefetch {
+		b[2] |= 1
+	}
+	if c.compact {
+		b[2] |= 2
+	}
+	if c.little {
+		b[2] |= 4
+	}
+
+	for i := 0; i < 16; i++ {
+		b = binary.BigEndian.AppendUint32(b, c.k0[i])
+		b = binary.BigEndian.AppendUint32(b, c.k1[i])
+	}
+
+	return b, nil
+}
+
+// UnmarshalBinary restores a cipher saved by MarshalBinary.
+func (c *SEEDCipher) UnmarshalBinary(b []byte) error {
+
+	if len(b) != 3+4*32 || b[0] != seedStateVersion || b[1] < 1 || b[1] > 16 {
+		return ErrState
+	}
+
+	// each constructor sets at most one of prefetch, compact and little
+	switch b[2] {
+	case 0, 1, 2, 4:
+	default:
+		return ErrState
+	}
+
+	*c = SEEDCipher{rounds: int(b[1]), prefetch: b[2]&1 != 0, compact: b[2]&2 != 0, little: b[2]&4 != 0}
+	if c.rounds == 16 {
+		c.rounds = 0
+	}
+
+	b = b[3:]
+	for i := 0; i < 16; i++ {
+		c.k0[i] = binary.BigEndian.Uint32(b[8*i:])
+		c.k1[i] = binary.BigEndian.Uint32(b[8*i+4:])
+	}
+
+	return nil
+}
+
+// SEEDConstants returns the standard key schedule constants KC_i, as a
+// starting point for NewSEEDWithConstants.
+func SEEDConstants() [16]uint32 { return kc }
+
+// compute the round subkeys, using the key schedule constants kc
+func (c *SEEDCipher) subkeys(key []byte, kc *[16]uint32) {
 
 	key0 := binary.BigEndian.Uint32(key)
 	key1 := binary.BigEndian.Uint32(key[4:])
@@ -129,7 +450,7 @@ func (c *seedCipher) subkeys(key []byte) {
 	}
 }
 
-var kc = [...]uint32{
+var kc = [16]uint32{
 	0x9E3779B9, 0x3C6EF373, 0x78DDE6E6, 0xF1BBCDCC,
 	0xE3779B99, 0xC6EF3733, 0x8DDE6E67, 0x1BBCDCCF,
 	0x3779B99E, 0x6EF3733C, 0xDDE6E678, 0xBBCDCCF1,
@@ -2,6 +2,12 @@ package krcrypt
 
 import (
 	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strings"
 	"testing"
 )
 
@@ -18,21 +24,341 @@ var seedTestVectors = []struct {
 
 func TestSEEDEncrypt(t *testing.T) {
 
+	var vectors []BlockVector
 	for _, v := range seedTestVectors {
-		h, _ := NewSEED(v.key)
+		vectors = append(vectors, BlockVector{v.key, v.plain, v.cipher})
+	}
+
+	RunBlockKAT(t, NewSEED, vectors)
+}
+
+func TestSEEDEncryptIntoAllocs(t *testing.T) {
 
-		var c, p [16]byte
+	v := seedTestVectors[0]
 
-		h.Encrypt(c[:], v.plain)
+	// every way of keying a SEEDCipher, each of which takes its own path
+	// through EncryptInto
+	for _, tt := range []struct {
+		name string
+		ctor func([]byte) (*SEEDCipher, error)
+		want []byte
+	}{
+		{"plain", func(k []byte) (*SEEDCipher, error) { s, err := NewSEED(k); return s.(*SEEDCipher), err }, v.cipher},
+		{"prefetch", NewSEEDPrefetch, v.cipher},
+		{"compact", NewSEEDCompact, v.cipher},
+		{"masked", func(k []byte) (*SEEDCipher, error) { return NewSEEDMasked(k, rand.Reader) }, v.cipher},
+		{"little-endian", NewSEEDLittleEndian, swapWords(v.cipher)},
+	} {
+		c, err := tt.ctor(v.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		src := v.plain
+		if tt.name == "little-endian" {
+			src = swapWords(v.plain)
+		}
 
-		if !bytes.Equal(v.cipher, c[:]) {
-			t.Errorf("seed encrypt failed: got %#v wanted %#v\n", c, v.cipher)
+		var dst [16]byte
+		if n := testing.AllocsPerRun(100, func() { c.EncryptInto(dst[:], src) }); n != 0 {
+			t.Errorf("%s: EncryptInto allocated %v times per call, wanted 0", tt.name, n)
 		}
 
-		h.Decrypt(p[:], c[:])
+		if !bytes.Equal(dst[:], tt.want) {
+			t.Errorf("%s: EncryptInto=%x, wanted %x", tt.name, dst, tt.want)
+		}
+	}
+}
+
+func BenchmarkSEEDEncryptInto(b *testing.B) {
+
+	s, _ := NewSEED(make([]byte, 16))
+	c := s.(*SEEDCipher)
+
+	var buf [16]byte
+	b.SetBytes(16)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.EncryptInto(buf[:], buf[:])
+	}
+}
+
+func TestSEEDWithConstants(t *testing.T) {
+
+	for _, v := range seedTestVectors {
+		c, err := NewSEEDWithConstants(v.key, SEEDConstants())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var out [16]byte
+		c.Encrypt(out[:], v.plain)
+		if !bytes.Equal(out[:], v.cipher) {
+			t.Errorf("seed with default constants: got %#v wanted %#v\n", out, v.cipher)
+		}
+	}
+
+	v := seedTestVectors[0]
+
+	constants := SEEDConstants()
+	constants[3] ^= 1
+	c, _ := NewSEEDWithConstants(v.key, constants)
+
+	var out [16]byte
+	c.Encrypt(out[:], v.plain)
+	if bytes.Equal(out[:], v.cipher) {
+		t.Errorf("modified constants did not change the ciphertext")
+	}
+
+	if err := VerifyInverse(c, 100); err != nil {
+		t.Errorf("seed with modified constants: %v", err)
+	}
+}
+
+func TestSEEDRounds(t *testing.T) {
+
+	for _, v := range seedTestVectors {
+		c, err := NewSEEDRounds(v.key, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var out [16]byte
+		c.Encrypt(out[:], v.plain)
+		if !bytes.Equal(out[:], v.cipher) {
+			t.Errorf("seed with 16 rounds: got %#v wanted %#v\n", out, v.cipher)
+		}
+	}
+
+	key := seedTestVectors[2].key
+	for r := 1; r <= 16; r++ {
+		c, err := NewSEEDRounds(key, r)
+		if err != nil {
+			t.Fatalf("rounds=%d: %v", r, err)
+		}
+		if err := VerifyInverse(c, 50); err != nil {
+			t.Errorf("rounds=%d: %v", r, err)
+		}
+	}
+
+	for _, r := range []int{-1, 0, 17} {
+		if _, err := NewSEEDRounds(key, r); err != RoundsError(r) {
+			t.Errorf("NewSEEDRounds(%d)=%v, wanted %v", r, err, RoundsError(r))
+		}
+	}
+}
+
+// the round keys and the state after each round from RFC 4269 Appendix B,
+// for the first two of seedTestVectors: Ki0 Ki1, then L0 L1 R0 R1
+var seedTraceVectors = [][16]string{
+	{
+		"7C8F8C7E C737A22C  08090A0B 0C0D0E0F 8081BC57 C4EA8A1F",
+		"FF276CDB A7CA684A  8081BC57 C4EA8A1F 117A8B07 D7358C24",
+		"2F9D01A1 70049E41  117A8B07 D7358C24 D1738C94 7326CAB0",
+		"AE59B3C4 4245E90C  D1738C94 7326CAB0 577ECE6D 1F8433EC",
+		"A1D6400F DBC1394E  577ECE6D 1F8433EC 910F62AB DDA096C1",
+		"85963508 0C5F1FCB  910F62AB DDA096C1 EA4D39B4 B17B1938",
+		"B684BDA7 61A4AEAE  EA4D39B4 B17B1938 B04E251F 97D7442C",
+		"D17E0741 FEE90AA1  B04E251F 97D7442C B86D31BF A5988C06",
+		"76CC05D5 E97A7394  B86D31BF A5988C06 9008EABF 38DF7430",
+		"50AC6F92 1B2666E5  9008EABF 38DF7430 33E47DE0 54EFF76C",
+		"65B7904A 8EC3A7B3  33E47DE0 54EFF76C 6BE9C434 BF3F378A",
+		"2F7E2E22 A2B121B9  6BE9C434 BF3F378A B8DC3842 03A02D33",
+		"4D0BFDE4 4E888D9B  B8DC3842 03A02D33 6679FCF7 9791DFCB",
+		"631C8DDC 4378A6C4  6679FCF7 9791DFCB 1A415792 A02B8C54",
+		"216AF65F 7878C031  1A415792 A02B8C54 19AFF1CC 6D346CDB",
+		"71891150 98B255B0  5EBAC6E0 054E1668 19AFF1CC 6D346CDB",
+	},
+	{
+		"C119F584 5AE033A0  00000000 00000000 9D8DB62C 911F0C19",
+		"62947390 A600AD14  9D8DB62C 911F0C19 21229A97 4AB4B7B8",
+		"F6F6544E 596C4B49  21229A97 4AB4B7B8 5A27B404 899D7315",
+		"C1A3DE02 CE483C49  5A27B404 899D7315 B8489E76 BA0EF3EA",
+		"5E742E6D 7E25163D  B8489E76 BA0EF3EA 04A3DF29 31A27FB4",
+		"8299D2B4 790A46CE  04A3DF29 31A27FB4 EC9C17BF 81AA2AA0",
+		"EA67D836 55F354F2  EC9C17BF 81AA2AA0 4FA74E8D CDB21BB8",
+		"C47329FB F50DB634  4FA74E8D CDB21BB8 D93492FE 4F71A4DA",
+		"2BD30235 51679CE6  D93492FE 4F71A4DA B14053D9 A911379B",
+		"FA8D6B76 A9F37E02  B14053D9 A911379B 5A7024D6 3905668B",
+		"8B99CC60 0F6092D4  5A7024D6 3905668B 605C8C3A 73DFBB75",
+		"BDAEFCFA 489C2242  605C8C3A 73DFBB75 40282F39 31CB8987",
+		"F6357C14 CFCCB126  40282F39 31CB8987 E9F834A8 3B9586D4",
+		"A0AA6D85 F8C10774  E9F834A8 3B9586D4 4B60324B 761C9958",
+		"47F4FEC5 353AE1BA  4B60324B 761C9958 84483597 E4370F43",
+		"FECCEA48 A4EF9F9B  C11F22F2 01405050 84483597 E4370F43",
+	},
+}
+
+func TestSEEDEncryptTrace(t *testing.T) {
+
+	for n, trace := range seedTraceVectors {
+		v := seedTestVectors[n]
+		c, _ := NewSEEDRounds(v.key, 16)
+		dst, rounds := c.EncryptTrace(v.plain)
+
+		if len(rounds) != 16 {
+			t.Fatalf("got %d round states, wanted 16", len(rounds))
+		}
+		if !bytes.Equal(dst, v.cipher) {
+			t.Errorf("seed trace: got %#v wanted %#v\n", dst, v.cipher)
+		}
+
+		for r, line := range trace {
+			want, _ := hex.DecodeString(strings.Replace(line, " ", "", -1))
+
+			var k [8]byte
+			binary.BigEndian.PutUint32(k[:], c.k0[r])
+			binary.BigEndian.PutUint32(k[4:], c.k1[r])
+			if !bytes.Equal(k[:], want[:8]) {
+				t.Errorf("B.%d: K%d=%x, wanted %x", n+1, r+1, k, want[:8])
+			}
+
+			if !bytes.Equal(rounds[r][:], want[8:]) {
+				t.Errorf("B.%d: state after round %d=%x, wanted %x", n+1, r+1, rounds[r], want[8:])
+			}
+		}
+	}
+}
+
+func BenchmarkSEEDKeySchedule(b *testing.B) {
+	key := make([]byte, 16)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		NewSEED(key)
+	}
+}
+
+func TestSEEDBatch(t *testing.T) {
+
+	keys := make([][]byte, len(seedTestVectors))
+	for i, v := range seedTestVectors {
+		keys[i] = v.key
+	}
+
+	cs, err := NewSEEDBatch(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, v := range seedTestVectors {
+		got := make([]byte, 16)
+		cs[i].Encrypt(got, v.plain)
+		if !bytes.Equal(got, v.cipher) {
+			t.Errorf("batch cipher %d: got %x wanted %x", i, got, v.cipher)
+		}
+	}
+
+	keys[2] = keys[2][:15]
+	_, err = NewSEEDBatch(keys)
+	if !errors.Is(err, KeySizeError(15)) || !strings.Contains(err.Error(), "key 2") {
+		t.Errorf("NewSEEDBatch(bad key 2)=%v, wanted a KeySizeError naming key 2", err)
+	}
+}
+
+const seedBatchKeys = 1000
+
+func BenchmarkSEEDBatch(b *testing.B) {
+	keys := make([][]byte, seedBatchKeys)
+	for i := range keys {
+		keys[i] = make([]byte, 16)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		NewSEEDBatch(keys)
+	}
+}
+
+func BenchmarkSEEDBatchIndividual(b *testing.B) {
+	key := make([]byte, 16)
+	cs := make([]cipher.Block, seedBatchKeys)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for j := range cs {
+			cs[j], _ = NewSEED(key)
+		}
+	}
+}
+
+func TestSEEDPrefetch(t *testing.T) {
+
+	for _, v := range seedTestVectors {
+		c, err := NewSEEDPrefetch(v.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var ct, pt [16]byte
+
+		c.Encrypt(ct[:], v.plain)
+		if !bytes.Equal(ct[:], v.cipher) {
+			t.Errorf("prefetch encrypt failed: got %#v wanted %#v\n", ct, v.cipher)
+		}
+
+		c.Decrypt(pt[:], ct[:])
+		if !bytes.Equal(pt[:], v.plain) {
+			t.Errorf("prefetch decrypt failed: got %#v wanted %#v\n", pt, v.plain)
+		}
+	}
+}
+
+func BenchmarkSEEDPrefetch(b *testing.B) {
+
+	c, _ := NewSEEDPrefetch(make([]byte, 16))
+
+	var buf [16]byte
+	b.SetBytes(16)
+	for i := 0; i < b.N; i++ {
+		c.Encrypt(buf[:], buf[:])
+	}
+}
+
+func TestSEEDEncryptWords(t *testing.T) {
+
+	for _, v := range seedTestVectors {
+		c, _ := NewSEEDRounds(v.key, 16)
+		w := func(b []byte, i int) uint32 { return binary.BigEndian.Uint32(b[4*i:]) }
+
+		l0, l1, r0, r1 := c.EncryptWords(w(v.plain, 0), w(v.plain, 1), w(v.plain, 2), w(v.plain, 3))
+		if l0 != w(v.cipher, 0) || l1 != w(v.cipher, 1) || r0 != w(v.cipher, 2) || r1 != w(v.cipher, 3) {
+			t.Errorf("EncryptWords failed: got %08x %08x %08x %08x wanted %x\n", l0, l1, r0, r1, v.cipher)
+		}
+
+		l0, l1, r0, r1 = c.DecryptWords(l0, l1, r0, r1)
+		if l0 != w(v.plain, 0) || l1 != w(v.plain, 1) || r0 != w(v.plain, 2) || r1 != w(v.plain, 3) {
+			t.Errorf("DecryptWords failed: got %08x %08x %08x %08x wanted %x\n", l0, l1, r0, r1, v.plain)
+		}
+
+		cc, _ := NewSEEDCompact(v.key)
+		if l0, _, _, r1 := cc.EncryptWords(w(v.plain, 0), w(v.plain, 1), w(v.plain, 2), w(v.plain, 3)); l0 != w(v.cipher, 0) || r1 != w(v.cipher, 3) {
+			t.Errorf("compact EncryptWords failed for key %x\n", v.key)
+		}
+	}
+}
+
+func TestSEEDReset(t *testing.T) {
+
+	// with all-zero subkeys each round function sees only the data
+	var zero SEEDCipher
+	want := make([]byte, 16)
+	zero.Encrypt(want, seedTestVectors[0].plain)
+
+	key := seedTestVectors[0].key
+	rounds, _ := NewSEEDRounds(key, 16)
+	compact, _ := NewSEEDCompact(key)
+	masked, _ := NewSEEDMasked(key, bytes.NewReader(make([]byte, 8)))
+
+	for _, c := range []*SEEDCipher{new(SEEDCipher), rounds, compact, masked} {
+		c.Reset()
+		c.Reset()
+
+		if *c != (SEEDCipher{}) {
+			t.Errorf("Reset left %+v, wanted a zero SEEDCipher", *c)
+		}
 
-		if !bytes.Equal(v.plain, p[:]) {
-			t.Errorf("seed decrypt failed: got %#v wanted %#v\n", p, v.plain)
+		got := make([]byte, 16)
+		c.Encrypt(got, seedTestVectors[0].plain)
+		if !bytes.Equal(got, want) {
+			t.Errorf("Encrypt after Reset=%x, wanted the zero-subkey encryption %x", got, want)
 		}
 	}
 }
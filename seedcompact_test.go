@@ -0,0 +1,90 @@
+package krcrypt
+
+import (
+	"bytes"
+	mrand "math/rand"
+	"testing"
+)
+
+func TestGCompact(t *testing.T) {
+
+	// g works bytewise, so checking each byte lane covers every input
+	for i := uint32(0); i < 256; i++ {
+		for _, x := range []uint32{i, i << 8, i << 16, i << 24} {
+			if gCompact(x) != g(x) {
+				t.Fatalf("gCompact(%08x)=%08x, wanted %08x", x, gCompact(x), g(x))
+			}
+		}
+	}
+
+	r := mrand.New(mrand.NewSource(3))
+	for i := 0; i < 1000; i++ {
+		x := r.Uint32()
+		if gCompact(x) != g(x) {
+			t.Fatalf("gCompact(%08x)=%08x, wanted %08x", x, gCompact(x), g(x))
+		}
+	}
+}
+
+func TestSEEDCompact(t *testing.T) {
+
+	for _, v := range seedTestVectors {
+		c, err := NewSEEDCompact(v.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var ct, pt [16]byte
+
+		c.Encrypt(ct[:], v.plain)
+		if !bytes.Equal(ct[:], v.cipher) {
+			t.Errorf("compact encrypt failed: got %#v wanted %#v\n", ct, v.cipher)
+		}
+
+		c.Decrypt(pt[:], ct[:])
+		if !bytes.Equal(pt[:], v.plain) {
+			t.Errorf("compact decrypt failed: got %#v wanted %#v\n", pt, v.plain)
+		}
+
+		// the setting survives a round trip through MarshalBinary
+		data, _ := c.MarshalBinary()
+		var c2 SEEDCipher
+		if err := c2.UnmarshalBinary(data); err != nil || !c2.compact {
+			t.Errorf("UnmarshalBinary lost the compact setting: %v", err)
+		}
+	}
+
+	if _, err := NewSEEDCompact(make([]byte, 8)); err != KeySizeError(8) {
+		t.Errorf("NewSEEDCompact(8 byte key)=%v, wanted %v", err, KeySizeError(8))
+	}
+}
+
+// keeps the benchmarked calls from being optimized away
+var gSink uint32
+
+func BenchmarkG(b *testing.B) {
+	var x uint32
+	for i := 0; i < b.N; i++ {
+		x = g(x + uint32(i))
+	}
+	gSink = x
+}
+
+func BenchmarkGCompact(b *testing.B) {
+	var x uint32
+	for i := 0; i < b.N; i++ {
+		x = gCompact(x + uint32(i))
+	}
+	gSink = x
+}
+
+func BenchmarkSEEDCompact(b *testing.B) {
+
+	c, _ := NewSEEDCompact(make([]byte, 16))
+
+	var buf [16]byte
+	b.SetBytes(16)
+	for i := 0; i < b.N; i++ {
+		c.Encrypt(buf[:], buf[:])
+	}
+}
@@ -0,0 +1,47 @@
+package krcrypt
+
+// SEED with little-endian block words
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+import "encoding/binary"
+
+// NewSEEDLittleEndian is like NewSEED, but Encrypt and Decrypt read and write
+// each of the block's four 32-bit words little-endian instead of big-endian.
+// The key schedule and rounds are unchanged.
+//
+// THIS IS NOT STANDARD SEED.  Its ciphertext matches neither RFC 4269 nor any
+// conforming implementation; it exists only to interoperate with the few
+// implementations that got the byte order wrong.  EncryptWords, DecryptWords
+// and EncryptTrace still work on the standard big-endian words.
+func NewSEEDLittleEndian(key []byte) (*SEEDCipher, error) {
+	c := new(SEEDCipher)
+
+	if klen := len(key); klen != 16 {
+		return nil, KeySizeError(klen)
+	}
+
+	c.subkeys(key, &kc)
+	c.little = true
+	return c, nil
+}
+
+// cryptLittleEndian encrypts or decrypts one block with little-endian words
+func (c *SEEDCipher) cryptLittleEndian(dst, src []byte, decrypt bool) {
+
+	crypt := c.encryptWords
+	if decrypt {
+		crypt = c.decryptWords
+	}
+
+	l0, l1, r0, r1 := crypt(
+		binary.LittleEndian.Uint32(src),
+		binary.LittleEndian.Uint32(src[4:]),
+		binary.LittleEndian.Uint32(src[8:]),
+		binary.LittleEndian.Uint32(src[12:]))
+
+	binary.LittleEndian.PutUint32(dst, l0)
+	binary.LittleEndian.PutUint32(dst[4:], l1)
+	binary.LittleEndian.PutUint32(dst[8:], r0)
+	binary.LittleEndian.PutUint32(dst[12:], r1)
+}
@@ -0,0 +1,60 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+// swapWords reverses the bytes of each 32-bit word of a block
+func swapWords(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := 0; i < len(b); i += 4 {
+		out[i], out[i+1], out[i+2], out[i+3] = b[i+3], b[i+2], b[i+1], b[i]
+	}
+	return out
+}
+
+func TestSEEDLittleEndian(t *testing.T) {
+
+	for _, v := range seedTestVectors {
+		c, err := NewSEEDLittleEndian(v.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var ct, pt [16]byte
+
+		c.Encrypt(ct[:], v.plain)
+		if bytes.Equal(ct[:], v.cipher) {
+			t.Errorf("little-endian SEED gave the big-endian ciphertext %x", ct)
+		}
+
+		// it is SEED on the byte-swapped words
+		be, _ := NewSEED(v.key)
+		var want [16]byte
+		be.Encrypt(want[:], swapWords(v.plain))
+		if !bytes.Equal(ct[:], swapWords(want[:])) {
+			t.Errorf("encrypt failed: got %x wanted %x", ct, swapWords(want[:]))
+		}
+
+		c.Decrypt(pt[:], ct[:])
+		if !bytes.Equal(pt[:], v.plain) {
+			t.Errorf("decrypt failed: got %x wanted %x", pt, v.plain)
+		}
+
+		// the byte order survives marshaling
+		b, _ := c.MarshalBinary()
+		var d SEEDCipher
+		if err := d.UnmarshalBinary(b); err != nil {
+			t.Fatal(err)
+		}
+		d.Encrypt(pt[:], v.plain)
+		if pt != ct {
+			t.Errorf("unmarshaled cipher encrypted to %x, wanted %x", pt, ct)
+		}
+	}
+
+	if _, err := NewSEEDLittleEndian(make([]byte, 15)); err != KeySizeError(15) {
+		t.Errorf("NewSEEDLittleEndian(short key)=%v, wanted %v", err, KeySizeError(15))
+	}
+}
@@ -0,0 +1,200 @@
+package krcrypt
+
+// First-order masked SEED
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+http://www.goubin.fr/papers/arith-final.pdf
+https://link.springer.com/chapter/10.1007/3-540-48059-5_15
+
+*/
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// seedMasker holds the mask generator for a masked SEEDCipher
+type seedMasker struct {
+	state uint64
+}
+
+// a masked 32-bit value: v is the value xor m
+type masked32 struct {
+	v, m uint32
+}
+
+// NewSEEDMasked returns a SEEDCipher that computes with first-order Boolean
+// masking, as a countermeasure to differential power analysis.  Every value
+// that depends on both the data and the key is split into two shares, a
+// random mask and the value xored with it, with fresh masks for each block;
+// the S-box tables are recomputed under a fresh mask for each block, and the
+// modular additions go through Goubin's Boolean/arithmetic conversions.  The
+// output is exactly that of standard SEED.
+//
+// The masks come from a generator seeded from rand here; rand is not read
+// again.  Because of the generator's state, a masked SEEDCipher must not be
+// used from more than one goroutine at once.
+//
+// Masking is expensive: encrypting a block takes around 18 times as long as
+// the unmasked cipher (~3.9us vs ~220ns on amd64, see BenchmarkSEEDMasked),
+// mostly in the conversions and in rebuilding the tables.
+func NewSEEDMasked(key []byte, rand io.Reader) (*SEEDCipher, error) {
+	c := new(SEEDCipher)
+
+	if klen := len(key); klen != 16 {
+		return nil, KeySizeError(klen)
+	}
+
+	var seed [8]byte
+	if _, err := io.ReadFull(rand, seed[:]); err != nil {
+		return nil, err
+	}
+
+	c.subkeys(key, &kc)
+	c.mask = &seedMasker{state: binary.LittleEndian.Uint64(seed[:])}
+	return c, nil
+}
+
+// next returns 32 random bits, from splitmix64
+func (s *seedMasker) next() uint32 {
+	s.state += 0x9e3779b97f4a7c15
+	z := s.state
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return uint32(z ^ z>>31)
+}
+
+// seedMaskedTables are the S-box tables for one block, with every input
+// masked by in and the xor of the four outputs masked by out
+type seedMaskedTables struct {
+	t   [4][256]uint32
+	in  uint32
+	out uint32
+}
+
+// crypt encrypts or decrypts one block with masking
+func (s *seedMasker) crypt(c *SEEDCipher, dst, src []byte, decrypt bool) {
+
+	var tab seedMaskedTables
+	s.tables(&tab)
+
+	var l0, l1, r0, r1 masked32
+	for i, w := range []*masked32{&l0, &l1, &r0, &r1} {
+		w.m = s.next()
+		w.v = binary.BigEndian.Uint32(src[4*i:]) ^ w.m
+	}
+
+	n := c.numRounds()
+	for j := 0; j < n; j++ {
+		i := j
+		if decrypt {
+			i = n - 1 - j
+		}
+
+		f0, f1 := s.f(&tab, c.k0[i], c.k1[i], r0, r1)
+		f0 = masked32{l0.v ^ f0.v, l0.m ^ f0.m}
+		f1 = masked32{l1.v ^ f1.v, l1.m ^ f1.m}
+
+		if j == n-1 {
+			l0, l1 = f0, f1
+		} else {
+			l0, l1, r0, r1 = r0, r1, f0, f1
+		}
+	}
+
+	for i, w := range []masked32{l0, l1, r0, r1} {
+		binary.BigEndian.PutUint32(dst[4*i:], w.v^w.m)
+	}
+}
+
+// tables builds the masked S-box tables under fresh masks
+func (s *seedMasker) tables(tab *seedMaskedTables) {
+
+	ss := [4]*[256]uint32{&ss0, &ss1, &ss2, &ss3}
+
+	tab.in = s.next()
+	tab.out = 0
+	for i := range tab.t {
+		in := byte(tab.in >> (8 * uint(i)))
+		out := s.next()
+		tab.out ^= out
+		for x := range tab.t[i] {
+			tab.t[i][x] = ss[i][byte(x)^in] ^ out
+		}
+	}
+}
+
+// g is the G function on a masked value, returning a freshly masked result
+func (s *seedMasker) g(tab *seedMaskedTables, x masked32) masked32 {
+
+	// change the mask to the one the tables expect, without unmasking
+	x.v ^= x.m ^ tab.in
+
+	y := tab.t[0][byte(x.v)] ^ tab.t[1][byte(x.v>>8)] ^ tab.t[2][byte(x.v>>16)] ^ tab.t[3][byte(x.v>>24)]
+
+	r := s.next()
+	return masked32{y ^ r, tab.out ^ r}
+}
+
+// add returns x+y mod 2^32, masked by the sum of their masks
+func (s *seedMasker) add(x, y masked32) masked32 {
+	a := seedBtoA(x.v, x.m, s.next()) + seedBtoA(y.v, y.m, s.next())
+	m := x.m + y.m
+	return masked32{seedAtoB(a, m, s.next()), m}
+}
+
+// the round function, as in f
+func (s *seedMasker) f(tab *seedMaskedTables, k0, k1 uint32, r0, r1 masked32) (masked32, masked32) {
+
+	c := masked32{r0.v ^ k0, r0.m}
+	d := masked32{r1.v ^ k1, r1.m}
+
+	d = masked32{d.v ^ c.v, d.m ^ c.m}
+	d = s.g(tab, d)
+	c = s.add(c, d)
+	c = s.g(tab, c)
+	d = s.add(d, c)
+	d = s.g(tab, d)
+	c = s.add(c, d)
+
+	return c, d
+}
+
+// seedBtoA converts x' = x^r to A = x-r, without ever computing x.  gamma
+// must be random.
+func seedBtoA(xp, r, gamma uint32) uint32 {
+	t := xp ^ gamma
+	t -= gamma
+	t ^= xp
+	gamma ^= r
+	a := xp ^ gamma
+	a -= gamma
+	return a ^ t
+}
+
+// seedAtoB converts A = x-r to x' = x^r, without ever computing x.  gamma
+// must be random.
+func seedAtoB(a, r, gamma uint32) uint32 {
+	t := 2 * gamma
+	xp := gamma ^ r
+	omega := gamma & xp
+	xp = t ^ a
+	gamma ^= xp
+	gamma &= r
+	omega ^= gamma
+	gamma = t & a
+	omega ^= gamma
+	for k := 1; k < 32; k++ {
+		gamma = t & r
+		gamma ^= omega
+		t &= a
+		gamma ^= t
+		t = 2 * gamma
+	}
+	return xp ^ t
+}
@@ -0,0 +1,80 @@
+package krcrypt
+
+import (
+	"crypto/rand"
+	"errors"
+	mrand "math/rand"
+	"testing"
+)
+
+func TestSEEDMasked(t *testing.T) {
+
+	r := mrand.New(mrand.NewSource(1))
+
+	for i := 0; i < 50; i++ {
+		key := make([]byte, 16)
+		r.Read(key)
+
+		plain, _ := NewSEED(key)
+		masked, err := NewSEEDMasked(key, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var p, want, got, back [16]byte
+		r.Read(p[:])
+
+		plain.Encrypt(want[:], p[:])
+		masked.Encrypt(got[:], p[:])
+		if got != want {
+			t.Errorf("masked encrypt(%x)=%x, wanted %x", p, got, want)
+		}
+
+		masked.Decrypt(back[:], got[:])
+		if back != p {
+			t.Errorf("masked decrypt(%x)=%x, wanted %x", got, back, p)
+		}
+	}
+
+	// the masked path must not allocate either
+	c, _ := NewSEEDMasked(make([]byte, 16), rand.Reader)
+	var dst [16]byte
+	if n := testing.AllocsPerRun(100, func() { c.EncryptInto(dst[:], dst[:]) }); n != 0 {
+		t.Errorf("masked EncryptInto allocated %v times per call, wanted 0", n)
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) { return 0, errors.New("no entropy") }
+
+func TestSEEDMaskedRandError(t *testing.T) {
+	if _, err := NewSEEDMasked(make([]byte, 16), failingReader{}); err == nil {
+		t.Errorf("NewSEEDMasked with a failing reader succeeded")
+	}
+}
+
+func TestSEEDMaskConversions(t *testing.T) {
+
+	r := mrand.New(mrand.NewSource(2))
+
+	for i := 0; i < 1000; i++ {
+		x, m, g := r.Uint32(), r.Uint32(), r.Uint32()
+
+		if a := seedBtoA(x^m, m, g); a+m != x {
+			t.Fatalf("seedBtoA(%08x^%08x)=%08x, wanted %08x", x, m, a, x-m)
+		}
+		if xp := seedAtoB(x-m, m, g); xp^m != x {
+			t.Fatalf("seedAtoB(%08x-%08x)=%08x, wanted %08x", x, m, xp, x^m)
+		}
+	}
+}
+
+func BenchmarkSEEDMasked(b *testing.B) {
+	c, _ := NewSEEDMasked(make([]byte, 16), rand.Reader)
+	var buf [16]byte
+	b.SetBytes(16)
+	for i := 0; i < b.N; i++ {
+		c.Encrypt(buf[:], buf[:])
+	}
+}
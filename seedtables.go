@@ -0,0 +1,42 @@
+package krcrypt
+
+// Checking the SEED tables for corruption
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+)
+
+// ErrTableChecksum is returned by VerifyTables when the SEED S-box tables
+// don't match their checksum.
+var ErrTableChecksum = errors.New("krcrypt: SEED table checksum mismatch")
+
+// the FNV-1a checksum of ss0..ss3, each word big-endian
+const seedTablesFNV = 0xc63de6ad
+
+// seedTablesSum returns the FNV-1a checksum of the four extended S-boxes
+func seedTablesSum(ss ...*[256]uint32) uint32 {
+	h := fnv.New32a()
+	var b [4]byte
+	for _, t := range ss {
+		for _, w := range t {
+			binary.BigEndian.PutUint32(b[:], w)
+			h.Write(b[:])
+		}
+	}
+	return h.Sum32()
+}
+
+// VerifyTables checks the SEED S-box tables ss0..ss3 against a checksum
+// computed when they were written, returning ErrTableChecksum if they differ.
+// It catches a bad build or memory corruption, and deployments that can't
+// afford either should call it at startup and refuse to run if it fails.
+func VerifyTables() error {
+	if seedTablesSum(&ss0, &ss1, &ss2, &ss3) != seedTablesFNV {
+		return ErrTableChecksum
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package krcrypt
+
+import "testing"
+
+func TestVerifyTables(t *testing.T) {
+
+	if err := VerifyTables(); err != nil {
+		t.Fatalf("VerifyTables()=%v", err)
+	}
+
+	// corrupt one bit of one table
+	ss2[7] ^= 0x100
+	err := VerifyTables()
+	ss2[7] ^= 0x100
+
+	if err != ErrTableChecksum {
+		t.Errorf("VerifyTables(corrupted)=%v, wanted %v", err, ErrTableChecksum)
+	}
+}
@@ -0,0 +1,144 @@
+package krcrypt
+
+import (
+	"crypto/cipher"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSelfTestAll(t *testing.T) {
+
+	if err := SelfTestAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a cipher giving the wrong answer is named in the error
+	defer func(saved []selfTest) { selfTests = saved }(selfTests)
+
+	broken := selfTests[1]
+	broken.name = "broken SEED"
+	broken.ctor = func(k []byte) (cipher.Block, error) {
+		b, err := NewSEED(k)
+		return brokenBlock{b}, err
+	}
+	selfTests = append(selfTests[:len(selfTests):len(selfTests)], broken)
+
+	err := SelfTestAll()
+	if e, ok := err.(SelfTestError); !ok || len(e) != 1 || e[0] != "broken SEED" {
+		t.Errorf("SelfTestAll=%v, wanted a SelfTestError for broken SEED", err)
+	}
+}
+
+// every constructor returning a block cipher must be called by a known-answer
+// test.  The constructors are found by parsing the package, so a new one
+// can't be forgotten.
+func TestSelfTestCoverage(t *testing.T) {
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var files []*ast.File
+	for _, p := range pkgs {
+		for _, f := range p.Files {
+			files = append(files, f)
+		}
+	}
+
+	// the types with all of cipher.Block's methods
+	methods := make(map[string]int)
+	for _, f := range files {
+		for _, d := range f.Decls {
+			fn, ok := d.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil {
+				continue
+			}
+			switch fn.Name.Name {
+			case "BlockSize", "Encrypt", "Decrypt":
+				methods[receiverName(fn.Recv.List[0].Type)]++
+			}
+		}
+	}
+
+	// the constructors of those types, or of a cipher.Block
+	ctors := make(map[string]bool)
+	for _, f := range files {
+		for _, d := range f.Decls {
+			fn, ok := d.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || !strings.HasPrefix(fn.Name.Name, "New") || fn.Type.Results == nil {
+				continue
+			}
+			switch r := fn.Type.Results.List[0].Type.(type) {
+			case *ast.SelectorExpr:
+				if x, ok := r.X.(*ast.Ident); ok && x.Name == "cipher" && r.Sel.Name == "Block" {
+					ctors[fn.Name.Name] = true
+				}
+			case *ast.StarExpr:
+				if methods[receiverName(r)] == 3 {
+					ctors[fn.Name.Name] = true
+				}
+			}
+		}
+	}
+
+	if !ctors["NewSEED"] || !ctors["NewTwofish"] {
+		t.Fatalf("found constructors %v, which is missing some", ctors)
+	}
+
+	// the functions the entries of selfTests call
+	called := make(map[string]bool)
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			v, ok := n.(*ast.ValueSpec)
+			if !ok || len(v.Names) != 1 || v.Names[0].Name != "selfTests" {
+				return true
+			}
+			ast.Inspect(v, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok {
+					called[id.Name] = true
+				}
+				return true
+			})
+			return false
+		})
+	}
+
+	// a helper called by an entry counts as its callees
+	for _, f := range files {
+		for _, d := range f.Decls {
+			if fn, ok := d.(*ast.FuncDecl); ok && fn.Recv == nil && called[fn.Name.Name] && !ctors[fn.Name.Name] {
+				ast.Inspect(fn.Body, func(n ast.Node) bool {
+					if id, ok := n.(*ast.Ident); ok {
+						called[id.Name] = true
+					}
+					return true
+				})
+			}
+		}
+	}
+
+	for name := range ctors {
+		if !called[name] {
+			t.Errorf("%s has no known-answer test in selfTests", name)
+		}
+	}
+}
+
+// receiverName returns the name of the type T or *T
+func receiverName(e ast.Expr) string {
+	if s, ok := e.(*ast.StarExpr); ok {
+		e = s.X
+	}
+	if id, ok := e.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+// https://www.cosic.esat.kuleuven.be/nessie/testvectors/ "Set 1, vector# 0" for
+// each key size, and one with a full key and block
+var serpentTestVectors = []struct {
+	key    []byte
+	plain  []byte
+	cipher []byte
+}{
+	{
+		[]byte{0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0x26, 0x4e, 0x54, 0x81, 0xef, 0xf4, 0x2a, 0x46, 0x06, 0xab, 0xda, 0x06, 0xc0, 0xbf, 0xda, 0x3d},
+	},
+	{
+		[]byte{0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0x9e, 0x27, 0x4e, 0xad, 0x9b, 0x73, 0x7b, 0xb2, 0x1e, 0xfc, 0xfc, 0xa5, 0x48, 0x60, 0x26, 0x89},
+	},
+	{
+		[]byte{0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0xa2, 0x23, 0xaa, 0x12, 0x88, 0x46, 0x3c, 0x0e, 0x2b, 0xe3, 0x8e, 0xbd, 0x82, 0x56, 0x16, 0xc0},
+	},
+	{
+		[]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f},
+		[]byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+		[]byte{0x28, 0x68, 0xb7, 0xa2, 0xd2, 0x8e, 0xcd, 0x5e, 0x4f, 0xde, 0xfa, 0xc3, 0xc4, 0x33, 0x00, 0x74},
+	},
+}
+
+func TestSerpentEncrypt(t *testing.T) {
+
+	for _, v := range serpentTestVectors {
+		s, err := NewSerpent(v.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var c, p [16]byte
+
+		s.Encrypt(c[:], v.plain)
+
+		if !bytes.Equal(v.cipher, c[:]) {
+			t.Errorf("serpent encrypt failed: got %#v wanted %#v\n", c, v.cipher)
+		}
+
+		s.Decrypt(p[:], c[:])
+
+		if !bytes.Equal(v.plain, p[:]) {
+			t.Errorf("serpent decrypt failed: got %#v wanted %#v\n", p, v.plain)
+		}
+	}
+
+	if _, err := NewSerpent(make([]byte, 20)); err != KeySizeError(20) {
+		t.Errorf("NewSerpent(20 byte key)=%v, wanted %v", err, KeySizeError(20))
+	}
+}
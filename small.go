@@ -0,0 +1,70 @@
+package krcrypt
+
+// Encrypt-then-MAC for small payloads
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+import "io"
+
+const (
+	smallNonceSize = 12
+	smallTagSize   = 16
+)
+
+// SealSmall encrypts plaintext, of any length, with SEED-CTR under a random
+// 12-byte nonce and authenticates the nonce and ciphertext with SEED-CMAC,
+// returning nonce||ciphertext||tag.  There is no padding, so the output is
+// always 28 bytes longer than the input.  Separate encryption and MAC keys
+// are derived from the 16-byte key with SplitKey.
+func SealSmall(key []byte, plaintext []byte, opts ...SealOption) ([]byte, error) {
+
+	encKey, macKey, err := SplitKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, smallNonceSize+len(plaintext), smallNonceSize+len(plaintext)+smallTagSize)
+	if _, err := io.ReadFull(newSealConfig(opts).rand, out[:smallNonceSize]); err != nil {
+		return nil, err
+	}
+
+	iv := make([]byte, 16)
+	copy(iv, out[:smallNonceSize])
+	s, _ := NewCTR(encKey, iv)
+	s.XORKeyStream(out[smallNonceSize:], plaintext)
+
+	mac, _ := NewCMAC(macKey)
+	mac.Write(out)
+	return mac.Sum(out), nil
+}
+
+// OpenSmall checks the tag of a message from SealSmall, in constant time,
+// and only then decrypts it.  It returns ErrAuthentication if the tag is
+// wrong and ErrCiphertextTooShort if there is no room for the nonce and tag.
+func OpenSmall(key []byte, sealed []byte) ([]byte, error) {
+
+	encKey, macKey, err := SplitKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < smallNonceSize+smallTagSize {
+		return nil, ErrCiphertextTooShort
+	}
+
+	body, tag := sealed[:len(sealed)-smallTagSize], sealed[len(sealed)-smallTagSize:]
+
+	mac, _ := NewCMAC(macKey)
+	mac.Write(body)
+	if _, err := verifyAndRelease(mac.Sum(nil), tag, nil); err != nil {
+		return nil, err
+	}
+
+	iv := make([]byte, 16)
+	copy(iv, body[:smallNonceSize])
+	s, _ := NewCTR(encKey, iv)
+
+	plain := make([]byte, len(body)-smallNonceSize)
+	s.XORKeyStream(plain, body[smallNonceSize:])
+	return plain, nil
+}
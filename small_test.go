@@ -0,0 +1,47 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSealSmall(t *testing.T) {
+
+	key := []byte("0123456789abcdef")
+
+	for _, n := range []int{0, 1, 15, 16, 17, 100} {
+		plain := bytes.Repeat([]byte{'x'}, n)
+
+		sealed, err := SealSmall(key, plain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sealed) != n+28 {
+			t.Errorf("SealSmall(%d bytes) gave %d bytes, wanted %d", n, len(sealed), n+28)
+		}
+
+		got, err := OpenSmall(key, sealed)
+		if err != nil || !bytes.Equal(got, plain) {
+			t.Errorf("OpenSmall(SealSmall(%d bytes))=%q, %v", n, got, err)
+		}
+
+		for _, i := range []int{0, 11, 12, len(sealed) - 1} {
+			if i >= len(sealed) {
+				continue
+			}
+			bad := append([]byte(nil), sealed...)
+			bad[i] ^= 1
+			if _, err := OpenSmall(key, bad); err != ErrAuthentication {
+				t.Errorf("OpenSmall with byte %d of %d changed=%v, wanted %v", i, len(sealed), err, ErrAuthentication)
+			}
+		}
+	}
+
+	if _, err := OpenSmall(key, make([]byte, 27)); err != ErrCiphertextTooShort {
+		t.Errorf("OpenSmall(27 bytes)=%v, wanted %v", err, ErrCiphertextTooShort)
+	}
+
+	if _, err := SealSmall(key[:8], nil); err != KeySizeError(8) {
+		t.Errorf("SealSmall(8-byte key)=%v, wanted %v", err, KeySizeError(8))
+	}
+}
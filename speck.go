@@ -0,0 +1,317 @@
+package krcrypt
+
+// The Speck family of block ciphers from the NSA
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+https://eprint.iacr.org/2013/404.pdf
+https://nsacyber.github.io/simon-speck/implementations/ImplementationGuide1.1.pdf
+
+*/
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"strconv"
+)
+
+// A speckCipher is an instance of Speck with a particular block size and key.
+// Words are held in uint64s and masked down to the word size.
+type speckCipher struct {
+	n     uint   // word size in bits
+	bs    int    // block size in bytes
+	mask  uint64 // n one bits
+	alpha uint   // rotation amounts
+	beta  uint
+	rk    []uint64 // round keys
+}
+
+// BlockSizeError is returned for unsupported block sizes
+type BlockSizeError int
+
+func (b BlockSizeError) Error() string {
+	return "krcrypt: invalid block size " + strconv.Itoa(int(b))
+}
+
+// the number of rounds for each block size and number of key words
+var speckRounds = map[int]map[int]int{
+	32:  {4: 22},
+	48:  {3: 22, 4: 23},
+	64:  {3: 26, 4: 27},
+	96:  {2: 28, 3: 29},
+	128: {2: 32, 3: 33, 4: 34},
+}
+
+// SpeckParams reports the number of rounds and the word size in bits of the
+// Speck variant with the given block and key sizes in bits, or ok=false if
+// there is no such variant, so a combination can be checked before calling
+// NewSpeck.
+func SpeckParams(blockBits, keyBits int) (rounds, wordBits int, ok bool) {
+	wordBits = blockBits / 2
+	byKey, found := speckRounds[blockBits]
+	if !found || keyBits%wordBits != 0 {
+		return 0, 0, false
+	}
+	rounds, ok = byKey[keyBits/wordBits]
+	if !ok {
+		return 0, 0, false
+	}
+	return rounds, wordBits, true
+}
+
+// NewSpeck creates and returns a new cipher.Block implementing Speck with the
+// given block size in bits (32, 48, 64, 96 or 128).  The key length selects
+// the variant, and must be 2, 3 or 4 words as allowed for the block size; for
+// example Speck128 takes 16, 24 or 32 byte keys.
+//
+// Blocks and keys are serialized as in the implementation guide: each word is
+// little-endian, with the block's y word first and the key's k0 word first.
+func NewSpeck(key []byte, blockBits int) (cipher.Block, error) {
+
+	byKey, ok := speckRounds[blockBits]
+	if !ok {
+		return nil, BlockSizeError(blockBits)
+	}
+
+	n := uint(blockBits / 2)
+	wb := int(n / 8) // word size in bytes
+
+	m := len(key) / wb
+	rounds, ok := byKey[m]
+	if !ok || len(key)%wb != 0 {
+		return nil, KeySizeError(len(key))
+	}
+
+	c := &speckCipher{
+		n:     n,
+		bs:    blockBits / 8,
+		mask:  1<<n - 1,
+		alpha: 8,
+		beta:  3,
+		rk:    make([]uint64, rounds),
+	}
+	if n == 16 {
+		c.alpha, c.beta = 7, 2
+	}
+
+	// k0 is first in the key, then l0, l1, ...
+	l := make([]uint64, m-1, m-1+rounds)
+	for i := range l {
+		l[i] = c.load(key[(i+1)*wb:])
+	}
+	c.rk[0] = c.load(key)
+
+	for i := 0; i < rounds-1; i++ {
+		l = append(l, (c.rk[i]+c.rotr(l[i], c.alpha))&c.mask^uint64(i))
+		c.rk[i+1] = c.rotl(c.rk[i], c.beta) ^ l[i+m-1]
+	}
+
+	return c, nil
+}
+
+// NewSpeckGCM returns Speck in Galois/Counter Mode with the standard 12-byte
+// nonce and 16-byte tag.  GCM is only defined over 128-bit blocks, so
+// blockBits must be 128, and the key 16, 24 or 32 bytes; a smaller Speck is
+// rejected with an error wrapping BlockSizeError.
+func NewSpeckGCM(key []byte, blockBits int) (cipher.AEAD, error) {
+	if blockBits != 128 {
+		return nil, fmt.Errorf("krcrypt: NewSpeckGCM: GCM needs a 128-bit block: %w", BlockSizeError(blockBits))
+	}
+	block, err := NewSpeck(key, blockBits)
+	if err != nil {
+		return nil, fmt.Errorf("krcrypt: NewSpeckGCM: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// Reset zeroes the round keys.
+func (c *speckCipher) Reset() {
+	for i := range c.rk {
+		c.rk[i] = 0
+	}
+}
+
+// IsWiped reports whether the round keys are all zero.
+func (c *speckCipher) IsWiped() bool {
+	for _, k := range c.rk {
+		if k != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *speckCipher) BlockSize() int { return c.bs }
+
+func (c *speckCipher) rotl(x uint64, r uint) uint64 { return (x<<r | x>>(c.n-r)) & c.mask }
+func (c *speckCipher) rotr(x uint64, r uint) uint64 { return (x>>r | x<<(c.n-r)) & c.mask }
+
+// load a little-endian word
+func (c *speckCipher) load(b []byte) uint64 {
+	var buf [8]byte
+	copy(buf[:], b[:c.n/8])
+	return binary.LittleEndian.Uint64(buf[:])
+}
+
+// store a little-endian word
+func (c *speckCipher) store(b []byte, x uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], x)
+	copy(b, buf[:c.n/8])
+}
+
+// Encrypt encrypts the block in src and stores the resulting ciphertext in dst.
+func (c *speckCipher) Encrypt(dst, src []byte) {
+
+	wb := int(c.n / 8)
+	y := c.load(src)
+	x := c.load(src[wb:])
+
+	for _, k := range c.rk {
+		x = (c.rotr(x, c.alpha)+y)&c.mask ^ k
+		y = c.rotl(y, c.beta) ^ x
+	}
+
+	c.store(dst, y)
+	c.store(dst[wb:], x)
+}
+
+// Decrypt decrypts the block in src and stores the resulting plaintext in dst.
+func (c *speckCipher) Decrypt(dst, src []byte) {
+
+	wb := int(c.n / 8)
+	y := c.load(src)
+	x := c.load(src[wb:])
+
+	for i := len(c.rk) - 1; i >= 0; i-- {
+		y = c.rotr(y^x, c.beta)
+		x = c.rotl((x^c.rk[i]-y)&c.mask, c.alpha)
+	}
+
+	c.store(dst, y)
+	c.store(dst[wb:], x)
+}
+
+// how many blocks of keystream speckCTR generates at once
+const speckLanes = 4
+
+// speckCTR is counter mode for Speck, computing four keystream blocks at a
+// time with their rounds interleaved.  The rounds of independent blocks don't
+// depend on each other, so the CPU can overlap them.
+type speckCTR struct {
+	c    *speckCipher
+	ctr  []byte // the next counter block
+	ks   []byte // buffered keystream
+	used int    // how much of ks has been consumed
+}
+
+// NewSpeckCTR returns a cipher.Stream for Speck in counter mode, with the
+// given block size.  The iv is the initial counter block, which is incremented
+// as a big-endian integer exactly as cipher.NewCTR does, so the output is the
+// same as cipher.NewCTR over NewSpeck, only faster.
+func NewSpeckCTR(key, iv []byte, blockBits int) (cipher.Stream, error) {
+
+	b, err := NewSpeck(key, blockBits)
+	if err != nil {
+		return nil, err
+	}
+
+	c := b.(*speckCipher)
+	if len(iv) != c.bs {
+		return nil, IVSizeError(len(iv))
+	}
+
+	ks := make([]byte, speckLanes*c.bs)
+	return &speckCTR{
+		c:    c,
+		ctr:  append([]byte(nil), iv...),
+		ks:   ks,
+		used: len(ks),
+	}, nil
+}
+
+func (s *speckCTR) XORKeyStream(dst, src []byte) {
+
+	if len(dst) < len(src) {
+		panic("krcrypt: output smaller than input")
+	}
+
+	for len(src) > 0 {
+		if s.used == len(s.ks) {
+			s.refill()
+		}
+		n := len(s.ks) - s.used
+		if n > len(src) {
+			n = len(src)
+		}
+		xorslice(dst[:n], src[:n], s.ks[s.used:s.used+n])
+		s.used += n
+		dst, src = dst[n:], src[n:]
+	}
+}
+
+// refill encrypts the next four counter blocks into s.ks
+func (s *speckCTR) refill() {
+
+	c := s.c
+	wb := int(c.n / 8)
+
+	var x, y [speckLanes]uint64
+	for l := 0; l < speckLanes; l++ {
+		y[l] = c.load(s.ctr)
+		x[l] = c.load(s.ctr[wb:])
+		incCounter(s.ctr)
+	}
+
+	if c.n == 64 {
+		speckRounds64(&x, &y, c.rk)
+	} else {
+		speckRoundsN(c, &x, &y)
+	}
+
+	for l := 0; l < speckLanes; l++ {
+		c.store(s.ks[l*c.bs:], y[l])
+		c.store(s.ks[l*c.bs+wb:], x[l])
+	}
+	s.used = 0
+}
+
+// speckRounds64 runs the rounds for four Speck128 blocks.  With 64-bit words
+// there's no masking to do and the rotations are constants.
+func speckRounds64(x, y *[speckLanes]uint64, rk []uint64) {
+	x0, x1, x2, x3 := x[0], x[1], x[2], x[3]
+	y0, y1, y2, y3 := y[0], y[1], y[2], y[3]
+	for _, k := range rk {
+		x0 = (bits.RotateLeft64(x0, -8) + y0) ^ k
+		x1 = (bits.RotateLeft64(x1, -8) + y1) ^ k
+		x2 = (bits.RotateLeft64(x2, -8) + y2) ^ k
+		x3 = (bits.RotateLeft64(x3, -8) + y3) ^ k
+		y0 = bits.RotateLeft64(y0, 3) ^ x0
+		y1 = bits.RotateLeft64(y1, 3) ^ x1
+		y2 = bits.RotateLeft64(y2, 3) ^ x2
+		y3 = bits.RotateLeft64(y3, 3) ^ x3
+	}
+	x[0], x[1], x[2], x[3] = x0, x1, x2, x3
+	y[0], y[1], y[2], y[3] = y0, y1, y2, y3
+}
+
+// speckRoundsN runs the rounds for four blocks of any word size
+func speckRoundsN(c *speckCipher, x, y *[speckLanes]uint64) {
+	mask, alpha, beta := c.mask, c.alpha, c.beta
+	for _, k := range c.rk {
+		x[0] = (c.rotr(x[0], alpha)+y[0])&mask ^ k
+		x[1] = (c.rotr(x[1], alpha)+y[1])&mask ^ k
+		x[2] = (c.rotr(x[2], alpha)+y[2])&mask ^ k
+		x[3] = (c.rotr(x[3], alpha)+y[3])&mask ^ k
+		y[0] = c.rotl(y[0], beta) ^ x[0]
+		y[1] = c.rotl(y[1], beta) ^ x[1]
+		y[2] = c.rotl(y[2], beta) ^ x[2]
+		y[3] = c.rotl(y[3], beta) ^ x[3]
+	}
+}
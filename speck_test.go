@@ -0,0 +1,222 @@
+package krcrypt
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// https://eprint.iacr.org/2013/404.pdf, Appendix C.  The values are written
+// as in the paper, most significant word first.
+var speckTestVectors = []struct {
+	blockBits int
+	key       string
+	plain     string
+	cipher    string
+}{
+	{32, "1918 1110 0908 0100", "6574 694c", "a868 42f2"},
+	{48, "121110 0a0908 020100", "20796c 6c6172", "c049a5 385adc"},
+	{48, "1a1918 121110 0a0908 020100", "6d2073 696874", "735e10 b6445d"},
+	{64, "13121110 0b0a0908 03020100", "74614620 736e6165", "9f7952ec 4175946c"},
+	{64, "1b1a1918 13121110 0b0a0908 03020100", "3b726574 7475432d", "8c6fa548 454e028b"},
+	{96, "0d0c0b0a0908 050403020100", "65776f68202c 656761737520", "9e4d09ab7178 62bdde8f79aa"},
+	{96, "151413121110 0d0c0b0a0908 050403020100", "656d6974206e 69202c726576", "2bf31072228a 7ae440252ee6"},
+	{128, "0f0e0d0c0b0a0908 0706050403020100", "6c61766975716520 7469206564616d20", "a65d985179783265 7860fedf5c570d18"},
+	{128, "1716151413121110 0f0e0d0c0b0a0908 0706050403020100", "7261482066656968 43206f7420746e65", "1be4cf3a13135566 f9bc185de03c1886"},
+	{128, "1f1e1d1c1b1a1918 1716151413121110 0f0e0d0c0b0a0908 0706050403020100", "65736f6874206e49 202e72656e6f6f70", "4109010405c0f53e 4eeeb48d9c188f43"},
+}
+
+// speckBytes converts the paper's word notation to the byte serialization,
+// which is little-endian words with the last word first: a byte reversal.
+func speckBytes(s string) []byte {
+	b, err := hex.DecodeString(strings.Replace(s, " ", "", -1))
+	if err != nil {
+		panic(err)
+	}
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return b
+}
+
+func TestSpeck(t *testing.T) {
+
+	for _, v := range speckTestVectors {
+		key, plain, want := speckBytes(v.key), speckBytes(v.plain), speckBytes(v.cipher)
+
+		s, err := NewSpeck(key, v.blockBits)
+		if err != nil {
+			t.Fatalf("Speck%d/%d: %v", v.blockBits, len(key)*8, err)
+		}
+
+		c := make([]byte, len(plain))
+		s.Encrypt(c, plain)
+		if !bytes.Equal(c, want) {
+			t.Errorf("Speck%d/%d encrypt failed: got %x wanted %x", v.blockBits, len(key)*8, c, want)
+		}
+
+		p := make([]byte, len(plain))
+		s.Decrypt(p, c)
+		if !bytes.Equal(p, plain) {
+			t.Errorf("Speck%d/%d decrypt failed: got %x wanted %x", v.blockBits, len(key)*8, p, plain)
+		}
+	}
+}
+
+func TestSpeckErrors(t *testing.T) {
+
+	if _, err := NewSpeck(make([]byte, 16), 256); err != BlockSizeError(256) {
+		t.Errorf("NewSpeck(256 bits)=%v, wanted %v", err, BlockSizeError(256))
+	}
+
+	if _, err := NewSpeck(make([]byte, 8), 128); err != KeySizeError(8) {
+		t.Errorf("NewSpeck(8 byte key)=%v, wanted %v", err, KeySizeError(8))
+	}
+}
+
+func TestSpeckParams(t *testing.T) {
+
+	// Table 3.1 of the paper
+	for _, v := range []struct {
+		blockBits, keyBits, rounds int
+	}{
+		{32, 64, 22},
+		{48, 72, 22}, {48, 96, 23},
+		{64, 96, 26}, {64, 128, 27},
+		{96, 96, 28}, {96, 144, 29},
+		{128, 128, 32}, {128, 192, 33}, {128, 256, 34},
+	} {
+		rounds, wordBits, ok := SpeckParams(v.blockBits, v.keyBits)
+		if !ok || rounds != v.rounds || wordBits != v.blockBits/2 {
+			t.Errorf("SpeckParams(%d, %d)=(%d, %d, %v), wanted (%d, %d, true)", v.blockBits, v.keyBits, rounds, wordBits, ok, v.rounds, v.blockBits/2)
+		}
+
+		// and NewSpeck agrees
+		if _, err := NewSpeck(make([]byte, v.keyBits/8), v.blockBits); err != nil {
+			t.Errorf("NewSpeck(%d, %d): %v", v.blockBits, v.keyBits, err)
+		}
+	}
+
+	for _, v := range [][2]int{{32, 96}, {64, 64}, {128, 100}, {128, 512}, {256, 256}, {0, 0}} {
+		if r, w, ok := SpeckParams(v[0], v[1]); ok || r != 0 || w != 0 {
+			t.Errorf("SpeckParams(%d, %d)=(%d, %d, %v), wanted (0, 0, false)", v[0], v[1], r, w, ok)
+		}
+	}
+}
+
+func TestSpeckGCM(t *testing.T) {
+
+	nonce := make([]byte, 12)
+	plain := []byte("attack at dawn")
+	aad := []byte("header")
+
+	for _, n := range []int{16, 24, 32} {
+		key := make([]byte, n)
+		for i := range key {
+			key[i] = byte(i)
+		}
+
+		g, err := NewSpeckGCM(key, 128)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sealed := g.Seal(nil, nonce, plain, aad)
+		if got, err := g.Open(nil, nonce, sealed, aad); err != nil || !bytes.Equal(got, plain) {
+			t.Errorf("Speck128/%d: Open=(%q,%v), wanted %q", n*8, got, err, plain)
+		}
+
+		// the ciphertext part is Speck-CTR from counter 2
+		iv := append(nonce[:12:12], 0, 0, 0, 2)
+		want := make([]byte, len(plain))
+		b, _ := NewSpeck(key, 128)
+		cipher.NewCTR(b, iv).XORKeyStream(want, plain)
+		if !bytes.Equal(sealed[:len(plain)], want) {
+			t.Errorf("Speck128/%d: ciphertext %x, wanted %x", n*8, sealed[:len(plain)], want)
+		}
+
+		sealed[0] ^= 1
+		if _, err := g.Open(nil, nonce, sealed, aad); err == nil {
+			t.Errorf("Speck128/%d: Open accepted a tampered ciphertext", n*8)
+		}
+	}
+
+	if _, err := NewSpeckGCM(make([]byte, 16), 64); !errors.Is(err, BlockSizeError(64)) {
+		t.Errorf("NewSpeckGCM(64 bits)=%v, wanted %v", err, BlockSizeError(64))
+	}
+
+	if _, err := NewSpeckGCM(make([]byte, 8), 128); !errors.Is(err, KeySizeError(8)) {
+		t.Errorf("NewSpeckGCM(8 byte key)=%v, wanted %v", err, KeySizeError(8))
+	}
+}
+
+func TestSpeckCTR(t *testing.T) {
+
+	for _, v := range speckTestVectors {
+		key := speckBytes(v.key)
+		bs := v.blockBits / 8
+
+		// start near the top so the counter wraps
+		iv := make([]byte, bs)
+		for i := range iv {
+			iv[i] = 0xff
+		}
+		iv[bs-1] = 0xfd
+
+		b, _ := NewSpeck(key, v.blockBits)
+
+		for _, n := range []int{1, bs, 4*bs - 1, 4 * bs, 100} {
+			src := make([]byte, n)
+			for i := range src {
+				src[i] = byte(i)
+			}
+
+			want := make([]byte, n)
+			cipher.NewCTR(b, iv).XORKeyStream(want, src)
+
+			s, err := NewSpeckCTR(key, iv, v.blockBits)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// feed it in odd-sized pieces
+			got := make([]byte, n)
+			for i := 0; i < n; i += 7 {
+				j := i + 7
+				if j > n {
+					j = n
+				}
+				s.XORKeyStream(got[i:j], src[i:j])
+			}
+
+			if !bytes.Equal(got, want) {
+				t.Errorf("Speck%d/%d CTR len=%d: got %x wanted %x", v.blockBits, len(key)*8, n, got, want)
+			}
+		}
+	}
+
+	if _, err := NewSpeckCTR(make([]byte, 16), make([]byte, 8), 128); err != IVSizeError(8) {
+		t.Errorf("NewSpeckCTR(short iv)=%v, wanted %v", err, IVSizeError(8))
+	}
+}
+
+func benchmarkSpeckStream(b *testing.B, s cipher.Stream) {
+	buf := make([]byte, 4096)
+	b.SetBytes(int64(len(buf)))
+	for i := 0; i < b.N; i++ {
+		s.XORKeyStream(buf, buf)
+	}
+}
+
+func BenchmarkSpeckCTR(b *testing.B) {
+	s, _ := NewSpeckCTR(make([]byte, 16), make([]byte, 16), 128)
+	benchmarkSpeckStream(b, s)
+}
+
+func BenchmarkSpeckCTRSequential(b *testing.B) {
+	block, _ := NewSpeck(make([]byte, 16), 128)
+	benchmarkSpeckStream(b, cipher.NewCTR(block, make([]byte, 16)))
+}
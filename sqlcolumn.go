@@ -0,0 +1,67 @@
+package krcrypt
+
+// Transparently encrypted database columns
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+)
+
+// ErrNoColumnKey is returned when an EncryptedString is stored or loaded before SetColumnKey has been called.
+var ErrNoColumnKey = errors.New("krcrypt: no column key set")
+
+var columnKey = keyedAEAD{errNoKey: ErrNoColumnKey}
+
+// SetColumnKey sets the 16-byte SEED key used by all EncryptedString values.
+func SetColumnKey(key []byte) error {
+	return columnKey.set(key)
+}
+
+// An EncryptedString is a string that is encrypted with SEED-GCM when written
+// to a database and decrypted when read back.  It is stored as base64 text of
+// the random nonce followed by the ciphertext, so any text column will do.
+type EncryptedString string
+
+var (
+	_ driver.Valuer = EncryptedString("")
+	_ sql.Scanner   = (*EncryptedString)(nil)
+)
+
+// Value implements driver.Valuer.
+func (s EncryptedString) Value() (driver.Value, error) {
+
+	text, err := columnKey.sealText([]byte(s))
+	if err != nil {
+		return nil, err
+	}
+
+	return string(text), nil
+}
+
+// Scan implements sql.Scanner.  A NULL column scans as the empty string.
+func (s *EncryptedString) Scan(src interface{}) error {
+
+	var text []byte
+	switch v := src.(type) {
+	case nil:
+		*s = ""
+		return nil
+	case string:
+		text = []byte(v)
+	case []byte:
+		text = v
+	default:
+		return errors.New("krcrypt: cannot scan EncryptedString from non-text column")
+	}
+
+	plain, err := columnKey.openText(text)
+	if err != nil {
+		return err
+	}
+
+	*s = EncryptedString(plain)
+	return nil
+}
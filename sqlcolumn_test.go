@@ -0,0 +1,136 @@
+package krcrypt
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// A tiny in-memory database/sql driver with a single text column.  "INSERT"
+// appends its argument; anything else selects every stored value.
+type memDriver struct {
+	sync.Mutex
+	rows []driver.Value
+}
+
+type memConn struct{ d *memDriver }
+type memStmt struct {
+	d     *memDriver
+	query string
+}
+type memRows struct {
+	vals []driver.Value
+}
+
+func (d *memDriver) Open(string) (driver.Conn, error) { return memConn{d}, nil }
+
+func (c memConn) Prepare(q string) (driver.Stmt, error) { return memStmt{c.d, q}, nil }
+func (c memConn) Close() error                          { return nil }
+func (c memConn) Begin() (driver.Tx, error)             { return nil, errors.New("no transactions") }
+
+func (s memStmt) Close() error  { return nil }
+func (s memStmt) NumInput() int { return strings.Count(s.query, "?") }
+
+func (s memStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.Lock()
+	defer s.d.Unlock()
+	s.d.rows = append(s.d.rows, args[0])
+	return driver.RowsAffected(1), nil
+}
+
+func (s memStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.d.Lock()
+	defer s.d.Unlock()
+	return &memRows{append([]driver.Value(nil), s.d.rows...)}, nil
+}
+
+func (r *memRows) Columns() []string { return []string{"v"} }
+func (r *memRows) Close() error      { return nil }
+func (r *memRows) Next(dest []driver.Value) error {
+	if len(r.vals) == 0 {
+		return io.EOF
+	}
+	dest[0], r.vals = r.vals[0], r.vals[1:]
+	return nil
+}
+
+var memDB = &memDriver{}
+
+func init() {
+	sql.Register("krcrypt-mem", memDB)
+}
+
+func TestEncryptedString(t *testing.T) {
+
+	if err := SetColumnKey([]byte("0123456789abcdef")); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("krcrypt-mem", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	const secret = "4111 1111 1111 1111"
+
+	if _, err := db.Exec("INSERT ?", EncryptedString(secret)); err != nil {
+		t.Fatal(err)
+	}
+
+	if stored := memDB.rows[len(memDB.rows)-1].(string); strings.Contains(stored, secret) {
+		t.Errorf("plaintext stored in the database: %q", stored)
+	}
+
+	var got EncryptedString
+	if err := db.QueryRow("SELECT").Scan(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got != secret {
+		t.Errorf("round trip=%q, wanted %q", got, secret)
+	}
+}
+
+func TestEncryptedStringNull(t *testing.T) {
+
+	if err := SetColumnKey([]byte("0123456789abcdef")); err != nil {
+		t.Fatal(err)
+	}
+
+	s := EncryptedString("stale")
+	if err := s.Scan(nil); err != nil || s != "" {
+		t.Errorf("Scan(nil)=%v, left %q, wanted nil and empty", err, s)
+	}
+
+	if err := s.Scan(42); err == nil {
+		t.Errorf("Scan(int) succeeded")
+	}
+}
+
+func TestEncryptedStringNoKey(t *testing.T) {
+
+	columnKey.Lock()
+	saved := columnKey.aead
+	columnKey.aead = nil
+	columnKey.Unlock()
+
+	defer func() {
+		columnKey.Lock()
+		columnKey.aead = saved
+		columnKey.Unlock()
+	}()
+
+	if _, err := EncryptedString("x").Value(); err != ErrNoColumnKey {
+		t.Errorf("Value without key=%v, wanted %v", err, ErrNoColumnKey)
+	}
+
+	var s EncryptedString
+	if err := s.Scan("AAAA"); err != ErrNoColumnKey {
+		t.Errorf("Scan without key=%v, wanted %v", err, ErrNoColumnKey)
+	}
+}
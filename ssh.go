@@ -0,0 +1,51 @@
+package krcrypt
+
+// SEED ciphers shaped for SSH transports
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+https://tools.ietf.org/html/rfc4253#section-6.3 (CBC)
+https://tools.ietf.org/html/rfc4344#section-4 (CTR)
+
+*/
+
+import "crypto/cipher"
+
+// NewSSHCipher returns SEED in counter mode as SSH uses it (RFC 4344): the
+// 16-byte iv derived during key exchange is the initial counter block,
+// incremented as a big-endian integer, and the one stream runs across every
+// packet in a direction.  The key must be 16 bytes.
+//
+// SEED is not an IANA-registered SSH cipher, so this is for experiments and
+// testing only; both ends have to agree on a private name for it, such as
+// "seed-ctr@example.com".
+func NewSSHCipher(key, iv []byte) (cipher.Stream, error) {
+	return NewCTR(key, iv)
+}
+
+// NewSSHBlockMode returns SEED in CBC mode as SSH uses it (RFC 4253), for
+// encryption or, if decrypt is set, decryption.  The chaining carries on from
+// one packet to the next, as the returned cipher.BlockMode does across calls
+// to CryptBlocks.  Like NewSSHCipher this is not a registered SSH cipher; and
+// SSH's CBC modes are deprecated anyway, since they leak information to
+// attackers who can inject traffic, so prefer NewSSHCipher.
+func NewSSHBlockMode(key, iv []byte, decrypt bool) (cipher.BlockMode, error) {
+
+	block, err := NewSEED(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(iv) != block.BlockSize() {
+		return nil, IVSizeError(len(iv))
+	}
+
+	if decrypt {
+		return cipher.NewCBCDecrypter(block, iv), nil
+	}
+	return cipher.NewCBCEncrypter(block, iv), nil
+}
@@ -0,0 +1,75 @@
+package krcrypt
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"errors"
+	"testing"
+)
+
+func TestSSHCipher(t *testing.T) {
+
+	key := []byte("YELLOW SUBMARINE")
+	iv := append(make([]byte, 12), 0xff, 0xff, 0xff, 0xfe)
+
+	s, err := NewSSHCipher(key, iv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, _ := NewSEED(key)
+	ref := cipher.NewCTR(block, iv)
+
+	// packets of assorted sizes, as a transport would send them
+	for _, n := range []int{5, 16, 32, 100, 1, 64} {
+		msg := bytes.Repeat([]byte{byte(n)}, n)
+		got := make([]byte, n)
+		want := make([]byte, n)
+		s.XORKeyStream(got, msg)
+		ref.XORKeyStream(want, msg)
+		if !bytes.Equal(got, want) {
+			t.Fatalf("SSH CTR packet of %d bytes differs from cipher.NewCTR", n)
+		}
+	}
+
+	if _, err := NewSSHCipher(key, iv[:8]); !errors.Is(err, IVSizeError(8)) {
+		t.Errorf("NewSSHCipher(8-byte iv)=%v, wanted %v", err, IVSizeError(8))
+	}
+}
+
+func TestSSHBlockMode(t *testing.T) {
+
+	key := []byte("YELLOW SUBMARINE")
+	iv := make([]byte, 16)
+
+	enc, err := NewSSHBlockMode(key, iv, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec, _ := NewSSHBlockMode(key, iv, true)
+
+	// chaining carries over between packets
+	p1 := bytes.Repeat([]byte{1}, 32)
+	p2 := bytes.Repeat([]byte{2}, 48)
+	c1 := make([]byte, len(p1))
+	c2 := make([]byte, len(p2))
+	enc.CryptBlocks(c1, p1)
+	enc.CryptBlocks(c2, p2)
+
+	block, _ := NewSEED(key)
+	all := make([]byte, len(p1)+len(p2))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(all, append(append([]byte(nil), p1...), p2...))
+	if !bytes.Equal(all, append(c1, c2...)) {
+		t.Errorf("SSH CBC doesn't chain across packets")
+	}
+
+	dec.CryptBlocks(c1, c1)
+	dec.CryptBlocks(c2, c2)
+	if !bytes.Equal(c1, p1) || !bytes.Equal(c2, p2) {
+		t.Errorf("SSH CBC decrypt failed")
+	}
+
+	if _, err := NewSSHBlockMode(key, iv[:15], false); err != IVSizeError(15) {
+		t.Errorf("NewSSHBlockMode(15-byte iv)=%v, wanted %v", err, IVSizeError(15))
+	}
+}
@@ -0,0 +1,84 @@
+package krcrypt
+
+// Serialized cipher state
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+import (
+	"crypto/cipher"
+	"encoding"
+	"encoding/gob"
+	"errors"
+)
+
+// ErrState is returned when a serialized cipher state is malformed or from an
+// incompatible version.
+var ErrState = errors.New("krcrypt: invalid cipher state")
+
+// AlgorithmError is returned by State.Block for an algorithm it doesn't know.
+type AlgorithmError string
+
+func (a AlgorithmError) Error() string {
+	return "krcrypt: unknown algorithm " + string(a)
+}
+
+func init() {
+	// so a *SEEDCipher can travel in a cipher.Block interface value
+	gob.Register(&SEEDCipher{})
+}
+
+// A State is a cipher's precomputed key schedule tagged with its algorithm,
+// so that a receiver can rebuild the right cipher from it without the key.
+// It encodes with encoding/gob or any other encoder handling exported fields.
+// Like the key itself, it must be kept secret.
+type State struct {
+	Algorithm string
+	Data      []byte
+}
+
+// stateCiphers maps each algorithm tag to a constructor for an empty cipher
+var stateCiphers = map[string]func() stateCipher{
+	"SEED": func() stateCipher { return new(SEEDCipher) },
+}
+
+type stateCipher interface {
+	cipher.Block
+	encoding.BinaryUnmarshaler
+}
+
+// NewState captures the state of b, which must be one of the package's ciphers
+// that supports marshaling (currently only SEED).
+func NewState(b cipher.Block) (*State, error) {
+
+	var alg string
+	switch b.(type) {
+	case *SEEDCipher:
+		alg = "SEED"
+	default:
+		return nil, errors.New("krcrypt: cipher doesn't support saving its state")
+	}
+
+	data, err := b.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	return &State{Algorithm: alg, Data: data}, nil
+}
+
+// Block rebuilds the cipher, returning an AlgorithmError for an unknown
+// algorithm and ErrState if the data doesn't fit it.
+func (s *State) Block() (cipher.Block, error) {
+
+	ctor, ok := stateCiphers[s.Algorithm]
+	if !ok {
+		return nil, AlgorithmError(s.Algorithm)
+	}
+
+	c := ctor()
+	if err := c.UnmarshalBinary(s.Data); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
@@ -0,0 +1,96 @@
+package krcrypt
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/gob"
+	"testing"
+)
+
+func TestStateGob(t *testing.T) {
+
+	v := seedTestVectors[0]
+
+	c, _ := NewSEEDRounds(v.key, 12)
+	st, err := NewState(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(st); err != nil {
+		t.Fatal(err)
+	}
+
+	var got State
+	if err := gob.NewDecoder(&buf).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := got.Block()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want, ct [16]byte
+	c.Encrypt(want[:], v.plain)
+	b.Encrypt(ct[:], v.plain)
+	if want != ct {
+		t.Errorf("restored cipher encrypt=%x, wanted %x", ct, want)
+	}
+}
+
+func TestStateGobInterface(t *testing.T) {
+
+	v := seedTestVectors[0]
+	c, _ := NewSEED(v.key)
+
+	// the registered type can be sent as a cipher.Block
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&c); err != nil {
+		t.Fatal(err)
+	}
+
+	var b cipher.Block
+	if err := gob.NewDecoder(&buf).Decode(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	var ct [16]byte
+	b.Encrypt(ct[:], v.plain)
+	if !bytes.Equal(ct[:], v.cipher) {
+		t.Errorf("decoded cipher encrypt=%x, wanted %x", ct, v.cipher)
+	}
+}
+
+func TestStateErrors(t *testing.T) {
+
+	if _, err := (&State{Algorithm: "Lucifer"}).Block(); err != AlgorithmError("Lucifer") {
+		t.Errorf("unknown algorithm: err=%v", err)
+	}
+
+	if _, err := (&State{Algorithm: "SEED", Data: []byte{1, 2, 3}}).Block(); err != ErrState {
+		t.Errorf("short state: err=%v, wanted %v", err, ErrState)
+	}
+
+	c, _ := NewSEED(make([]byte, 16))
+	data, _ := c.(*SEEDCipher).MarshalBinary()
+	data[0] = 99
+	if _, err := (&State{Algorithm: "SEED", Data: data}).Block(); err != ErrState {
+		t.Errorf("future version: err=%v, wanted %v", err, ErrState)
+	}
+
+	// flags MarshalBinary never writes, no cipher having more than one
+	data[0] = seedStateVersion
+	for _, flags := range []byte{3, 5, 6, 7, 8, 255} {
+		data[2] = flags
+		if _, err := (&State{Algorithm: "SEED", Data: data}).Block(); err != ErrState {
+			t.Errorf("flags %d: err=%v, wanted %v", flags, err, ErrState)
+		}
+	}
+
+	h, _ := NewHIGHT(make([]byte, 16))
+	if _, err := NewState(h); err == nil {
+		t.Errorf("NewState(HIGHT) succeeded")
+	}
+}
@@ -0,0 +1,180 @@
+package krcrypt
+
+// Encrypting a stream in authenticated chunks
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+https://eprint.iacr.org/2015/189.pdf (the STREAM construction)
+
+*/
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// ErrStream is returned by DecryptStream when a stream is malformed,
+// truncated, tampered with, or encrypted under a different key.
+var ErrStream = errors.New("krcrypt: corrupt or truncated stream")
+
+// the stream header: magic and version
+var streamHeader = []byte{'K', 'R', 'S', 'T', 1}
+
+// the plaintext bytes per chunk
+const streamChunk = 64 << 10
+
+// EncryptStream encrypts everything read from src to dst with SEED-GCM under
+// the 16-byte key.  The format is the 5-byte header "KRST\x01" and a random
+// 8-byte nonce prefix, then the plaintext in chunks of 64KB, each written as
+// a flag byte, a 4-byte big-endian length, and the sealed chunk.  A chunk's
+// nonce is the prefix followed by its 4-byte index, and its flag, 1 for the
+// last chunk and 0 otherwise, is its associated data, so chunks can't be
+// reordered, and dropping the end of the stream is noticed.  The last chunk
+// may be empty.
+//
+// The random prefix lets one key encrypt many streams, but a key should
+// still be retired well before 2^32 of them.
+func EncryptStream(key []byte, dst io.Writer, src io.Reader) error {
+	return encryptStream(key, dst, src, streamChunk)
+}
+
+// encryptStream is EncryptStream with chunks of the given size, which must be
+// at most streamChunk for DecryptStream to read them
+func encryptStream(key []byte, dst io.Writer, src io.Reader, chunk int) error {
+
+	aead, err := NewGCM(key)
+	if err != nil {
+		return err
+	}
+
+	var nonce [12]byte
+	if _, err := io.ReadFull(rand.Reader, nonce[:8]); err != nil {
+		return err
+	}
+
+	hdr := append(append([]byte(nil), streamHeader...), nonce[:8]...)
+	if _, err := dst.Write(hdr); err != nil {
+		return err
+	}
+
+	buf := make([]byte, chunk)
+	frame := make([]byte, 5, 5+chunk+aead.Overhead())
+
+	for i := uint64(0); ; i++ {
+		if i > 0xffffffff {
+			return errors.New("krcrypt: EncryptStream: too many chunks")
+		}
+
+		n, err := io.ReadFull(src, buf)
+		last := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !last {
+			return err
+		}
+
+		var flag byte
+		if last {
+			flag = 1
+		}
+
+		binary.BigEndian.PutUint32(nonce[8:], uint32(i))
+		frame = aead.Seal(frame[:5], nonce[:], buf[:n], []byte{flag})
+		frame[0] = flag
+		binary.BigEndian.PutUint32(frame[1:], uint32(len(frame)-5))
+
+		if _, err := dst.Write(frame); err != nil {
+			return err
+		}
+
+		if last {
+			return nil
+		}
+	}
+}
+
+// DecryptStream reverses EncryptStream, writing the plaintext of each chunk
+// to dst as soon as it has been authenticated.  It returns ErrStream if any
+// chunk fails to open, or if src ends before the last chunk or goes on after
+// it.  In that case dst may already hold the plaintext of the chunks before
+// the bad one, which must not be trusted to be the whole stream.
+func DecryptStream(key []byte, dst io.Writer, src io.Reader) error {
+
+	aead, err := NewGCM(key)
+	if err != nil {
+		return err
+	}
+
+	readFull := func(b []byte) error {
+		if _, err := io.ReadFull(src, b); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return ErrStream
+			}
+			return err
+		}
+		return nil
+	}
+
+	hdr := make([]byte, len(streamHeader)+8)
+	if err := readFull(hdr); err != nil {
+		return err
+	}
+	if string(hdr[:len(streamHeader)]) != string(streamHeader) {
+		return ErrStream
+	}
+
+	var nonce [12]byte
+	copy(nonce[:], hdr[len(streamHeader):])
+
+	sealed := make([]byte, streamChunk+aead.Overhead())
+	var plain []byte
+
+	for i := uint64(0); ; i++ {
+		if i > 0xffffffff {
+			return ErrStream
+		}
+
+		var fl [5]byte
+		if err := readFull(fl[:]); err != nil {
+			return err
+		}
+
+		flag, n := fl[0], binary.BigEndian.Uint32(fl[1:])
+		if flag > 1 || n > uint32(len(sealed)) {
+			return ErrStream
+		}
+
+		if err := readFull(sealed[:n]); err != nil {
+			return err
+		}
+
+		binary.BigEndian.PutUint32(nonce[8:], uint32(i))
+		plain, err = aead.Open(plain[:0], nonce[:], sealed[:n], []byte{flag})
+		if err != nil {
+			return ErrStream
+		}
+
+		if _, err := dst.Write(plain); err != nil {
+			return err
+		}
+
+		if flag == 1 {
+			break
+		}
+	}
+
+	// nothing may follow the last chunk
+	var extra [1]byte
+	switch _, err := io.ReadFull(src, extra[:]); err {
+	case io.EOF:
+		return nil
+	case nil:
+		return ErrStream
+	default:
+		return err
+	}
+}
@@ -0,0 +1,75 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+	"testing/iotest"
+)
+
+func TestEncryptStream(t *testing.T) {
+
+	key := []byte("0123456789abcdef")
+
+	for _, n := range []int{0, 1, 63, 64, 65, 128, 1000} {
+		plain := make([]byte, n)
+		for i := range plain {
+			plain[i] = byte(i)
+		}
+
+		// short reads on both sides
+		var sealed bytes.Buffer
+		if err := encryptStream(key, &sealed, iotest.OneByteReader(bytes.NewReader(plain)), 64); err != nil {
+			t.Fatalf("EncryptStream(%d bytes): %v", n, err)
+		}
+
+		var got bytes.Buffer
+		if err := DecryptStream(key, &got, iotest.HalfReader(bytes.NewReader(sealed.Bytes()))); err != nil {
+			t.Fatalf("DecryptStream(%d bytes): %v", n, err)
+		}
+		if !bytes.Equal(got.Bytes(), plain) {
+			t.Errorf("DecryptStream(%d bytes) round trip failed", n)
+		}
+	}
+}
+
+func TestDecryptStreamTampered(t *testing.T) {
+
+	key := []byte("0123456789abcdef")
+	plain := bytes.Repeat([]byte("stream"), 50)
+
+	var buf bytes.Buffer
+	encryptStream(key, &buf, bytes.NewReader(plain), 64)
+	sealed := buf.Bytes()
+
+	// each frame is 5 bytes of framing and 64+16 of chunk, after 13 of header
+	frame := 5 + 64 + 16
+	full := 13 + 4*frame
+
+	flipped := append([]byte(nil), sealed...)
+	flipped[13+frame+20] ^= 1
+
+	final := append([]byte(nil), sealed...)
+	final[13+4*frame] = 0 // claim the last chunk isn't
+
+	for _, tt := range []struct {
+		name string
+		data []byte
+	}{
+		{"truncated at a chunk boundary", sealed[:full]},
+		{"truncated mid-chunk", sealed[:full-10]},
+		{"truncated header", sealed[:8]},
+		{"empty", nil},
+		{"tampered chunk", flipped},
+		{"flag changed", final},
+		{"trailing data", append(append([]byte(nil), sealed...), 0)},
+		{"chunks swapped", append(append(append([]byte(nil), sealed[:13]...), sealed[13+frame:13+2*frame]...), sealed[13:]...)},
+	} {
+		if err := DecryptStream(key, new(bytes.Buffer), bytes.NewReader(tt.data)); err != ErrStream {
+			t.Errorf("%s: DecryptStream=%v, wanted %v", tt.name, err, ErrStream)
+		}
+	}
+
+	if err := DecryptStream([]byte("0123456789abcdeg"), new(bytes.Buffer), bytes.NewReader(sealed)); err != ErrStream {
+		t.Errorf("wrong key: DecryptStream=%v, wanted %v", err, ErrStream)
+	}
+}
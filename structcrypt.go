@@ -0,0 +1,196 @@
+package krcrypt
+
+// Encrypting tagged struct fields
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+import (
+	"crypto/cipher"
+	"encoding/base64"
+	"errors"
+	"reflect"
+)
+
+// ErrNotStructPointer is returned by EncryptStruct and DecryptStruct when not given a pointer to a struct.
+var ErrNotStructPointer = errors.New("krcrypt: need a non-nil pointer to a struct")
+
+// A StructFieldError describes a tagged field that EncryptStruct or DecryptStruct could not handle.
+type StructFieldError struct {
+	Field  string // the dotted path to the field
+	Reason string
+}
+
+func (e *StructFieldError) Error() string {
+	return "krcrypt: field " + e.Field + ": " + e.Reason
+}
+
+// the struct tag selecting which fields get encrypted
+const (
+	cryptTag  = "crypt"
+	cryptSEED = "seed"
+)
+
+// EncryptStruct encrypts, in place, every field of the struct pointed to by v
+// tagged with `crypt:"seed"`.  Fields are sealed with SEED-GCM under key, each
+// with its own random nonce which is stored in front of the ciphertext.
+// []byte fields are replaced by nonce||ciphertext; string fields, which
+// typically end up in text formats, by the base64 encoding of that.
+//
+// Untagged fields are left alone, but nested structs (and pointers to them)
+// are searched for tagged fields too.  A struct reached more than once, by
+// a cycle of pointers or by two pointers to it, is only encrypted once.
+// Tagging an unexported field or a field of any other type is an error, and
+// v is not modified if any error is returned.
+func EncryptStruct(key []byte, v interface{}) error {
+
+	aead, fields, err := cryptFields(key, v)
+	if err != nil {
+		return err
+	}
+
+	// seal everything before changing anything, so a failure leaves v alone
+	sealed := make([][]byte, len(fields))
+	for i, f := range fields {
+		var plain []byte
+		if f.v.Kind() == reflect.String {
+			plain = []byte(f.v.String())
+		} else {
+			plain = f.v.Bytes()
+		}
+
+		if sealed[i], err = sealRandomNonce(aead, plain, nil); err != nil {
+			return err
+		}
+	}
+
+	for i, f := range fields {
+		if f.v.Kind() == reflect.String {
+			f.v.SetString(base64.StdEncoding.EncodeToString(sealed[i]))
+		} else {
+			f.v.SetBytes(sealed[i])
+		}
+	}
+
+	return nil
+}
+
+// DecryptStruct reverses EncryptStruct.  If any field fails to decrypt, an
+// error naming it is returned and v is not modified.
+func DecryptStruct(key []byte, v interface{}) error {
+
+	aead, fields, err := cryptFields(key, v)
+	if err != nil {
+		return err
+	}
+
+	plains := make([][]byte, len(fields))
+	for i, f := range fields {
+		var sealed []byte
+		if f.v.Kind() == reflect.String {
+			if sealed, err = base64.StdEncoding.DecodeString(f.v.String()); err != nil {
+				return &StructFieldError{f.path, "invalid base64: " + err.Error()}
+			}
+		} else {
+			sealed = f.v.Bytes()
+		}
+
+		if plains[i], err = openPrefixedNonce(aead, sealed, nil); err != nil {
+			return &StructFieldError{f.path, err.Error()}
+		}
+	}
+
+	for i, f := range fields {
+		if f.v.Kind() == reflect.String {
+			f.v.SetString(string(plains[i]))
+		} else {
+			f.v.SetBytes(plains[i])
+		}
+	}
+
+	return nil
+}
+
+type cryptField struct {
+	path string
+	v    reflect.Value
+}
+
+// cryptFields validates v and returns the AEAD to use along with all the tagged fields
+func cryptFields(key []byte, v interface{}) (cipher.AEAD, []cryptField, error) {
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil, nil, ErrNotStructPointer
+	}
+
+	aead, err := NewGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fields, err := collectCryptFields(rv.Elem(), rv.Elem().Type().Name(), make(map[visitedStruct]bool), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return aead, fields, nil
+}
+
+// a struct already walked: the type matters as well as the address, since
+// a struct and its first field share one
+type visitedStruct struct {
+	addr uintptr
+	t    reflect.Type
+}
+
+// collectCryptFields walks the struct s, appending its tagged fields to
+// fields.  Structs in seen are skipped, and s is added to it.
+func collectCryptFields(s reflect.Value, path string, seen map[visitedStruct]bool, fields []cryptField) ([]cryptField, error) {
+
+	t := s.Type()
+
+	if s.CanAddr() {
+		k := visitedStruct{s.UnsafeAddr(), t}
+		if seen[k] {
+			return fields, nil
+		}
+		seen[k] = true
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		fv := s.Field(i)
+		fpath := path + "." + sf.Name
+
+		tag, tagged := sf.Tag.Lookup(cryptTag)
+		if !tagged {
+			if sf.PkgPath != "" {
+				continue
+			}
+			var err error
+			switch {
+			case fv.Kind() == reflect.Struct:
+				fields, err = collectCryptFields(fv, fpath, seen, fields)
+			case fv.Kind() == reflect.Ptr && !fv.IsNil() && fv.Elem().Kind() == reflect.Struct:
+				fields, err = collectCryptFields(fv.Elem(), fpath, seen, fields)
+			}
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		switch {
+		case tag != cryptSEED:
+			return nil, &StructFieldError{fpath, "unsupported crypt tag " + tag}
+		case sf.PkgPath != "":
+			return nil, &StructFieldError{fpath, "cannot encrypt unexported field"}
+		case fv.Kind() != reflect.String && !(fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Uint8):
+			return nil, &StructFieldError{fpath, "cannot encrypt field of type " + fv.Type().String()}
+		}
+
+		fields = append(fields, cryptField{fpath, fv})
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,127 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+type testDBConfig struct {
+	Host     string
+	Password string `crypt:"seed"`
+}
+
+type testConfig struct {
+	Name   string
+	Token  []byte `crypt:"seed"`
+	DB     testDBConfig
+	Backup *testDBConfig
+}
+
+func TestEncryptStruct(t *testing.T) {
+
+	key := []byte("0123456789abcdef")
+
+	c := testConfig{
+		Name:   "prod",
+		Token:  []byte{0x01, 0x02, 0x03},
+		DB:     testDBConfig{Host: "db1", Password: "hunter2"},
+		Backup: &testDBConfig{Host: "db2", Password: "swordfish"},
+	}
+	orig := c
+	origBackup := *c.Backup
+
+	if err := EncryptStruct(key, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Name != orig.Name || c.DB.Host != orig.DB.Host || c.Backup.Host != origBackup.Host {
+		t.Errorf("untagged fields were modified: %+v", c)
+	}
+	if bytes.Equal(c.Token, orig.Token) || c.DB.Password == orig.DB.Password || c.Backup.Password == origBackup.Password {
+		t.Errorf("tagged fields were not encrypted: %+v", c)
+	}
+
+	if err := DecryptStruct(key, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(c.Token, orig.Token) || c.DB != orig.DB || *c.Backup != origBackup {
+		t.Errorf("DecryptStruct=%+v, wanted %+v", c, orig)
+	}
+}
+
+func TestEncryptStructErrors(t *testing.T) {
+
+	key := []byte("0123456789abcdef")
+
+	var unexported struct {
+		secret string `crypt:"seed"`
+	}
+	if err := EncryptStruct(key, &unexported); err == nil {
+		t.Errorf("EncryptStruct accepted an unexported field")
+	}
+
+	var badType struct {
+		N int `crypt:"seed"`
+	}
+	if err := EncryptStruct(key, &badType); err == nil {
+		t.Errorf("EncryptStruct accepted an int field")
+	}
+
+	var badTag struct {
+		S string `crypt:"rot13"`
+	}
+	if err := EncryptStruct(key, &badTag); err == nil {
+		t.Errorf("EncryptStruct accepted an unknown tag")
+	}
+
+	if err := EncryptStruct(key, testConfig{}); err != ErrNotStructPointer {
+		t.Errorf("EncryptStruct(non-pointer)=%v, wanted %v", err, ErrNotStructPointer)
+	}
+
+	// a failed decryption must leave the struct untouched
+	c := testConfig{Token: []byte("a"), DB: testDBConfig{Password: "b"}}
+	EncryptStruct(key, &c)
+	tampered := c.DB.Password
+	c.Token[len(c.Token)-1] ^= 1
+	if err := DecryptStruct(key, &c); err == nil {
+		t.Errorf("DecryptStruct accepted a tampered field")
+	}
+	if c.DB.Password != tampered {
+		t.Errorf("DecryptStruct modified the struct despite failing")
+	}
+}
+
+type testNode struct {
+	Secret string `crypt:"seed"`
+	Next   *testNode
+}
+
+func TestEncryptStructCycle(t *testing.T) {
+
+	key := []byte("0123456789abcdef")
+
+	// a ring of two nodes, and a second pointer to one of them
+	a := &testNode{Secret: "a"}
+	b := &testNode{Secret: "b", Next: a}
+	a.Next = b
+	c := struct {
+		Ring  *testNode
+		Alias *testNode
+	}{a, b}
+
+	if err := EncryptStruct(key, &c); err != nil {
+		t.Fatal(err)
+	}
+	if a.Secret == "a" || b.Secret == "b" {
+		t.Errorf("the ring was not encrypted: %q, %q", a.Secret, b.Secret)
+	}
+
+	// each node was sealed once, so one decryption restores it
+	if err := DecryptStruct(key, &c); err != nil {
+		t.Fatal(err)
+	}
+	if a.Secret != "a" || b.Secret != "b" {
+		t.Errorf("DecryptStruct gave %q, %q, wanted %q, %q", a.Secret, b.Secret, "a", "b")
+	}
+}
@@ -0,0 +1,160 @@
+package krcrypt
+
+// The Threefish-256 tweakable block cipher
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+http://www.skein-hash.info/sites/default/files/skein1.3.pdf
+
+*/
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// Threefish256 is an instance of Threefish-256, the tweakable block cipher
+// inside Skein-256, using a particular key and tweak.  Blocks are 32 bytes.
+type Threefish256 struct {
+	k [5]uint64 // the key words, and their parity word
+	t [3]uint64 // the tweak words, and their parity word
+}
+
+// the key schedule parity constant
+const threefishC240 = 0x1bd11bdaa9fc1a22
+
+// rotation constants for Threefish-256, indexed by round mod 8 and MIX number
+var threefish256R = [8][2]int{
+	{14, 16}, {52, 57}, {23, 40}, {5, 37},
+	{25, 33}, {46, 12}, {58, 22}, {32, 32},
+}
+
+// NewThreefish256 creates and returns a new Threefish256.  The key must be 32
+// bytes and the tweak 16 bytes.
+func NewThreefish256(key, tweak []byte) (*Threefish256, error) {
+
+	if klen := len(key); klen != 32 {
+		return nil, KeySizeError(klen)
+	}
+
+	c := new(Threefish256)
+
+	c.k[4] = threefishC240
+	for i := 0; i < 4; i++ {
+		c.k[i] = binary.LittleEndian.Uint64(key[8*i:])
+		c.k[4] ^= c.k[i]
+	}
+
+	if err := c.SetTweak(tweak); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// SetTweak replaces the 16-byte tweak, for example to tweak each block with
+// its position.  It is cheap: the subkeys are computed on the fly.
+func (c *Threefish256) SetTweak(tweak []byte) error {
+
+	if tlen := len(tweak); tlen != 16 {
+		return TweakSizeError(tlen)
+	}
+
+	c.t[0] = binary.LittleEndian.Uint64(tweak)
+	c.t[1] = binary.LittleEndian.Uint64(tweak[8:])
+	c.t[2] = c.t[0] ^ c.t[1]
+	return nil
+}
+
+// Reset zeroes the key and the tweak.
+func (c *Threefish256) Reset() {
+	c.k = [5]uint64{}
+	c.t = [3]uint64{}
+}
+
+// IsWiped reports whether the key and the tweak are all zero.
+func (c *Threefish256) IsWiped() bool {
+	return c.k == [5]uint64{} && c.t == [3]uint64{}
+}
+
+func (c *Threefish256) BlockSize() int { return 32 }
+
+// subkey s, injected before rounds 4s (and after the last round, for s=18)
+func (c *Threefish256) subkey(s int) [4]uint64 {
+	return [4]uint64{
+		c.k[s%5],
+		c.k[(s+1)%5] + c.t[s%3],
+		c.k[(s+2)%5] + c.t[(s+1)%3],
+		c.k[(s+3)%5] + uint64(s),
+	}
+}
+
+// Encrypt encrypts the 32-byte block in src and stores the resulting ciphertext in dst.
+func (c *Threefish256) Encrypt(dst, src []byte) {
+
+	var v [4]uint64
+	for i := range v {
+		v[i] = binary.LittleEndian.Uint64(src[8*i:])
+	}
+
+	for d := 0; d < 72; d++ {
+		if d%4 == 0 {
+			k := c.subkey(d / 4)
+			v[0] += k[0]
+			v[1] += k[1]
+			v[2] += k[2]
+			v[3] += k[3]
+		}
+
+		r := &threefish256R[d%8]
+
+		// two MIXes, then the permutation (0 3 2 1)
+		v[0] += v[1]
+		v[1] = bits.RotateLeft64(v[1], r[0]) ^ v[0]
+		v[2] += v[3]
+		v[3] = bits.RotateLeft64(v[3], r[1]) ^ v[2]
+		v[1], v[3] = v[3], v[1]
+	}
+
+	k := c.subkey(18)
+	for i := range v {
+		binary.LittleEndian.PutUint64(dst[8*i:], v[i]+k[i])
+	}
+}
+
+// Decrypt decrypts the 32-byte block in src and stores the resulting plaintext in dst.
+func (c *Threefish256) Decrypt(dst, src []byte) {
+
+	var v [4]uint64
+
+	k := c.subkey(18)
+	for i := range v {
+		v[i] = binary.LittleEndian.Uint64(src[8*i:]) - k[i]
+	}
+
+	for d := 71; d >= 0; d-- {
+		r := &threefish256R[d%8]
+
+		v[1], v[3] = v[3], v[1]
+		v[3] = bits.RotateLeft64(v[3]^v[2], -r[1])
+		v[2] -= v[3]
+		v[1] = bits.RotateLeft64(v[1]^v[0], -r[0])
+		v[0] -= v[1]
+
+		if d%4 == 0 {
+			k := c.subkey(d / 4)
+			v[0] -= k[0]
+			v[1] -= k[1]
+			v[2] -= k[2]
+			v[3] -= k[3]
+		}
+	}
+
+	for i := range v {
+		binary.LittleEndian.PutUint64(dst[8*i:], v[i])
+	}
+}
@@ -0,0 +1,92 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Threefish-256 known answers from the Skein reference implementation
+var threefish256TestVectors = []struct {
+	key    []byte
+	tweak  []byte
+	plain  []byte
+	cipher []byte
+}{
+	{
+		make([]byte, 32),
+		make([]byte, 16),
+		make([]byte, 32),
+		[]byte{
+			0x84, 0xda, 0x2a, 0x1f, 0x8b, 0xea, 0xee, 0x94, 0x70, 0x66, 0xae, 0x3e, 0x31, 0x03, 0xf1, 0xad,
+			0x53, 0x6d, 0xb1, 0xf4, 0xa1, 0x19, 0x24, 0x95, 0x11, 0x6b, 0x9f, 0x3c, 0xe6, 0x13, 0x3f, 0xd8,
+		},
+	},
+	{
+		[]byte{
+			0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+			0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27, 0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f,
+		},
+		[]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f},
+		[]byte{
+			0xff, 0xfe, 0xfd, 0xfc, 0xfb, 0xfa, 0xf9, 0xf8, 0xf7, 0xf6, 0xf5, 0xf4, 0xf3, 0xf2, 0xf1, 0xf0,
+			0xef, 0xee, 0xed, 0xec, 0xeb, 0xea, 0xe9, 0xe8, 0xe7, 0xe6, 0xe5, 0xe4, 0xe3, 0xe2, 0xe1, 0xe0,
+		},
+		[]byte{
+			0xe0, 0xd0, 0x91, 0xff, 0x0e, 0xea, 0x8f, 0xdf, 0xc9, 0x81, 0x92, 0xe6, 0x2e, 0xd8, 0x0a, 0xd5,
+			0x9d, 0x86, 0x5d, 0x08, 0x58, 0x8d, 0xf4, 0x76, 0x65, 0x70, 0x56, 0xb5, 0x95, 0x5e, 0x97, 0xdf,
+		},
+	},
+}
+
+func TestThreefish256Encrypt(t *testing.T) {
+
+	for _, v := range threefish256TestVectors {
+		tf, err := NewThreefish256(v.key, v.tweak)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var c, p [32]byte
+
+		tf.Encrypt(c[:], v.plain)
+
+		if !bytes.Equal(v.cipher, c[:]) {
+			t.Errorf("threefish encrypt failed: got %#v wanted %#v\n", c, v.cipher)
+		}
+
+		tf.Decrypt(p[:], c[:])
+
+		if !bytes.Equal(v.plain, p[:]) {
+			t.Errorf("threefish decrypt failed: got %#v wanted %#v\n", p, v.plain)
+		}
+	}
+}
+
+func TestThreefish256SetTweak(t *testing.T) {
+
+	v := threefish256TestVectors[1]
+
+	// start with the wrong tweak, then fix it
+	tf, _ := NewThreefish256(v.key, make([]byte, 16))
+
+	var c [32]byte
+	tf.Encrypt(c[:], v.plain)
+	if bytes.Equal(c[:], v.cipher) {
+		t.Fatalf("tweak had no effect")
+	}
+
+	if err := tf.SetTweak(v.tweak); err != nil {
+		t.Fatal(err)
+	}
+	tf.Encrypt(c[:], v.plain)
+	if !bytes.Equal(c[:], v.cipher) {
+		t.Errorf("threefish after SetTweak: got %#v wanted %#v\n", c, v.cipher)
+	}
+
+	if err := tf.SetTweak(make([]byte, 8)); err != TweakSizeError(8) {
+		t.Errorf("SetTweak(8 bytes)=%v, wanted %v", err, TweakSizeError(8))
+	}
+	if _, err := NewThreefish256(make([]byte, 16), v.tweak); err != KeySizeError(16) {
+		t.Errorf("NewThreefish256(16 byte key)=%v, wanted %v", err, KeySizeError(16))
+	}
+}
@@ -0,0 +1,69 @@
+package krcrypt
+
+// Measuring cipher throughput at run time
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+import (
+	"crypto/cipher"
+	"sort"
+	"time"
+)
+
+// how much data BenchmarkThroughput encrypts
+var throughputBytes = 4 << 20
+
+// BenchmarkThroughput measures how fast the block cipher from ctor encrypts on
+// this machine, in megabytes (10^6 bytes) per second.  It builds the cipher
+// with a fixed key of keyLen bytes and encrypts a 4MB buffer one block at a
+// time, so the figure is for the raw cipher without any mode.  It returns 0
+// if ctor rejects the key.  This is for capacity planning in a running
+// program; use go test -bench for careful comparisons.
+func BenchmarkThroughput(ctor func([]byte) (cipher.Block, error), keyLen int) (mbPerSec float64) {
+
+	key := make([]byte, keyLen)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	b, err := ctor(key)
+	if err != nil {
+		return 0
+	}
+
+	bs := b.BlockSize()
+	buf := make([]byte, throughputBytes/bs*bs)
+
+	start := time.Now()
+	for i := 0; i < len(buf); i += bs {
+		b.Encrypt(buf[i:i+bs], buf[i:i+bs])
+	}
+	elapsed := time.Since(start)
+
+	if elapsed <= 0 {
+		elapsed = 1
+	}
+
+	return float64(len(buf)) / 1e6 / elapsed.Seconds()
+}
+
+// A CipherThroughput is one line of the report from CompareCiphers.
+type CipherThroughput struct {
+	Name     string
+	MBPerSec float64
+}
+
+// CompareCiphers runs BenchmarkThroughput for every cipher in the package,
+// with the key length of its known-answer test, and returns the results
+// fastest first.  It takes a few seconds.
+func CompareCiphers() []CipherThroughput {
+
+	var report []CipherThroughput
+	for _, v := range selfTests {
+		report = append(report, CipherThroughput{v.name, BenchmarkThroughput(v.ctor, len(v.key)/2)})
+	}
+
+	sort.SliceStable(report, func(i, j int) bool { return report[i].MBPerSec > report[j].MBPerSec })
+
+	return report
+}
@@ -0,0 +1,30 @@
+package krcrypt
+
+import "testing"
+
+func TestBenchmarkThroughput(t *testing.T) {
+
+	defer func(n int) { throughputBytes = n }(throughputBytes)
+	throughputBytes = 64 << 10
+
+	if got := BenchmarkThroughput(NewSEED, 16); got <= 0 {
+		t.Errorf("BenchmarkThroughput(SEED)=%v, wanted a positive rate", got)
+	}
+
+	if got := BenchmarkThroughput(NewSEED, 15); got != 0 {
+		t.Errorf("BenchmarkThroughput(SEED, bad key length)=%v, wanted 0", got)
+	}
+
+	report := CompareCiphers()
+	if len(report) != len(selfTests) {
+		t.Fatalf("CompareCiphers reported %d ciphers, wanted %d", len(report), len(selfTests))
+	}
+	for i, r := range report {
+		if r.MBPerSec <= 0 {
+			t.Errorf("CompareCiphers: %s at %v MB/s", r.Name, r.MBPerSec)
+		}
+		if i > 0 && r.MBPerSec > report[i-1].MBPerSec {
+			t.Errorf("CompareCiphers: %s is faster than %s but listed after it", r.Name, report[i-1].Name)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package krcrypt
+
+// TLS 1.2 record protection with SEED-CBC and HMAC-SHA1
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+http://tools.ietf.org/html/rfc4162
+http://tools.ietf.org/html/rfc5246#section-6.2.3.2
+
+*/
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"hash"
+)
+
+// ErrRecordMAC is returned when a record fails to authenticate.  Bad padding
+// and a bad MAC are deliberately not distinguished.
+var ErrRecordMAC = errors.New("krcrypt: bad record MAC")
+
+// TLSRecordSEEDCBC protects TLS 1.2 records the way the TLS_*_WITH_SEED_CBC_SHA
+// cipher suites of RFC 4162 do: MAC-then-encrypt with HMAC-SHA1 and SEED-CBC,
+// with an explicit per-record IV.  It is for custom record layers; Go's
+// crypto/tls can't be extended with new cipher suites.
+//
+// Open checks padding and MAC without branching on the padding, but like any
+// MAC-then-encrypt CBC construction it is not fully immune to Lucky13-style
+// timing attacks.  Prefer an AEAD for new protocols.
+type TLSRecordSEEDCBC struct {
+	block cipher.Block
+	mac   hash.Hash
+}
+
+// NewTLSRecordSEEDCBC returns a record protector using the 16-byte SEED key
+// and the 20-byte HMAC-SHA1 key from the TLS key block.  Each record takes its
+// own IV, so there is none here.
+func NewTLSRecordSEEDCBC(key, macKey []byte) (*TLSRecordSEEDCBC, error) {
+
+	block, err := NewSEED(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(macKey) != sha1.Size {
+		return nil, KeySizeError(len(macKey))
+	}
+
+	return &TLSRecordSEEDCBC{block: block, mac: hmac.New(sha1.New, macKey)}, nil
+}
+
+// the MAC covers seq_num, type, version, length and the fragment
+func (t *TLSRecordSEEDCBC) computeMAC(seq uint64, contentType uint8, version uint16, fragment []byte) []byte {
+
+	var hdr [13]byte
+	binary.BigEndian.PutUint64(hdr[:], seq)
+	hdr[8] = contentType
+	binary.BigEndian.PutUint16(hdr[9:], version)
+	binary.BigEndian.PutUint16(hdr[11:], uint16(len(fragment)))
+
+	t.mac.Reset()
+	t.mac.Write(hdr[:])
+	t.mac.Write(fragment)
+	return t.mac.Sum(nil)
+}
+
+// Seal returns the GenericBlockCipher payload for the record: the 16-byte iv
+// followed by the encryption of fragment, its MAC, and the padding.  The iv
+// must be unpredictable and fresh for every record.
+func (t *TLSRecordSEEDCBC) Seal(seq uint64, contentType uint8, version uint16, iv, fragment []byte) ([]byte, error) {
+
+	bs := t.block.BlockSize()
+	if len(iv) != bs {
+		return nil, IVSizeError(len(iv))
+	}
+
+	mac := t.computeMAC(seq, contentType, version, fragment)
+
+	n := len(fragment) + len(mac) + 1
+	padLen := (bs - n%bs) % bs
+
+	out := make([]byte, bs, bs+n+padLen)
+	copy(out, iv)
+	out = append(out, fragment...)
+	out = append(out, mac...)
+	for i := 0; i <= padLen; i++ {
+		out = append(out, byte(padLen))
+	}
+
+	cipher.NewCBCEncrypter(t.block, iv).CryptBlocks(out[bs:], out[bs:])
+	return out, nil
+}
+
+// Open decrypts and authenticates a payload produced by Seal, returning the
+// fragment or ErrRecordMAC.
+func (t *TLSRecordSEEDCBC) Open(seq uint64, contentType uint8, version uint16, payload []byte) ([]byte, error) {
+
+	bs := t.block.BlockSize()
+	macSize := t.mac.Size()
+
+	// the smallest record is a block holding just the MAC and the pad length
+	minLen := bs + (macSize+1+bs-1)/bs*bs
+	if len(payload) < minLen || len(payload)%bs != 0 {
+		return nil, ErrRecordMAC
+	}
+
+	plain := make([]byte, len(payload)-bs)
+	cipher.NewCBCDecrypter(t.block, payload[:bs]).CryptBlocks(plain, payload[bs:])
+
+	toRemove, good := tlsPadding(plain, macSize)
+
+	end := len(plain) - toRemove - macSize
+	fragment := plain[:end]
+	mac := t.computeMAC(seq, contentType, version, fragment)
+
+	// bad padding spoils the MAC, so both fail the same single check
+	mac[0] ^= byte(1 - good)
+
+	if _, err := verifyAndRelease(mac, plain[end:end+macSize], plain); err != nil {
+		return nil, ErrRecordMAC
+	}
+
+	return fragment, nil
+}
+
+// tlsPadding checks the TLS padding at the end of plain without branching on
+// its contents.  It returns how many bytes to strip and 1 if the padding was
+// valid.  If it wasn't, it asks for only one byte to be stripped, so the MAC
+// is still computed over something.
+func tlsPadding(plain []byte, macSize int) (toRemove int, good int) {
+
+	padLen := int(plain[len(plain)-1])
+
+	// the padding, its length byte and the MAC must all fit
+	good = subtle.ConstantTimeLessOrEq(padLen+1+macSize, len(plain))
+
+	// look at the last 256 bytes regardless of the actual padding length
+	toCheck := 256
+	if toCheck > len(plain) {
+		toCheck = len(plain)
+	}
+	for i := 1; i < toCheck; i++ {
+		inPadding := subtle.ConstantTimeLessOrEq(i, padLen)
+		matches := subtle.ConstantTimeByteEq(plain[len(plain)-1-i], byte(padLen))
+		good &= matches | (inPadding ^ 1)
+	}
+
+	toRemove = subtle.ConstantTimeSelect(good, padLen+1, 1)
+	return toRemove, good
+}
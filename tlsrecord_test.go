@@ -0,0 +1,82 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTLSRecordSEEDCBC(t *testing.T) {
+
+	key := []byte("0123456789abcdef")
+	macKey := []byte("0123456789abcdefghij")
+	iv := []byte("fedcba9876543210")
+
+	r, err := NewTLSRecordSEEDCBC(key, macKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		seq     = 7
+		appData = 23
+		tls12   = 0x0303
+	)
+
+	for _, n := range []int{0, 1, 11, 12, 27, 100} {
+		fragment := bytes.Repeat([]byte{'x'}, n)
+
+		payload, err := r.Seal(seq, appData, tls12, iv, fragment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(payload)%16 != 0 || !bytes.Equal(payload[:16], iv) {
+			t.Fatalf("Seal(len=%d) produced a malformed payload", n)
+		}
+
+		got, err := r.Open(seq, appData, tls12, payload)
+		if err != nil || !bytes.Equal(got, fragment) {
+			t.Errorf("Open(len=%d)=(%q,%v), wanted %q", n, got, err, fragment)
+		}
+
+		// the MAC binds the sequence number and header
+		if _, err := r.Open(seq+1, appData, tls12, payload); err != ErrRecordMAC {
+			t.Errorf("Open with wrong seq=%v, wanted %v", err, ErrRecordMAC)
+		}
+		if _, err := r.Open(seq, appData+1, tls12, payload); err != ErrRecordMAC {
+			t.Errorf("Open with wrong type=%v, wanted %v", err, ErrRecordMAC)
+		}
+
+		for _, i := range []int{0, 16, len(payload) - 1} {
+			bad := append([]byte(nil), payload...)
+			bad[i] ^= 0x80
+			if _, err := r.Open(seq, appData, tls12, bad); err != ErrRecordMAC {
+				t.Errorf("Open(len=%d) with byte %d flipped=%v, wanted %v", n, i, err, ErrRecordMAC)
+			}
+		}
+	}
+
+	if _, err := r.Open(seq, appData, tls12, make([]byte, 20)); err != ErrRecordMAC {
+		t.Errorf("Open(short)=%v, wanted %v", err, ErrRecordMAC)
+	}
+}
+
+func TestTLSPadding(t *testing.T) {
+
+	for _, tt := range []struct {
+		plain    []byte
+		toRemove int
+		good     int
+	}{
+		{[]byte{9, 9, 9, 9, 0}, 1, 1},
+		{[]byte{9, 9, 2, 2, 2}, 3, 1},
+		{[]byte{9, 3, 2, 2, 2}, 3, 1},
+		{[]byte{9, 9, 3, 2, 2}, 1, 0},
+		{[]byte{9, 9, 1, 2, 2}, 1, 0},
+		{[]byte{4, 4, 4, 4, 4}, 1, 0}, // no room for the MAC
+	} {
+		toRemove, good := tlsPadding(tt.plain, 1)
+		if toRemove != tt.toRemove || good != tt.good {
+			t.Errorf("tlsPadding(%v)=(%d,%d), wanted (%d,%d)", tt.plain, toRemove, good, tt.toRemove, tt.good)
+		}
+	}
+}
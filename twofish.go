@@ -0,0 +1,250 @@
+package krcrypt
+
+// The Twofish block cipher
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+https://www.schneier.com/academic/paperfiles/paper-twofish-paper.pdf
+https://www.schneier.com/code/ecb_ival.txt
+
+*/
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// A TwofishCipher is an instance of Twofish encryption using a particular
+// key.  Blocks are 16 bytes.  The key-dependent S-boxes are folded together
+// with the MDS matrix into four tables at key setup, so encryption is four
+// lookups per g function.
+type TwofishCipher struct {
+	s [4][256]uint32
+	k [40]uint32
+}
+
+// the 4-bit permutations t0..t3 that q0 and q1 are built from
+var twofishT = [2][4][16]byte{
+	{
+		{0x8, 0x1, 0x7, 0xd, 0x6, 0xf, 0x3, 0x2, 0x0, 0xb, 0x5, 0x9, 0xe, 0xc, 0xa, 0x4},
+		{0xe, 0xc, 0xb, 0x8, 0x1, 0x2, 0x3, 0x5, 0xf, 0x4, 0xa, 0x6, 0x7, 0x0, 0x9, 0xd},
+		{0xb, 0xa, 0x5, 0xe, 0x6, 0xd, 0x9, 0x0, 0xc, 0x8, 0xf, 0x3, 0x2, 0x4, 0x7, 0x1},
+		{0xd, 0x7, 0xf, 0x4, 0x1, 0x2, 0x6, 0xe, 0x9, 0xb, 0x3, 0x0, 0x8, 0x5, 0xc, 0xa},
+	},
+	{
+		{0x2, 0x8, 0xb, 0xd, 0xf, 0x7, 0x6, 0xe, 0x3, 0x1, 0x9, 0x4, 0x0, 0xa, 0xc, 0x5},
+		{0x1, 0xe, 0x2, 0xb, 0x4, 0xc, 0x3, 0x7, 0x6, 0xd, 0xa, 0x5, 0xf, 0x9, 0x0, 0x8},
+		{0x4, 0xc, 0x7, 0x5, 0x1, 0x6, 0x9, 0xa, 0x0, 0xe, 0xd, 0x8, 0x2, 0xb, 0x3, 0xf},
+		{0xb, 0x9, 0x5, 0x1, 0xc, 0x3, 0xd, 0xe, 0x6, 0x4, 0x7, 0xf, 0x2, 0x0, 0x8, 0xa},
+	},
+}
+
+// the fixed 8-bit permutations q0 and q1, built at init
+var twofishQ [2][256]byte
+
+// which of q0 and q1 each byte position goes through at each stage of h: the
+// stages for the key words L3, L2, L1, and L0, and the final one
+var twofishQOrder = [5][4]byte{
+	{1, 0, 0, 1},
+	{1, 1, 0, 0},
+	{0, 1, 0, 1},
+	{0, 0, 1, 1},
+	{1, 0, 1, 0},
+}
+
+// the MDS matrix, over GF(2^8) mod x^8+x^6+x^5+x^3+1
+var twofishMDS = [4][4]byte{
+	{0x01, 0xef, 0x5b, 0x5b},
+	{0x5b, 0xef, 0xef, 0x01},
+	{0xef, 0x5b, 0x01, 0xef},
+	{0xef, 0x01, 0xef, 0x5b},
+}
+
+// the Reed-Solomon matrix, over GF(2^8) mod x^8+x^6+x^3+x^2+1
+var twofishRS = [4][8]byte{
+	{0x01, 0xa4, 0x55, 0x87, 0x5a, 0x58, 0xdb, 0x9e},
+	{0xa4, 0x56, 0x82, 0xf3, 0x1e, 0xc6, 0x68, 0xe5},
+	{0x02, 0xa1, 0xfc, 0xc1, 0x47, 0xae, 0x3d, 0x19},
+	{0xa4, 0x55, 0x87, 0x5a, 0x58, 0xdb, 0x9e, 0x03},
+}
+
+func init() {
+	for q := range twofishQ {
+		t := &twofishT[q]
+		for x := 0; x < 256; x++ {
+			a, b := byte(x>>4), byte(x&15)
+			for i := 0; i < 2; i++ {
+				a, b = a^b, a^(b>>1|b<<3)&15^(a<<3)&15
+				a, b = t[2*i][a], t[2*i+1][b]
+			}
+			twofishQ[q][x] = b<<4 | a
+		}
+	}
+}
+
+// twofishMul multiplies a and b in GF(2^8) modulo the polynomial poly
+func twofishMul(a, b byte, poly uint) byte {
+	var p uint
+	x := uint(a)
+	for ; b != 0; b >>= 1 {
+		if b&1 != 0 {
+			p ^= x
+		}
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= poly
+		}
+	}
+	return byte(p)
+}
+
+// twofishMDSColumn returns column j of the MDS matrix times y, as a little-endian word
+func twofishMDSColumn(j int, y byte) uint32 {
+	var z uint32
+	for i := 0; i < 4; i++ {
+		z |= uint32(twofishMul(twofishMDS[i][j], y, 0x169)) << (8 * uint(i))
+	}
+	return z
+}
+
+// twofishQStack runs byte j of h's input through the q permutations,
+// xoring in byte j of each of the words in l between them
+func twofishQStack(j int, x byte, l [][4]byte) byte {
+	for i := len(l) - 1; i >= 0; i-- {
+		x = twofishQ[twofishQOrder[3-i][j]][x] ^ l[i][j]
+	}
+	return twofishQ[twofishQOrder[4][j]][x]
+}
+
+// twofishH is the h function of the key schedule, with all four input bytes equal to x
+func twofishH(x byte, l [][4]byte) uint32 {
+	var z uint32
+	for j := 0; j < 4; j++ {
+		z ^= twofishMDSColumn(j, twofishQStack(j, x, l))
+	}
+	return z
+}
+
+// NewTwofish creates and returns a new TwofishCipher.  The key must be 16, 24,
+// or 32 bytes.
+func NewTwofish(key []byte) (*TwofishCipher, error) {
+
+	klen := len(key)
+	if klen != 16 && klen != 24 && klen != 32 {
+		return nil, KeySizeError(klen)
+	}
+
+	k := klen / 8
+
+	// the even and odd key words, and the S words, the Reed-Solomon code of
+	// each 8 bytes of key in reverse order
+	me := make([][4]byte, k)
+	mo := make([][4]byte, k)
+	s := make([][4]byte, k)
+	for i := 0; i < k; i++ {
+		copy(me[i][:], key[8*i:])
+		copy(mo[i][:], key[8*i+4:])
+
+		for r := range twofishRS {
+			for c, v := range twofishRS[r] {
+				s[k-1-i][r] ^= twofishMul(key[8*i+c], v, 0x14d)
+			}
+		}
+	}
+
+	c := new(TwofishCipher)
+
+	for i := 0; i < 20; i++ {
+		a := twofishH(byte(2*i), me)
+		b := bits.RotateLeft32(twofishH(byte(2*i+1), mo), 8)
+		c.k[2*i] = a + b
+		c.k[2*i+1] = bits.RotateLeft32(a+2*b, 9)
+	}
+
+	for j := 0; j < 4; j++ {
+		for x := 0; x < 256; x++ {
+			c.s[j][x] = twofishMDSColumn(j, twofishQStack(j, byte(x), s))
+		}
+	}
+
+	return c, nil
+}
+
+// Reset zeroes the subkeys and the key-dependent S-boxes.
+func (c *TwofishCipher) Reset() {
+	c.s = [4][256]uint32{}
+	c.k = [40]uint32{}
+}
+
+// IsWiped reports whether the subkeys and the key-dependent S-boxes are all zero.
+func (c *TwofishCipher) IsWiped() bool {
+	return c.s == [4][256]uint32{} && c.k == [40]uint32{}
+}
+
+func (c *TwofishCipher) BlockSize() int { return 16 }
+
+func (c *TwofishCipher) g(x uint32) uint32 {
+	return c.s[0][byte(x)] ^ c.s[1][byte(x>>8)] ^ c.s[2][byte(x>>16)] ^ c.s[3][x>>24]
+}
+
+// Encrypt encrypts the 16-byte block in src and stores the resulting ciphertext in dst.
+func (c *TwofishCipher) Encrypt(dst, src []byte) {
+
+	r0 := binary.LittleEndian.Uint32(src[0:]) ^ c.k[0]
+	r1 := binary.LittleEndian.Uint32(src[4:]) ^ c.k[1]
+	r2 := binary.LittleEndian.Uint32(src[8:]) ^ c.k[2]
+	r3 := binary.LittleEndian.Uint32(src[12:]) ^ c.k[3]
+
+	// two rounds at a time, so the halves swap back each time
+	for r := 0; r < 16; r += 2 {
+		k := c.k[8+2*r:]
+
+		t0 := c.g(r0)
+		t1 := c.g(bits.RotateLeft32(r1, 8))
+		r2 = bits.RotateLeft32(r2^(t0+t1+k[0]), -1)
+		r3 = bits.RotateLeft32(r3, 1) ^ (t0 + 2*t1 + k[1])
+
+		t0 = c.g(r2)
+		t1 = c.g(bits.RotateLeft32(r3, 8))
+		r0 = bits.RotateLeft32(r0^(t0+t1+k[2]), -1)
+		r1 = bits.RotateLeft32(r1, 1) ^ (t0 + 2*t1 + k[3])
+	}
+
+	// undo the last swap, and whiten
+	binary.LittleEndian.PutUint32(dst[0:], r2^c.k[4])
+	binary.LittleEndian.PutUint32(dst[4:], r3^c.k[5])
+	binary.LittleEndian.PutUint32(dst[8:], r0^c.k[6])
+	binary.LittleEndian.PutUint32(dst[12:], r1^c.k[7])
+}
+
+// Decrypt decrypts the 16-byte block in src and stores the resulting plaintext in dst.
+func (c *TwofishCipher) Decrypt(dst, src []byte) {
+
+	r2 := binary.LittleEndian.Uint32(src[0:]) ^ c.k[4]
+	r3 := binary.LittleEndian.Uint32(src[4:]) ^ c.k[5]
+	r0 := binary.LittleEndian.Uint32(src[8:]) ^ c.k[6]
+	r1 := binary.LittleEndian.Uint32(src[12:]) ^ c.k[7]
+
+	for r := 14; r >= 0; r -= 2 {
+		k := c.k[8+2*r:]
+
+		t0 := c.g(r2)
+		t1 := c.g(bits.RotateLeft32(r3, 8))
+		r0 = bits.RotateLeft32(r0, 1) ^ (t0 + t1 + k[2])
+		r1 = bits.RotateLeft32(r1^(t0+2*t1+k[3]), -1)
+
+		t0 = c.g(r0)
+		t1 = c.g(bits.RotateLeft32(r1, 8))
+		r2 = bits.RotateLeft32(r2, 1) ^ (t0 + t1 + k[0])
+		r3 = bits.RotateLeft32(r3^(t0+2*t1+k[1]), -1)
+	}
+
+	binary.LittleEndian.PutUint32(dst[0:], r0^c.k[0])
+	binary.LittleEndian.PutUint32(dst[4:], r1^c.k[1])
+	binary.LittleEndian.PutUint32(dst[8:], r2^c.k[2])
+	binary.LittleEndian.PutUint32(dst[12:], r3^c.k[3])
+}
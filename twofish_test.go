@@ -0,0 +1,72 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+// from https://www.schneier.com/code/ecb_ival.txt and ecb_tbl.txt
+var twofishTestVectors = []struct {
+	key    []byte
+	plain  []byte
+	cipher []byte
+}{
+	{
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0x9f, 0x58, 0x9f, 0x5c, 0xf6, 0x12, 0x2c, 0x32, 0xb6, 0xbf, 0xec, 0x2f, 0x2a, 0xe8, 0xc3, 0x5a},
+	},
+	{
+		[]byte{0x9f, 0x58, 0x9f, 0x5c, 0xf6, 0x12, 0x2c, 0x32, 0xb6, 0xbf, 0xec, 0x2f, 0x2a, 0xe8, 0xc3, 0x5a},
+		[]byte{0xd4, 0x91, 0xdb, 0x16, 0xe7, 0xb1, 0xc3, 0x9e, 0x86, 0xcb, 0x08, 0x6b, 0x78, 0x9f, 0x54, 0x19},
+		[]byte{0x01, 0x9f, 0x98, 0x09, 0xde, 0x17, 0x11, 0x85, 0x8f, 0xaa, 0xc3, 0xa3, 0xba, 0x20, 0xfb, 0xc3},
+	},
+	{
+		[]byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 0xfe, 0xdc, 0xba, 0x98, 0x76, 0x54, 0x32, 0x10, 0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77},
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0xcf, 0xd1, 0xd2, 0xe5, 0xa9, 0xbe, 0x9c, 0xdf, 0x50, 0x1f, 0x13, 0xb8, 0x92, 0xbd, 0x22, 0x48},
+	},
+	{
+		[]byte{0x88, 0xb2, 0xb2, 0x70, 0x6b, 0x10, 0x5e, 0x36, 0xb4, 0x46, 0xbb, 0x6d, 0x73, 0x1a, 0x1e, 0x88, 0xef, 0xa7, 0x1f, 0x78, 0x89, 0x65, 0xbd, 0x44},
+		[]byte{0x39, 0xda, 0x69, 0xd6, 0xba, 0x49, 0x97, 0xd5, 0x85, 0xb6, 0xdc, 0x07, 0x3c, 0xa3, 0x41, 0xb2},
+		[]byte{0x18, 0x2b, 0x02, 0xd8, 0x14, 0x97, 0xea, 0x45, 0xf9, 0xda, 0xac, 0xdc, 0x29, 0x19, 0x3a, 0x65},
+	},
+	{
+		[]byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 0xfe, 0xdc, 0xba, 0x98, 0x76, 0x54, 0x32, 0x10, 0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		[]byte{0x37, 0x52, 0x7b, 0xe0, 0x05, 0x23, 0x34, 0xb8, 0x9f, 0x0c, 0xfc, 0xca, 0xe8, 0x7c, 0xfa, 0x20},
+	},
+	{
+		[]byte{0xd4, 0x3b, 0xb7, 0x55, 0x6e, 0xa3, 0x2e, 0x46, 0xf2, 0xa2, 0x82, 0xb7, 0xd4, 0x5b, 0x4e, 0x0d, 0x57, 0xff, 0x73, 0x9d, 0x4d, 0xc9, 0x2c, 0x1b, 0xd7, 0xfc, 0x01, 0x70, 0x0c, 0xc8, 0x21, 0x6f},
+		[]byte{0x90, 0xaf, 0xe9, 0x1b, 0xb2, 0x88, 0x54, 0x4f, 0x2c, 0x32, 0xdc, 0x23, 0x9b, 0x26, 0x35, 0xe6},
+		[]byte{0x6c, 0xb4, 0x56, 0x1c, 0x40, 0xbf, 0x0a, 0x97, 0x05, 0x93, 0x1c, 0xb6, 0xd4, 0x08, 0xe7, 0xfa},
+	},
+}
+
+func TestTwofishEncrypt(t *testing.T) {
+
+	for _, v := range twofishTestVectors {
+		tf, err := NewTwofish(v.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var c, p [16]byte
+
+		tf.Encrypt(c[:], v.plain)
+
+		if !bytes.Equal(v.cipher, c[:]) {
+			t.Errorf("twofish encrypt failed: got %#v wanted %#v\n", c, v.cipher)
+		}
+
+		tf.Decrypt(p[:], c[:])
+
+		if !bytes.Equal(v.plain, p[:]) {
+			t.Errorf("twofish decrypt failed: got %#v wanted %#v\n", p, v.plain)
+		}
+	}
+
+	if _, err := NewTwofish(make([]byte, 20)); err != KeySizeError(20) {
+		t.Errorf("NewTwofish(20 byte key)=%v, wanted %v", err, KeySizeError(20))
+	}
+}
@@ -0,0 +1,133 @@
+package krcrypt
+
+// Self-checks for block cipher implementations
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math"
+	"time"
+)
+
+// ErrFault is returned by EncryptChecked when the ciphertext doesn't decrypt
+// back to the plaintext, which means something went wrong during one of the
+// two computations.
+var ErrFault = errors.New("krcrypt: fault detected during encryption")
+
+// InverseError is returned by VerifyInverse when decrypting a ciphertext does
+// not reproduce the plaintext it was encrypted from.
+type InverseError struct {
+	Plaintext []byte // the random block that was encrypted
+	Decrypted []byte // what decrypting its ciphertext produced
+}
+
+func (e *InverseError) Error() string {
+	return "krcrypt: decrypt(encrypt(" + hex.EncodeToString(e.Plaintext) + ")) = " + hex.EncodeToString(e.Decrypted)
+}
+
+// VerifyInverse encrypts and then decrypts 'samples' random blocks with block,
+// returning an *InverseError for the first block that does not survive the
+// round trip.  It is meant to catch key schedule bugs, where the decryption
+// subkeys don't quite undo the encryption ones.
+func VerifyInverse(block cipher.Block, samples int) error {
+
+	bs := block.BlockSize()
+
+	p := make([]byte, bs)
+	c := make([]byte, bs)
+	d := make([]byte, bs)
+
+	for i := 0; i < samples; i++ {
+		if _, err := rand.Read(p); err != nil {
+			return err
+		}
+
+		block.Encrypt(c, p)
+		block.Decrypt(d, c)
+
+		if !bytes.Equal(p, d) {
+			return &InverseError{Plaintext: p, Decrypted: d}
+		}
+	}
+
+	return nil
+}
+
+// EncryptChecked encrypts the block in src into dst, then decrypts the
+// result and compares it with src before releasing it, as a countermeasure
+// to fault injection: a single transient fault in either computation makes
+// the comparison fail.  On failure it returns ErrFault and leaves dst
+// untouched, so a faulty ciphertext is never output.  It costs a little over
+// twice as much as Encrypt.  dst and src may overlap entirely.
+func EncryptChecked(b cipher.Block, dst, src []byte) error {
+
+	bs := b.BlockSize()
+
+	var cbuf, pbuf [64]byte
+	c, p := cbuf[:], pbuf[:]
+	if bs > len(c) {
+		c, p = make([]byte, bs), make([]byte, bs)
+	}
+	c, p = c[:bs], p[:bs]
+
+	b.Encrypt(c, src)
+	b.Decrypt(p, c)
+
+	if !bytes.Equal(p, src[:bs]) {
+		return ErrFault
+	}
+
+	copy(dst, c)
+	return nil
+}
+
+// MeasureTimingVariance times 'samples' single-block encryptions of random
+// blocks with block, and returns the mean and standard deviation of the
+// times.  A standard deviation that is large compared to the mean hints at
+// data-dependent timing, such as table lookups that hit or miss the cache
+// depending on the data.
+//
+// This is a rough check for gross effects, not a leakage test.  Each
+// measurement includes the overhead and granularity of the clock, which can
+// be comparable to the time for one block, and the results are disturbed by
+// scheduling, frequency scaling, and garbage collection; run it on an
+// otherwise idle machine and compare ciphers against each other rather than
+// reading the numbers in isolation.  Small but exploitable differences will
+// not show up here; detecting those needs a statistical test over fixed and
+// random input classes, such as dudect's.
+func MeasureTimingVariance(block cipher.Block, samples int) (mean, stddev time.Duration) {
+
+	if samples < 1 {
+		return 0, 0
+	}
+
+	bs := block.BlockSize()
+	in := make([]byte, samples*bs)
+	out := make([]byte, bs)
+	rand.Read(in)
+
+	// Welford's running mean and variance
+	var m, m2 float64
+	for i := 0; i < samples; i++ {
+		src := in[i*bs : (i+1)*bs]
+
+		start := time.Now()
+		block.Encrypt(out, src)
+		d := float64(time.Since(start))
+
+		delta := d - m
+		m += delta / float64(i+1)
+		m2 += delta * (d - m)
+	}
+
+	if samples > 1 {
+		stddev = time.Duration(math.Sqrt(m2 / float64(samples-1)))
+	}
+
+	return time.Duration(m), stddev
+}
@@ -0,0 +1,119 @@
+package krcrypt
+
+import (
+	"crypto/cipher"
+	"testing"
+)
+
+// every cipher in the package, keyed with an arbitrary key of each supported size
+var verifyCiphers = []struct {
+	name string
+	ctor func([]byte) (cipher.Block, error)
+	klen int
+}{
+	{"HIGHT", NewHIGHT, 16},
+	{"SEED", NewSEED, 16},
+	{"ARIA-128", NewARIA, 16},
+	{"ARIA-192", NewARIA, 24},
+	{"ARIA-256", NewARIA, 32},
+	{"Speck64/128", func(k []byte) (cipher.Block, error) { return NewSpeck(k, 64) }, 16},
+	{"Speck128/256", func(k []byte) (cipher.Block, error) { return NewSpeck(k, 128) }, 32},
+	{"Noekeon", func(k []byte) (cipher.Block, error) { return NewNoekeon(k) }, 16},
+	{"Serpent-192", func(k []byte) (cipher.Block, error) { return NewSerpent(k) }, 24},
+	{"Threefish-256", func(k []byte) (cipher.Block, error) { return NewThreefish256(k, make([]byte, 16)) }, 32},
+	{"RC5-32/12", func(k []byte) (cipher.Block, error) { return NewRC5(k, 32, 12) }, 16},
+	{"RC6", func(k []byte) (cipher.Block, error) { return NewRC6(k) }, 16},
+	{"CLEFIA-256", func(k []byte) (cipher.Block, error) { return NewClefia(k) }, 32},
+	{"GIFT-64", func(k []byte) (cipher.Block, error) { return NewGIFT(k, 64) }, 16},
+	{"GIFT-128", func(k []byte) (cipher.Block, error) { return NewGIFT(k, 128) }, 16},
+	{"Midori64", func(k []byte) (cipher.Block, error) { return NewMidori(k, 64) }, 16},
+	{"Midori128", func(k []byte) (cipher.Block, error) { return NewMidori(k, 128) }, 16},
+	{"SAFER K-128", func(k []byte) (cipher.Block, error) { return NewSaferK(k, 10) }, 16},
+	{"SAFER SK-64", func(k []byte) (cipher.Block, error) { return NewSafer(k, 8) }, 8},
+	{"Anubis-160", func(k []byte) (cipher.Block, error) { return NewAnubis(k) }, 20},
+	{"Anubis-320", func(k []byte) (cipher.Block, error) { return NewAnubis(k) }, 40},
+	{"Khazad", func(k []byte) (cipher.Block, error) { return NewKhazad(k) }, 16},
+	{"RC2-40", func(k []byte) (cipher.Block, error) { return NewRC2(k, 40) }, 5},
+	{"Blowfish", func(k []byte) (cipher.Block, error) { return NewBlowfish(k) }, 16},
+	{"Twofish", func(k []byte) (cipher.Block, error) { return NewTwofish(k) }, 16},
+	{"Camellia-128", func(k []byte) (cipher.Block, error) { return NewCamellia(k) }, 16},
+	{"Camellia-192", func(k []byte) (cipher.Block, error) { return NewCamellia(k) }, 24},
+	{"CAST-256", func(k []byte) (cipher.Block, error) { return NewCAST6(k) }, 20},
+}
+
+func TestVerifyInverse(t *testing.T) {
+
+	for _, v := range verifyCiphers {
+		key := make([]byte, v.klen)
+		for i := range key {
+			key[i] = byte(i * 7)
+		}
+
+		b, err := v.ctor(key)
+		if err != nil {
+			t.Fatalf("%s: %v", v.name, err)
+		}
+
+		if err := VerifyInverse(b, 1000); err != nil {
+			t.Errorf("%s: %v", v.name, err)
+		}
+	}
+}
+
+// a cipher whose Decrypt doesn't undo its Encrypt
+type brokenBlock struct{ cipher.Block }
+
+func (b brokenBlock) Decrypt(dst, src []byte) {
+	b.Block.Decrypt(dst, src)
+	dst[0] ^= 1
+}
+
+func TestVerifyInverseDetectsMismatch(t *testing.T) {
+
+	s, _ := NewSEED(make([]byte, 16))
+
+	err := VerifyInverse(brokenBlock{s}, 1)
+	if _, ok := err.(*InverseError); !ok {
+		t.Errorf("VerifyInverse(broken)=%v, wanted an *InverseError", err)
+	}
+}
+
+func TestEncryptChecked(t *testing.T) {
+
+	s, _ := NewSEED(make([]byte, 16))
+	c := s.(*SEEDCipher)
+
+	src := []byte("sixteen byte blk")
+	var want, got [16]byte
+	c.Encrypt(want[:], src)
+
+	if err := c.EncryptChecked(got[:], src); err != nil || got != want {
+		t.Errorf("EncryptChecked=(%x, %v), wanted (%x, nil)", got, err, want)
+	}
+
+	// a fault in the cipher is caught, and nothing is written
+	got = [16]byte{}
+	if err := EncryptChecked(brokenBlock{s}, got[:], src); err != ErrFault {
+		t.Errorf("EncryptChecked(broken)=%v, wanted %v", err, ErrFault)
+	}
+	if got != [16]byte{} {
+		t.Errorf("EncryptChecked(broken) wrote %x", got)
+	}
+}
+
+func TestMeasureTimingVariance(t *testing.T) {
+
+	for _, v := range verifyCiphers[:3] {
+		b, _ := v.ctor(make([]byte, v.klen))
+		mean, stddev := MeasureTimingVariance(b, 1000)
+		t.Logf("%s: mean %v stddev %v", v.name, mean, stddev)
+		if mean < 0 || stddev < 0 {
+			t.Errorf("%s: mean %v stddev %v", v.name, mean, stddev)
+		}
+	}
+
+	s, _ := NewSEED(make([]byte, 16))
+	if mean, stddev := MeasureTimingVariance(s, 0); mean != 0 || stddev != 0 {
+		t.Errorf("no samples: mean %v stddev %v", mean, stddev)
+	}
+}
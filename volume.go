@@ -0,0 +1,120 @@
+package krcrypt
+
+// A minimal passphrase-protected volume key header, in the spirit of LUKS
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+/*
+
+References:
+
+https://gitlab.com/cryptsetup/cryptsetup/wikis/LUKS-standard/on-disk-format.pdf
+
+*/
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+)
+
+// ErrVolumeHeader is returned when a volume header can't be opened: the
+// passphrase is wrong, or the header is corrupt or not a volume header.
+var ErrVolumeHeader = errors.New("krcrypt: wrong passphrase or corrupt volume header")
+
+var volumeMagic = []byte{'K', 'R', 'V', 'H', 1}
+
+// the memory-hard KDF parameters for new headers: 16MB of memory
+var (
+	volumeLogN = 14
+	volumeR    = 8
+)
+
+const (
+	volumeSaltOffset  = 8
+	volumeNonceOffset = volumeSaltOffset + 16
+	volumeKeyOffset   = volumeNonceOffset + 12
+)
+
+// FormatVolumeHeader returns a header holding masterKey, the key that
+// actually encrypts a volume, wrapped under a key derived from the
+// passphrase.  The master key should be random, and 16 to 64 bytes; 32 bytes
+// suits NewEME.  Changing the passphrase only means writing a new header for
+// the same master key, not re-encrypting the volume.
+//
+// The header is the magic "KRVH\x01", the KDF cost as log2(N) and r, the
+// master key length, a random 16-byte salt, a random 12-byte nonce, and then
+// the master key sealed with SEED-GCM, with everything before it as
+// associated data.  The key-encryption key comes from MemoryHardKDF.
+//
+// This is a teaching aid, not LUKS: there is a single key slot, no
+// anti-forensic splitting, and the format is not compatible with cryptsetup.
+func FormatVolumeHeader(passphrase, masterKey []byte) ([]byte, error) {
+
+	if klen := len(masterKey); klen < 16 || klen > 64 {
+		return nil, KeySizeError(klen)
+	}
+
+	header := make([]byte, volumeKeyOffset, volumeKeyOffset+len(masterKey)+16)
+	copy(header, volumeMagic)
+	header[5] = byte(volumeLogN)
+	header[6] = byte(volumeR)
+	header[7] = byte(len(masterKey))
+
+	if _, err := rand.Read(header[volumeSaltOffset:volumeKeyOffset]); err != nil {
+		return nil, err
+	}
+
+	kek, err := volumeKEK(passphrase, header)
+	if err != nil {
+		return nil, err
+	}
+
+	return kek.Seal(header, header[volumeNonceOffset:volumeKeyOffset], masterKey, header[:volumeKeyOffset]), nil
+}
+
+// OpenVolumeHeader unwraps the master key from a header written by
+// FormatVolumeHeader.  It returns ErrVolumeHeader if the passphrase is wrong
+// or the header has been altered.
+func OpenVolumeHeader(passphrase []byte, header []byte) (masterKey []byte, err error) {
+
+	if len(header) < volumeKeyOffset || !bytes.Equal(header[:len(volumeMagic)], volumeMagic) {
+		return nil, ErrVolumeHeader
+	}
+
+	if len(header) != volumeKeyOffset+int(header[7])+16 {
+		return nil, ErrVolumeHeader
+	}
+
+	kek, err := volumeKEK(passphrase, header)
+	if err == ErrKDFParams {
+		return nil, ErrVolumeHeader
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	masterKey, err = kek.Open(nil, header[volumeNonceOffset:volumeKeyOffset], header[volumeKeyOffset:], header[:volumeKeyOffset])
+	if err != nil {
+		return nil, ErrVolumeHeader
+	}
+
+	return masterKey, nil
+}
+
+// volumeKEK derives the key-encryption key with the parameters and salt in the header
+func volumeKEK(passphrase, header []byte) (cipher.AEAD, error) {
+
+	logN := int(header[5])
+	if logN < 1 || logN > 30 {
+		return nil, ErrKDFParams
+	}
+
+	k, err := MemoryHardKDF(passphrase, header[volumeSaltOffset:volumeNonceOffset], 1<<uint(logN), int(header[6]), 16)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewGCM(k)
+}
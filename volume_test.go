@@ -0,0 +1,58 @@
+package krcrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestVolumeHeader(t *testing.T) {
+
+	// keep the test quick
+	defer func(logN, r int) { volumeLogN, volumeR = logN, r }(volumeLogN, volumeR)
+	volumeLogN, volumeR = 4, 2
+
+	master := []byte("0123456789abcdef0123456789abcdef")
+	pass := []byte("correct horse battery staple")
+
+	header, err := FormatVolumeHeader(pass, master)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := OpenVolumeHeader(pass, header)
+	if err != nil || !bytes.Equal(got, master) {
+		t.Fatalf("OpenVolumeHeader=%x, %v wanted %x", got, err, master)
+	}
+
+	if _, err := NewEME(got); err != nil {
+		t.Errorf("NewEME(master key) failed: %v", err)
+	}
+
+	if _, err := OpenVolumeHeader([]byte("Tr0ub4dor&3"), header); err != ErrVolumeHeader {
+		t.Errorf("OpenVolumeHeader(wrong passphrase)=%v, wanted %v", err, ErrVolumeHeader)
+	}
+
+	for i := range header {
+		bad := append([]byte(nil), header...)
+		bad[i] ^= 1
+		if _, err := OpenVolumeHeader(pass, bad); err == nil {
+			t.Errorf("OpenVolumeHeader(byte %d changed) succeeded", i)
+		}
+	}
+
+	if _, err := OpenVolumeHeader(pass, header[:len(header)-1]); err != ErrVolumeHeader {
+		t.Errorf("OpenVolumeHeader(truncated)=%v, wanted %v", err, ErrVolumeHeader)
+	}
+
+	// each header gets its own salt
+	again, _ := FormatVolumeHeader(pass, master)
+	if bytes.Equal(header, again) {
+		t.Errorf("FormatVolumeHeader repeated a header")
+	}
+
+	for _, n := range []int{0, 15, 65} {
+		if _, err := FormatVolumeHeader(pass, make([]byte, n)); err != KeySizeError(n) {
+			t.Errorf("FormatVolumeHeader(%d-byte key)=%v, wanted %v", n, err, KeySizeError(n))
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package krcrypt
+
+// Wiping key material
+// Copyright (c) 2012 Damian Gryski <damian@gryski.com>
+// Licensed under the MIT License
+
+// A Wiper is a cipher whose key material can be zeroed, and checked to have
+// been, before its memory might be dumped or reused.  Every block cipher in
+// the package is one, including those whose constructors return a
+// cipher.Block.
+//
+// Reset zeroes the key material, so it doesn't linger in memory.  It may be
+// called more than once.  A reset cipher still encrypts, but under all-zero
+// key material, which isn't the cipher under any key; make a new cipher
+// rather than reusing it.
+//
+// IsWiped reports whether the key material is all zero, as after Reset.  A
+// real key giving all-zero key material is astronomically unlikely, so this
+// is a practical check that the key is gone.
+//
+// Each type's Reset says which of its tables it clears.
+type Wiper interface {
+	Reset()
+	IsWiped() bool
+}
+
+var (
+	_ Wiper = (*SEEDCipher)(nil)
+	_ Wiper = (*AnubisCipher)(nil)
+	_ Wiper = (*BlowfishCipher)(nil)
+	_ Wiper = (*CamelliaCipher)(nil)
+	_ Wiper = (*CAST6Cipher)(nil)
+	_ Wiper = (*ClefiaCipher)(nil)
+	_ Wiper = (*KhazadCipher)(nil)
+	_ Wiper = (*NoekeonCipher)(nil)
+	_ Wiper = (*RC2Cipher)(nil)
+	_ Wiper = (*RC6Cipher)(nil)
+	_ Wiper = (*SaferCipher)(nil)
+	_ Wiper = (*SerpentCipher)(nil)
+	_ Wiper = (*Threefish256)(nil)
+	_ Wiper = (*TwofishCipher)(nil)
+
+	// returned as a cipher.Block, but a type assertion to Wiper will work
+	_ Wiper = (*ariaCipher)(nil)
+	_ Wiper = (*giftCipher)(nil)
+	_ Wiper = (*hightCipher)(nil)
+	_ Wiper = (*midoriCipher)(nil)
+	_ Wiper = (*rc5Cipher)(nil)
+	_ Wiper = (*speckCipher)(nil)
+)
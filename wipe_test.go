@@ -0,0 +1,70 @@
+package krcrypt
+
+import (
+	"crypto/cipher"
+	"testing"
+)
+
+func TestWiper(t *testing.T) {
+
+	key := []byte("0123456789abcdef")
+	key32 := append(key[:16:16], key...)
+
+	seed, _ := NewSEEDRounds(key, 16)
+	anubis, _ := NewAnubis(key)
+	blowfish, _ := NewBlowfish(key)
+	camellia, _ := NewCamellia(key)
+	cast6, _ := NewCAST6(key)
+	clefia, _ := NewClefia(key)
+	khazad, _ := NewKhazad(key)
+	noekeon, _ := NewNoekeon(key)
+	rc2, _ := NewRC2(key, 128)
+	rc6, _ := NewRC6(key)
+	safer, _ := NewSaferK(key[:8], 8)
+	serpent, _ := NewSerpent(key)
+	threefish, _ := NewThreefish256(key32, key)
+	twofish, _ := NewTwofish(key)
+
+	// the ones that return a cipher.Block
+	for _, ctor := range []func() (cipher.Block, error){
+		func() (cipher.Block, error) { return NewARIA(key) },
+		func() (cipher.Block, error) { return NewGIFT(key, 64) },
+		func() (cipher.Block, error) { return NewHIGHT(key) },
+		func() (cipher.Block, error) { return NewMidori(key, 64) },
+		func() (cipher.Block, error) { return NewMidori(key, 128) },
+		func() (cipher.Block, error) { return NewRC5(key, 32, 12) },
+		func() (cipher.Block, error) { return NewSpeck(key, 128) },
+	} {
+		b, err := ctor()
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, ok := b.(Wiper)
+		if !ok {
+			t.Errorf("%T is not a Wiper", b)
+			continue
+		}
+		if w.IsWiped() {
+			t.Errorf("%T: IsWiped after keying", w)
+		}
+		w.Reset()
+		if !w.IsWiped() {
+			t.Errorf("%T: not IsWiped after Reset", w)
+		}
+	}
+
+	for _, w := range []Wiper{seed, anubis, blowfish, camellia, cast6, clefia, khazad, noekeon, rc2, rc6, safer, serpent, threefish, twofish} {
+		if w.IsWiped() {
+			t.Errorf("%T: IsWiped after keying", w)
+		}
+		w.Reset()
+		if !w.IsWiped() {
+			t.Errorf("%T: not IsWiped after Reset", w)
+		}
+	}
+
+	// a zero SEEDCipher has never held a key
+	if !new(SEEDCipher).IsWiped() {
+		t.Errorf("zero SEEDCipher: not IsWiped")
+	}
+}